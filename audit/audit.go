@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/guinso/gxdoc/util"
+	"github.com/guinso/rdbmstool"
+	"github.com/guinso/stringtool"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+//action names recorded in audit log
+const (
+	ActionAddSchemaInfo     = "schemaInfo.add"
+	ActionUpdateSchemaInfo  = "schemaInfo.update"
+	ActionAddSchema         = "schema.add"
+	ActionSaveSchemaDraft   = "schema.saveDraft"
+	ActionReleaseDraft      = "schema.releaseDraft"
+	ActionImportSchemaDraft = "schema.importDraft"
+	ActionImportBundle      = "schema.importBundle"
+	ActionSaveWorkflow      = "workflow.save"
+	ActionSaveRuleScript    = "ruleScript.save"
+	ActionSaveComputed      = "computed.save"
+	ActionSaveLocales       = "locales.save"
+	ActionSaveNumbering     = "numbering.save"
+	ActionAddRecord         = "record.add"
+	ActionTransitRecord     = "record.transition"
+)
+
+//Entry single audit log record
+type Entry struct {
+	ID             string    `json:"id"`
+	Actor          string    `json:"actor"`
+	Timestamp      time.Time `json:"timestamp"`
+	Action         string    `json:"action"`
+	TargetSchema   string    `json:"schema"`
+	TargetDocument string    `json:"document,omitempty"`
+	Before         string    `json:"before"`
+	After          string    `json:"after"`
+	RequestID      string    `json:"requestId"`
+}
+
+//Filter criteria to query audit log; empty value means no filtering on that field
+type Filter struct {
+	Actor          string
+	Action         string
+	TargetSchema   string
+	TargetDocument string
+	RequestID      string
+	From           time.Time //inclusive
+	To             time.Time //inclusive
+	Limit          int
+	Offset         int
+}
+
+//NewEntry create audit entry with actor and request ID taken from HTTP request
+//	actor is taken by util.GetRequestActor
+//	request ID is taken from 'X-Request-ID' header, a new UUID is generated if not provided
+func NewEntry(r *http.Request, action string, targetSchema string) *Entry {
+	requestID := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+	if requestID == "" {
+		requestID, _ = stringtool.GenerateRandomUUID()
+	}
+
+	return &Entry{
+		Actor:        util.GetRequestActor(r),
+		Timestamp:    time.Now().UTC(),
+		Action:       action,
+		TargetSchema: targetSchema,
+		RequestID:    requestID,
+	}
+}
+
+//Write append audit entry into database
+//NOTE: pass in same transaction handler used by the mutation so both are committed together
+func Write(db rdbmstool.DbHandlerProxy, entry *Entry) error {
+	if entry.ID == "" {
+		newID, idErr := stringtool.GenerateRandomUUID()
+		if idErr != nil {
+			return fmt.Errorf("failed to generate ID for audit entry: %s", idErr.Error())
+		}
+		entry.ID = newID
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	_, dbErr := db.Exec(`INSERT INTO audit_log
+	(id, actor, created, action, target_schema, target_document, before_data, after_data, request_id)
+	VALUES (?,?,?,?,?,?,?,?,?)`,
+		entry.ID, entry.Actor, entry.Timestamp.UTC().Format(timeFormat), entry.Action,
+		entry.TargetSchema, entry.TargetDocument, entry.Before, entry.After, entry.RequestID)
+	if dbErr != nil {
+		return fmt.Errorf("failed to write audit log into database: %s", dbErr.Error())
+	}
+
+	return nil
+}
+
+//Query get audit log records matching filter, newest record come first
+func Query(db rdbmstool.DbHandlerProxy, filter Filter) ([]Entry, error) {
+	conditions := []string{}
+	params := []interface{}{}
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		params = append(params, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		params = append(params, filter.Action)
+	}
+	if filter.TargetSchema != "" {
+		conditions = append(conditions, "target_schema = ?")
+		params = append(params, filter.TargetSchema)
+	}
+	if filter.TargetDocument != "" {
+		conditions = append(conditions, "target_document = ?")
+		params = append(params, filter.TargetDocument)
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = ?")
+		params = append(params, filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created >= ?")
+		params = append(params, filter.From.UTC().Format(timeFormat))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created <= ?")
+		params = append(params, filter.To.UTC().Format(timeFormat))
+	}
+
+	sqlStr := `SELECT id, actor, created, action, target_schema, target_document,
+	before_data, after_data, request_id FROM audit_log`
+	if len(conditions) > 0 {
+		sqlStr += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlStr += " ORDER BY created DESC, id"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	sqlStr += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, filter.Offset)
+
+	rows, rowsErr := db.Query(sqlStr, params...)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []Entry{}
+	for rows.Next() {
+		var item Entry
+		var tmpCreated string
+		scanErr := rows.Scan(&item.ID, &item.Actor, &tmpCreated, &item.Action, &item.TargetSchema,
+			&item.TargetDocument, &item.Before, &item.After, &item.RequestID)
+		if scanErr != nil {
+			if scanErr == sql.ErrNoRows {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to fetch record from database: %s", scanErr.Error())
+		}
+
+		created, timeErr := time.Parse(timeFormat, tmpCreated)
+		if timeErr != nil {
+			return nil, fmt.Errorf("invalid audit timestamp %s: %s", tmpCreated, timeErr.Error())
+		}
+		item.Timestamp = created
+
+		results = append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+	}
+
+	return results, nil
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+func TestWriteAndQuery(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+	defer trx.Rollback()
+
+	entry := Entry{
+		Actor:        "tester",
+		Action:       ActionUpdateSchemaInfo,
+		TargetSchema: "invoice",
+		Before:       `{"desc":"old"}`,
+		After:        `{"desc":"new"}`,
+		RequestID:    "test-request-123",
+	}
+	if err := Write(trx, &entry); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	items, itemsErr := Query(trx, Filter{RequestID: "test-request-123"})
+	if itemsErr != nil {
+		t.Fatal(itemsErr)
+		return
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("expect 1 audit entry but get %d", len(items))
+		return
+	}
+
+	if strings.Compare(items[0].Actor, "tester") != 0 {
+		t.Errorf("expect actor is 'tester' but get '%s'", items[0].Actor)
+	}
+	if strings.Compare(items[0].After, `{"desc":"new"}`) != 0 {
+		t.Errorf("expect after snapshot is kept but get '%s'", items[0].After)
+	}
+}
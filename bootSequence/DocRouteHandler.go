@@ -1,16 +1,20 @@
 package bootSequence
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/guinso/gxdoc/audit"
 	"github.com/guinso/gxdoc/document"
 	"github.com/guinso/gxdoc/util"
 	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
 )
 
 //addNewSchemaInfoItem add schema info data type
@@ -21,7 +25,7 @@ type addNewSchemaInfoItem struct {
 
 //updateSchemaInfoItem update schema info data type
 type updateSchemaInfoItem struct {
-	Name        string `jon:"name"`
+	Name        string `json:"name"`
 	Description string `json:"desc"`
 	IsActive    bool   `json:"isActive"`
 }
@@ -29,6 +33,8 @@ type updateSchemaInfoItem struct {
 var schemaRevisionPattern = regexp.MustCompile(`^document/schemas/.+/revisions/[1-9][0-9]*$`)
 var schemaLatestRevPattern = regexp.MustCompile(`^document/schemas/.+$`)
 var schemaDraftPattern = regexp.MustCompile(`^document/schemas/.+/draft$`)
+var schemaDraftReleasePattern = regexp.MustCompile(`^document/schemas/[^/]+/draft/release$`)
+var schemaInfoPattern = regexp.MustCompile(`^document/schema-infos/[^/]+$`)
 
 //HandleDocSchemaHTTP handle HTTP request
 func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
@@ -78,7 +84,7 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			util.SendHTTPServerErrorJSON(w)
 			return true
 		}
-		err := document.AddSchemaInfo(db, input.Name, input.Description)
+		err := document.AddSchemaInfo(trx, input.Name, input.Description)
 		if err != nil {
 			trx.Rollback()
 			if _, ok := err.(document.ErrSchemaInfoAlreadyExists); ok {
@@ -91,6 +97,14 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 
 			return true
 		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionAddSchemaInfo, input.Name)
+		if newInfo, newErr := document.GetSchemaInfo(trx, input.Name); newErr == nil && newInfo != nil {
+			auditEntry.After = newInfo.JSON()
+		}
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
 		trx.Commit()
 
 		util.SendHTTPResponseJSON(w, "{}")
@@ -122,18 +136,61 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 		}
 
 		schema.ID = "" //hide ID from expose to end user
-		xmlStr, xmlErr := schema.XML()
-		if xmlErr != nil {
-			util.LogError(xmlErr)
+		sendSchemaDefinition(w, r, db, name, schema)
+
+		return true
+
+	} else if schemaDraftReleasePattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//release draft as new revision, together with its rule script, computed fields and locales
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
 			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
 
+		var err error
+		auditEntry := audit.NewEntry(r, audit.ActionReleaseDraft, name)
+		auditEntry.Before, err = getSchemaSnapshot(trx, name, -1)
+		if err == nil {
+			err = document.SaveDraftToNewRevision(trx, name)
+		}
+		if err != nil {
+			trx.Rollback()
+
+			switch typedErr := err.(type) {
+			case document.ErrSchemaInfoNotFound, document.ErrDraftNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, err.Error())
+			case document.ErrSchemaLint:
+				sendLintErrors(w, typedErr)
+			default:
+				util.LogError(err)
+				util.SendHTTPServerErrorJSON(w)
+			}
 			return true
 		}
 
-		util.SendHTTPResponseXML(w, xmlStr)
+		schemaInfo, infoErr := document.GetSchemaInfo(trx, name)
+		if infoErr == nil {
+			auditEntry.After, infoErr = getSchemaSnapshot(trx, name, schemaInfo.LatestRevision)
+		}
+		if infoErr != nil {
+			trx.Rollback()
 
-		return true
+			util.LogError(infoErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
 
+		util.SendHTTPResponseJSON(w, fmt.Sprintf(`{"revision": %d}`, schemaInfo.LatestRevision))
+		return true
 	} else if schemaLatestRevPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
 		//update document schema (input data must be XML)
 		rawArr := strings.Split(sanatizeURL, "/")
@@ -148,9 +205,9 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			return true
 		}
 
-		dxdoc, dxErr := gxschema.ParseSchemaFromXML(bodyStr)
+		dxdoc, refs, includes, dxErr := parseSchemaDefinition(r, bodyStr)
 		if dxErr != nil {
-			util.SendHTTPClientErrorJSON(w, 400, -1, "invalid XML: "+dxErr.Error())
+			util.SendHTTPClientErrorJSON(w, 400, -1, "invalid input data: "+dxErr.Error())
 			return true
 		}
 
@@ -162,7 +219,9 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 
 			return true
 		}
-		_, err := document.AddSchema(trx, name, dxdoc, "")
+		var err error
+		auditEntry := audit.NewEntry(r, audit.ActionAddSchema, name)
+		auditEntry.Before, err = getSchemaSnapshot(trx, name, 0)
 		if err != nil {
 			trx.Rollback()
 
@@ -170,9 +229,48 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			util.SendHTTPServerErrorJSON(w)
 			return true
 		}
+
+		newRev, err := document.AddSchema(trx, name, dxdoc, "")
+		if err == nil {
+			err = document.SaveReferences(trx, name, newRev, refs)
+		}
+		if err == nil {
+			err = saveSchemaIncludes(trx, name, newRev, includes)
+		}
+		if err == nil {
+			err = document.SealSchemaRevision(trx, name, newRev)
+		}
+		if err != nil {
+			trx.Rollback()
+
+			if lintErr, ok := err.(document.ErrSchemaLint); ok {
+				sendLintErrors(w, lintErr)
+				return true
+			}
+			if isSchemaDefinitionError(err) {
+				util.SendHTTPClientErrorJSON(w, 400, -1, err.Error())
+				return true
+			}
+
+			util.LogError(err)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry.After, err = getSchemaSnapshot(trx, name, newRev)
+		if err != nil {
+			trx.Rollback()
+
+			util.LogError(err)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
 		trx.Commit()
 
-		util.SendHTTPResponseJSON(w, "{}")
+		sendLintWarnings(w, document.LintSchema(dxdoc))
 		return true
 
 	} else if schemaLatestRevPattern.MatchString(sanatizeURL) && util.IsGET(r) {
@@ -195,16 +293,7 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 		}
 
 		schema.ID = "" //hide ID from expose to end user
-		xmlStr, xmlErr := schema.XML()
-		if xmlErr != nil {
-			util.LogError(xmlErr)
-			util.SendHTTPServerErrorJSON(w)
-
-			return true
-		}
-
-		//TODO: include XSD reference as well
-		util.SendHTTPResponseXML(w, xmlStr)
+		sendSchemaDefinition(w, r, db, name, schema)
 
 		return true
 	} else if schemaDraftPattern.MatchString(sanatizeURL) && util.IsGET(r) {
@@ -227,15 +316,7 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 		}
 
 		schema.ID = "" //hide ID from end user
-		xmlStr, xmlErr := schema.XML()
-		if xmlErr != nil {
-			util.LogError(xmlErr)
-			util.SendHTTPServerErrorJSON(w)
-
-			return true
-		}
-
-		util.SendHTTPResponseXML(w, xmlStr)
+		sendSchemaDefinition(w, r, db, name, schema)
 
 		return true
 	} else if schemaDraftPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
@@ -251,7 +332,7 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			return true
 		}
 
-		gxdoc, gxErr := gxschema.ParseSchemaFromXML(string(bodyRaw))
+		gxdoc, refs, includes, gxErr := parseSchemaDefinition(r, string(bodyRaw))
 		if gxErr != nil {
 			util.SendHTTPClientErrorJSON(w, 400, -1, "invalid input data: "+gxErr.Error())
 			return true
@@ -264,21 +345,59 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			util.SendHTTPServerErrorJSON(w)
 			return true
 		}
+		auditEntry := audit.NewEntry(r, audit.ActionSaveSchemaDraft, name)
+		var snapshotErr error
+		auditEntry.Before, snapshotErr = getSchemaSnapshot(trx, name, -1)
+		if snapshotErr != nil {
+			trx.Rollback()
+
+			util.LogError(snapshotErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
 		saveDraftErr := document.SaveSchemaAsDraft(trx, name, gxdoc, "")
+		if saveDraftErr == nil {
+			saveDraftErr = document.SaveReferences(trx, name, -1, refs)
+		}
+		if saveDraftErr == nil {
+			saveDraftErr = saveSchemaIncludes(trx, name, -1, includes)
+		}
 		if saveDraftErr != nil {
 			trx.Rollback()
 
+			if lintErr, ok := saveDraftErr.(document.ErrSchemaLint); ok {
+				sendLintErrors(w, lintErr)
+				return true
+			}
+			if isSchemaDefinitionError(saveDraftErr) {
+				util.SendHTTPClientErrorJSON(w, 400, -1, saveDraftErr.Error())
+				return true
+			}
+
 			util.LogError(saveDraftErr)
 			util.SendHTTPServerErrorJSON(w)
 			return true
 		}
+
+		auditEntry.After, snapshotErr = getSchemaSnapshot(trx, name, -1)
+		if snapshotErr != nil {
+			trx.Rollback()
+
+			util.LogError(snapshotErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
 		trx.Commit()
 
-		util.SendHTTPResponseJSON(w, "{}")
+		sendLintWarnings(w, document.LintSchema(gxdoc))
 		return true
-	} else if strings.HasPrefix(sanatizeURL, "document/schemas/") && util.IsGET(r) {
+	} else if schemaInfoPattern.MatchString(sanatizeURL) && util.IsGET(r) {
 		//get single schema info
-		name := sanatizeURL[17:]
+		name := strings.Split(sanatizeURL, "/")[2]
 
 		db := util.GetDB()
 
@@ -296,11 +415,11 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 
 		util.SendHTTPResponseJSON(w, schemaInfo.JSON())
 		return true
-	} else if strings.HasPrefix(sanatizeURL, "document/schemas/") && util.IsPOST(r) {
+	} else if schemaInfoPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
 		//update schema info
 		db := util.GetDB()
 
-		name := sanatizeURL[17:]
+		name := strings.Split(sanatizeURL, "/")[2]
 		schemaInfo, infoErr := document.GetSchemaInfo(db, name)
 		if infoErr != nil {
 			util.LogError(infoErr)
@@ -321,7 +440,12 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 				"unable to process user input, please check your input data format")
 			return true
 		}
-		schemaInfo.Name = updateItem.Name
+		auditEntry := audit.NewEntry(r, audit.ActionUpdateSchemaInfo, name)
+		auditEntry.Before = schemaInfo.JSON()
+
+		if updateItem.Name != "" {
+			schemaInfo.Name = updateItem.Name //keep current name if not supplied
+		}
 		schemaInfo.Description = updateItem.Description
 		schemaInfo.IsActive = updateItem.IsActive
 
@@ -344,6 +468,11 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 			util.SendHTTPServerErrorJSON(w)
 			return true
 		}
+
+		auditEntry.After = schemaInfo.JSON()
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
 		trx.Commit()
 
 		util.SendHTTPResponseJSON(w, "{}")
@@ -352,3 +481,218 @@ func HandleDocSchemaHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Requ
 
 	return false
 }
+
+//getSchemaXML export schema into XML definition, with reference items written as <dxref>
+func getSchemaXML(db rdbmstool.DbHandlerProxy, name string, schema *gxschema.DxDoc) (string, error) {
+	xmlStr, xmlErr := schema.XML()
+	if xmlErr != nil {
+		return "", xmlErr
+	}
+
+	refs, refsErr := document.GetReferences(db, name, schema.Revision)
+	if refsErr != nil {
+		return "", refsErr
+	}
+
+	return document.ApplyReferences(xmlStr, refs)
+}
+
+//getDeclaredSchemaXML write includes of schema revision back into its declared XML definition
+func getDeclaredSchemaXML(db rdbmstool.DbHandlerProxy, name string, revision int, xmlStr string) (string, error) {
+	includes, includeErr := document.GetIncludes(db, name, revision)
+	if includeErr != nil {
+		return "", includeErr
+	}
+
+	return document.ApplyIncludes(xmlStr, includes)
+}
+
+//acceptJSON check client ask for JSON instead of XML through Accept header
+//	first media type recognized wins, quality value is not considered; XML is default
+func acceptJSON(r *http.Request) bool {
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		switch strings.TrimSpace(strings.Split(mediaRange, ";")[0]) {
+		case "application/json":
+			return true
+		case "text/xml", "application/xml", "*/*":
+			return false
+		}
+	}
+
+	return false
+}
+
+//sendSchemaDefinition send schema definition in JSON if client accept JSON, otherwise in XML
+//	label and description of locale best matching Accept-Language header are included
+func sendSchemaDefinition(w http.ResponseWriter, r *http.Request, db rdbmstool.DbHandlerProxy,
+	name string, schema *gxschema.DxDoc) {
+	//response format and labels depend on request headers; tell caches so
+	w.Header().Set("Vary", "Accept, Accept-Language")
+
+	locale, localeErr := document.GetSchemaLocale(db, name, schema.Revision, r.Header.Get("Accept-Language"))
+	if localeErr != nil {
+		util.LogError(localeErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+	if locale != nil {
+		w.Header().Set("Content-Language", locale.Locale)
+	}
+
+	if acceptJSON(r) {
+		if r.URL.Query().Get("flatten") == "false" {
+			util.SendHTTPClientErrorJSON(w, 400, -1, "flatten=false is only supported by XML definition")
+			return
+		}
+
+		refs, refsErr := document.GetReferences(db, name, schema.Revision)
+		if refsErr != nil {
+			util.LogError(refsErr)
+			util.SendHTTPServerErrorJSON(w)
+			return
+		}
+
+		jsonStr, jsonErr := document.SchemaToLocalizedJSON(schema, refs, locale)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return
+		}
+
+		util.SendHTTPResponseJSON(w, jsonStr)
+		return
+	}
+
+	declared := r.URL.Query().Get("flatten") == "false"
+	if declared {
+		//declared definition keeps <dxinclude> so it can be edited and submitted again
+		declaredSchema, declaredErr := document.GetDeclaredSchemaByRevision(db, name, schema.Revision)
+		if declaredErr != nil {
+			util.LogError(declaredErr)
+			util.SendHTTPServerErrorJSON(w)
+			return
+		}
+		if declaredSchema == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "schema revision not found")
+			return
+		}
+		declaredSchema.ID = ""
+		schema = declaredSchema
+	}
+
+	var xmlStr string
+	var xmlErr error
+	if schema.Revision > 0 {
+		xmlStr, xmlErr = getReleasedSchemaXML(db, name, schema)
+	} else {
+		xmlStr, xmlErr = getSchemaXML(db, name, schema) //draft has no XSD
+	}
+	if xmlErr == nil && declared {
+		xmlStr, xmlErr = getDeclaredSchemaXML(db, name, schema.Revision, xmlStr)
+	}
+	if xmlErr == nil && locale != nil {
+		xmlStr, xmlErr = document.ApplyLocaleToXML(xmlStr, locale)
+	}
+	if xmlErr != nil {
+		util.LogError(xmlErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseXML(w, xmlStr)
+}
+
+//parseSchemaDefinition parse submitted schema definition in JSON or XML format according to Content-Type
+//	includes are only supported by XML definition
+func parseSchemaDefinition(r *http.Request, body string) (
+	*gxschema.DxDoc, []document.Reference, []document.Include, error) {
+	if strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]) == "application/json" {
+		schema, refs, jsonErr := document.ParseSchemaFromJSON(body)
+		return schema, refs, []document.Include{}, jsonErr
+	}
+
+	includeStr, includes, includeErr := document.ExtractIncludes(body)
+	if includeErr != nil {
+		return nil, nil, nil, includeErr
+	}
+
+	xmlStr, refs, refErr := document.ExtractReferences(includeStr)
+	if refErr != nil {
+		return nil, nil, nil, refErr
+	}
+
+	schema, schemaErr := gxschema.ParseSchemaFromXML(xmlStr)
+	if schemaErr != nil {
+		return nil, nil, nil, schemaErr
+	}
+
+	return schema, refs, includes, nil
+}
+
+//saveSchemaIncludes save includes of schema revision and make sure it can be flattened
+func saveSchemaIncludes(db rdbmstool.DbHandlerProxy, name string, revision int, includes []document.Include) error {
+	if err := document.SaveIncludes(db, name, revision, includes); err != nil {
+		return err
+	}
+
+	_, flattenErr := document.GetSchemaByRevision(db, name, revision)
+	return flattenErr
+}
+
+//isSchemaDefinitionError check error is caused by submitted schema definition instead of server
+func isSchemaDefinitionError(err error) bool {
+	switch err.(type) {
+	case document.ErrReferenceNotFound, document.ErrInvalidInclude:
+		return true
+	}
+
+	return false
+}
+
+//getReleasedSchemaXML get XML definition of released schema revision
+//	with dataSchemaLocation pointing to XSD of document data, so XML producer can validate offline
+func getReleasedSchemaXML(db rdbmstool.DbHandlerProxy, name string, schema *gxschema.DxDoc) (string, error) {
+	xmlStr, xmlErr := getSchemaXML(db, name, schema)
+	if xmlErr != nil {
+		return "", xmlErr
+	}
+
+	return document.SetDataSchemaLocation(xmlStr, document.GetXSDURL(name, schema.Revision))
+}
+
+//getSchemaSnapshot get XML definition of schema revision as audit snapshot
+//	revision 0 means latest revision, -1 means draft
+//	return empty string if no such revision
+func getSchemaSnapshot(db *sql.Tx, name string, revision int) (string, error) {
+	var schema *gxschema.DxDoc
+	var schemaErr error
+	if revision == 0 {
+		schema, schemaErr = document.GetSchema(db, name)
+	} else {
+		schema, schemaErr = document.GetSchemaByRevision(db, name, revision)
+	}
+
+	if schemaErr != nil {
+		return "", schemaErr
+	}
+
+	if schema == nil {
+		return "", nil
+	}
+
+	return schema.XML()
+}
+
+//writeAuditLog write audit entry within transaction
+//on failure, transaction is rolled back and error response is sent; return false
+func writeAuditLog(trx *sql.Tx, w http.ResponseWriter, entry *audit.Entry) bool {
+	if err := audit.Write(trx, entry); err != nil {
+		trx.Rollback()
+
+		util.LogError(err)
+		util.SendHTTPServerErrorJSON(w)
+		return false
+	}
+
+	return true
+}
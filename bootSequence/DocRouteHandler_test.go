@@ -0,0 +1,26 @@
+package bootSequence
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSchemaDefinitionVaryHeader(t *testing.T) {
+	setupValidateTestDB(t)
+
+	for _, accept := range []string{"application/json", "application/xml"} {
+		r := httptest.NewRequest("GET", "/api/document/schemas/invoice", nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+
+		if !HandleDocSchemaHTTP("document/schemas/invoice", w, r) {
+			t.Fatal("expect schema request is handled")
+		}
+		if w.Code != 200 {
+			t.Fatalf("expect HTTP 200 for %s but get %d", accept, w.Code)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept, Accept-Language" {
+			t.Errorf("expect Vary header 'Accept, Accept-Language' for %s but get '%s'", accept, vary)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package bootSequence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/luascript"
+	"github.com/guinso/gxschema"
+	lua "github.com/yuin/gopher-lua"
+)
+
+//recordValidator validate and complete document data submitted against a schema revision:
+//structural validation, computed fields, then cross-field rules
+type recordValidator struct {
+	schema *gxschema.DxDoc
+	fields []document.ComputedField
+	rule   *document.RuleScript
+	locale *document.SchemaLocale //nil if no locale matches Accept-Language header
+	useLua bool                   //false if schema revision has no computed field nor rule script
+}
+
+//newRecordValidator load computed fields, rule script and locale of schema revision
+func newRecordValidator(db *sql.DB, r *http.Request, schema *gxschema.DxDoc) (*recordValidator, error) {
+	fields, fieldsErr := document.GetComputedFields(db, schema.Name, schema.Revision)
+	if fieldsErr != nil {
+		return nil, fieldsErr
+	}
+
+	rule, ruleErr := document.GetRuleScript(db, schema.Name, schema.Revision)
+	if ruleErr != nil {
+		return nil, ruleErr
+	}
+
+	locale, localeErr := document.GetSchemaLocale(db, schema.Name, schema.Revision, r.Header.Get("Accept-Language"))
+	if localeErr != nil {
+		return nil, localeErr
+	}
+
+	validator := recordValidator{
+		schema: schema,
+		fields: fields,
+		rule:   rule,
+		locale: locale,
+		useLua: len(fields) > 0 || strings.TrimSpace(rule.Script) != ""}
+
+	return &validator, nil
+}
+
+//newRunner create a fresh Lua sandbox for one record so globals set by
+//scripts never leak into next record of same batch
+//return nil if schema revision has no computed field nor rule script
+func (validator *recordValidator) newRunner() *lua.LState {
+	if !validator.useLua {
+		return nil
+	}
+
+	return luascript.GetSandboxRunner()
+}
+
+//ValidateJSON validate JSON data and fill in computed fields
+//return completed JSON data if no violation found; violations are localized
+func (validator *recordValidator) ValidateJSON(jsonStr string) (string, []document.Violation, error) {
+	result, violations, err := validator.validateJSON(jsonStr)
+
+	return result, document.LocalizeViolations(validator.schema, validator.locale, violations), err
+}
+
+//ValidateXML validate XML data; computed fields are not filled in but must not be supplied
+//violations are localized
+func (validator *recordValidator) ValidateXML(xmlStr string) ([]document.Violation, error) {
+	violations, err := validator.validateXML(xmlStr)
+
+	return document.LocalizeViolations(validator.schema, validator.locale, violations), err
+}
+
+func (validator *recordValidator) validateJSON(jsonStr string) (string, []document.Violation, error) {
+	violations := document.ValidateSubmissionFromJSON(jsonStr, validator.schema, validator.fields)
+	if len(violations) > 0 {
+		return "", violations, nil
+	}
+
+	runner := validator.newRunner()
+	if runner != nil {
+		defer runner.Close()
+	}
+
+	data, _ := document.DecodeJSONData(jsonStr)
+	if len(validator.fields) > 0 {
+		if err := document.ApplyComputedFields(runner, validator.schema, data, validator.fields); err != nil {
+			return "", nil, err
+		}
+
+		jsonRaw, jsonErr := json.Marshal(data)
+		if jsonErr != nil {
+			return "", nil, jsonErr
+		}
+		jsonStr = string(jsonRaw)
+
+		//computed values must satisfy schema as well
+		if violations = document.ValidateDataFromJSON(jsonStr, validator.schema); len(violations) > 0 {
+			return "", violations, nil
+		}
+	}
+
+	violations, runErr := validator.runRules(runner, data)
+	if runErr != nil || len(violations) > 0 {
+		return "", violations, runErr
+	}
+
+	return jsonStr, violations, nil
+}
+
+func (validator *recordValidator) validateXML(xmlStr string) ([]document.Violation, error) {
+	violations := document.FilterComputedViolations(
+		document.ValidateDataFromXML(xmlStr, validator.schema), validator.fields)
+
+	data, xmlErr := document.ParseXMLData(xmlStr)
+	if xmlErr == nil {
+		violations = append(document.CheckComputedData(data, validator.fields), violations...)
+	}
+	if len(violations) > 0 {
+		return violations, nil
+	}
+
+	runner := validator.newRunner()
+	if runner == nil {
+		return []document.Violation{}, nil
+	}
+	defer runner.Close()
+
+	//XML leaf values are plain text; give rules same types as JSON submission
+	return validator.runRules(runner, document.TypeXMLData(data, validator.schema.Items))
+}
+
+func (validator *recordValidator) runRules(runner *lua.LState, data map[string]interface{}) ([]document.Violation, error) {
+	if runner == nil {
+		return []document.Violation{}, nil
+	}
+
+	return validator.rule.Run(runner, data)
+}
@@ -0,0 +1,55 @@
+package bootSequence
+
+import (
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+func getRecordValidatorTest(script string) *recordValidator {
+	return &recordValidator{
+		schema: &gxschema.DxDoc{
+			Name:     "order",
+			Revision: 1,
+			Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxSection{Name: "lines", IsArray: true, Items: []gxschema.DxItem{
+					gxschema.DxStr{Name: "sku"},
+				}},
+			},
+		},
+		fields: []document.ComputedField{},
+		rule:   &document.RuleScript{Script: script},
+		useLua: true}
+}
+
+func TestRecordValidatorFreshRunner(t *testing.T) {
+	validator := getRecordValidatorTest(`
+if seen then addError("/qty", "global leaked from previous record") end
+seen = true`)
+
+	for index := 0; index < 2; index++ {
+		_, violations, err := validator.ValidateJSON(`{"qty": 1, "lines": [{"sku": "A"}]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(violations) > 0 {
+			t.Errorf("expect record %d has no violation but get %v", index, violations)
+		}
+	}
+}
+
+func TestRecordValidatorTypedXML(t *testing.T) {
+	validator := getRecordValidatorTest(`
+if type(doc.qty) ~= "number" then addError("/qty", "qty is not a number") end
+if #doc.lines ~= 1 then addError("/lines", "lines is not an array") end`)
+
+	violations, err := validator.ValidateXML(`<order><qty>2</qty><lines><sku>A</sku></lines></order>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("expect XML data is typed by schema but get %v", violations)
+	}
+}
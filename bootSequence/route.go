@@ -60,7 +60,9 @@ func HandleRouting(w http.ResponseWriter, r *http.Request) {
 		} else {
 			//log.Println("route handle by development static file")
 			//other wise, lets read a file path and display to client
-			http.ServeFile(w, r, "./"+devStaticPath+"/"+urlPath)
+			if !HandleFormHTTP(urlPath, w, r) {
+				http.ServeFile(w, r, "./"+devStaticPath+"/"+urlPath)
+			}
 		}
 
 	} else {
@@ -76,6 +78,7 @@ func HandleRouting(w http.ResponseWriter, r *http.Request) {
 		} else {
 			//log.Println("route handle by production stati file")
 			//other wise, lets read a file path and display to client
+			//NOTE: HTML form is development only, since record API it posts to is not routed in production
 			http.ServeFile(w, r, "./"+staticPath+"/"+urlPath)
 		}
 	}
@@ -85,10 +88,40 @@ func HandleRouting(w http.ResponseWriter, r *http.Request) {
 func routeDevelopmentAPI(w http.ResponseWriter, r *http.Request, url string) {
 	//TODO: how to check requstor ID and determine her authority?
 
-	if HandleDocSchemaHTTP(url, w, r) {
+	if HandleOpenAPIHTTP(url, w, r) {
+		return
+	} else if HandleSchemaVerifyHTTP(url, w, r) {
+		return
+	} else if HandleRuleScriptHTTP(url, w, r) {
+		return
+	} else if HandleComputedFieldHTTP(url, w, r) {
+		return
+	} else if HandleSchemaLocaleHTTP(url, w, r) {
+		return
+	} else if HandleSchemaExportHTTP(url, w, r) {
+		return
+	} else if HandleSchemaImportHTTP(url, w, r) {
+		return
+	} else if HandleSchemaBundleHTTP(url, w, r) {
+		return
+	} else if HandleSchemaDependentHTTP(url, w, r) {
+		return
+	} else if HandleSchemaLintHTTP(url, w, r) {
+		return
+	} else if HandleDocSchemaHTTP(url, w, r) {
 		return
 	} else if HandleDataValidationHTTP(url, w, r) {
 		return
+	} else if HandleBatchValidationHTTP(url, w, r) {
+		return
+	} else if HandleAuditHTTP(url, w, r) {
+		return
+	} else if HandleWorkflowHTTP(url, w, r) {
+		return
+	} else if HandleNumberingHTTP(url, w, r) {
+		return
+	} else if HandleRecordHTTP(url, w, r) {
+		return
 	}
 	// if done {
 	// 	return
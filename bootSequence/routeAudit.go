@@ -0,0 +1,98 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/util"
+)
+
+//HandleAuditHTTP handle HTTP routing for querying audit log
+func HandleAuditHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if sanatizeURL != "audit" || !util.IsGET(r) {
+		return false //URL pattern not match
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Actor:          query.Get("actor"),
+		Action:         query.Get("action"),
+		TargetSchema:   query.Get("schema"),
+		TargetDocument: query.Get("document"),
+		RequestID:      query.Get("requestId"),
+	}
+
+	var parseErr error
+	if filter.From, parseErr = parseAuditTime(query.Get("from")); parseErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "invalid 'from' value, please use RFC3339 or YYYY-MM-DD format")
+		return true
+	}
+	if filter.To, parseErr = parseAuditTime(query.Get("to")); parseErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "invalid 'to' value, please use RFC3339 or YYYY-MM-DD format")
+		return true
+	}
+	if _, err := time.Parse(auditDateFormat, query.Get("to")); err == nil {
+		//date only 'to' cover whole day
+		filter.To = filter.To.Add(24*time.Hour - time.Second)
+	}
+	if filter.Limit, parseErr = parseAuditInt(query.Get("limit")); parseErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "invalid 'limit' value (only accept integer)")
+		return true
+	}
+	if filter.Offset, parseErr = parseAuditInt(query.Get("offset")); parseErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "invalid 'offset' value (only accept integer)")
+		return true
+	}
+
+	entries, entriesErr := audit.Query(util.GetDB(), filter)
+	if entriesErr != nil {
+		util.LogError(entriesErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	jsonRaw, jsonErr := json.Marshal(entries)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+	return true
+}
+
+//auditDateFormat date only format accepted by 'from' and 'to'
+const auditDateFormat = "2006-01-02"
+
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if tmp, err := time.Parse(time.RFC3339, value); err == nil {
+		return tmp, nil
+	}
+
+	return time.Parse(auditDateFormat, value)
+}
+
+func parseAuditInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	tmp, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if tmp < 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return tmp, nil
+}
@@ -0,0 +1,94 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var batchValidatePattern = regexp.MustCompile(`^document/[^/]+/validate/batch$`)
+
+//HandleBatchValidationHTTP handle HTTP routing for batch data validation
+//result of every document is streamed back as newline delimited JSON
+func HandleBatchValidationHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !batchValidatePattern.MatchString(sanatizeURL) || !util.IsPOST(r) {
+		return false //URL pattern not match
+	}
+
+	var format string
+	switch strings.Split(r.Header.Get("Content-Type"), ";")[0] {
+	case "application/json":
+		format = document.BatchJSONArray
+	case "application/x-ndjson":
+		format = document.BatchNDJSON
+	case "text/xml":
+		format = document.BatchXML
+	default:
+		util.SendHTTPClientErrorJSON(w, 415, -1,
+			"batch input only accept JSON array, newline delimited JSON or XML envelope")
+		return true
+	}
+
+	//schema is parsed once and shared by whole batch; same revision selection as single validation
+	db := util.GetDB()
+	docSchemaName := strings.Split(sanatizeURL, "/")[1]
+	docSchema, found := getValidationSchema(db, w, r, docSchemaName)
+	if !found {
+		return true
+	}
+
+	//every worker own a validator since Lua runner can't be shared across goroutines
+	validators := []document.BatchValidator{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		validator, validatorErr := newRecordValidator(db, r, docSchema)
+		if validatorErr != nil {
+			util.LogError(validatorErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		if format == document.BatchXML {
+			validators = append(validators, validator.ValidateXML)
+		} else {
+			validators = append(validators, func(data string) ([]document.Violation, error) {
+				_, violations, err := validator.ValidateJSON(data)
+				return violations, err
+			})
+		}
+
+		if i == 0 && validator.locale != nil {
+			w.Header().Set("Content-Language", validator.locale.Locale)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf8")
+	w.WriteHeader(200)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w) //Encode append newline after every result
+
+	batchErr := document.ValidateBatch(r.Body, format, validators,
+		func(result document.BatchResult) {
+			if result.Errors == nil {
+				result.Errors = []document.Violation{}
+			}
+
+			if err := encoder.Encode(result); err != nil {
+				util.LogError(err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		})
+	if batchErr != nil {
+		util.LogError(batchErr)
+	}
+
+	return true
+}
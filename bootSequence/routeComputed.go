@@ -0,0 +1,110 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaDraftComputedPattern = regexp.MustCompile(`^document/schemas/[^/]+/draft/computed$`)
+var schemaRevisionComputedPattern = regexp.MustCompile(`^document/schemas/[^/]+/revisions/[1-9][0-9]*/computed$`)
+
+//HandleComputedFieldHTTP handle HTTP routing for schema computed fields
+func HandleComputedFieldHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if schemaRevisionComputedPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get computed fields of released revision
+		rawArr := strings.Split(sanatizeURL, "/")
+		revision, _ := strconv.Atoi(rawArr[4])
+
+		sendComputedFields(w, rawArr[2], revision)
+		return true
+	} else if schemaDraftComputedPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get computed fields of draft
+		sendComputedFields(w, strings.Split(sanatizeURL, "/")[2], -1)
+		return true
+	} else if schemaDraftComputedPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//update computed fields of draft
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		fields, parseErr := document.ParseComputedFieldsFromJSON(body)
+		if parseErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, parseErr.Error())
+			return true
+		}
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionSaveComputed, name)
+		if oldFields, oldErr := document.GetComputedFields(trx, name, -1); oldErr == nil {
+			oldRaw, _ := json.Marshal(oldFields)
+			auditEntry.Before = string(oldRaw)
+		}
+
+		saveErr := document.SaveDraftComputedFields(trx, name, fields)
+		if saveErr != nil {
+			trx.Rollback()
+
+			switch saveErr.(type) {
+			case document.ErrSchemaInfoNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+			case document.ErrDraftNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no draft")
+			case document.ErrInvalidComputedField:
+				util.SendHTTPClientErrorJSON(w, 400, -1, saveErr.Error())
+			default:
+				util.LogError(saveErr)
+				util.SendHTTPServerErrorJSON(w)
+			}
+			return true
+		}
+
+		newRaw, _ := json.Marshal(fields)
+		auditEntry.After = string(newRaw)
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, "{}")
+		return true
+	}
+
+	return false
+}
+
+func sendComputedFields(w http.ResponseWriter, name string, revision int) {
+	fields, fieldsErr := document.GetComputedFields(util.GetDB(), name, revision)
+	if fieldsErr != nil {
+		util.LogError(fieldsErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	jsonRaw, jsonErr := json.Marshal(fields)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+}
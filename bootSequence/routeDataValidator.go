@@ -1,9 +1,13 @@
 package bootSequence
 
 import (
-	"fmt"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/guinso/gxdoc/document"
@@ -12,7 +16,10 @@ import (
 	"github.com/guinso/gxdoc/util"
 )
 
-var dataValidatePattern = regexp.MustCompile(`^document/.+/validate$`)
+var dataValidatePattern = regexp.MustCompile(`^document/[^/]+/validate$`)
+
+//validateBodyLimit maximum size (in bytes) of data accepted by validate endpoint
+var validateBodyLimit int64 = 4 << 20
 
 //HandleDataValidationHTTP handle HTTP routing for data validation
 func HandleDataValidationHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
@@ -20,42 +27,161 @@ func HandleDataValidationHTTP(sanatizeURL string, w http.ResponseWriter, r *http
 		return false //URL pattern not match
 	}
 
-	docSchemaName := strings.Split(sanatizeURL, "/")[1]
-	docSchema, schemaErr := document.GetSchema(util.GetDB(), docSchemaName)
-	if schemaErr != nil {
-		//TODO: log error
-		util.LogError(schemaErr)
+	dataTypeRaw := strings.Split(r.Header.Get("Content-Type"), ";")[0]
+	if strings.Compare("application/json", dataTypeRaw) != 0 && strings.Compare("text/xml", dataTypeRaw) != 0 {
+		util.SendHTTPClientErrorJSON(w, 415, -1, "input data type only accept either JSON nor XML")
+		return true
+	}
+
+	if r.ContentLength > validateBodyLimit {
+		util.SendHTTPClientErrorJSON(w, 413, -1, "input data is too large")
+		return true
+	}
+
+	//read one byte beyond limit to detect oversized body without Content-Length
+	bodyRaw, bodyErr := ioutil.ReadAll(io.LimitReader(r.Body, validateBodyLimit+1))
+	if bodyErr != nil {
+		util.LogError(bodyErr)
 		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+	if int64(len(bodyRaw)) > validateBodyLimit {
+		util.SendHTTPClientErrorJSON(w, 413, -1, "input data is too large")
+		return true
+	}
+	inputStr := string(bodyRaw)
+
+	db := util.GetDB()
+	docSchemaName := strings.Split(sanatizeURL, "/")[1]
+	docSchema, found := getValidationSchema(db, w, r, docSchemaName)
+	if !found {
+		return true
 	}
 
-	inputStr, inputErr := util.GetHTTPRequestBody(r)
-	if inputErr != nil {
-		//TODO: log error
-		util.LogError(inputErr)
+	validator, validatorErr := newRecordValidator(db, r, docSchema)
+	if validatorErr != nil {
+		util.LogError(validatorErr)
 		util.SendHTTPServerErrorJSON(w)
+		return true
 	}
 
 	//validate data in JSON or XML format
-	dataTypeRaw := strings.Split(r.Header.Get("Content-Type"), ";")[0]
+	var violations []document.Violation
+	var validateErr error
 	if strings.Compare("application/json", dataTypeRaw) == 0 {
-		invalid := gxschema.ValidateDataFromJSON(inputStr, docSchema)
-		if invalid == nil {
-			util.SendHTTPResponseJSON(w, `{"isValid":true, "message":""}`)
-		} else {
-			util.SendHTTPResponseJSON(w,
-				fmt.Sprintf(`{"isValid":false, "message":"%s"}`, invalid.Error()))
-		}
-	} else if strings.Compare("text/xml", dataTypeRaw) == 0 {
-		invalid := gxschema.ValidateDataFromXML(inputStr, docSchema)
-		if invalid == nil {
-			util.SendHTTPResponseJSON(w, `{"isValid":true, "message":""}`)
-		} else {
-			util.SendHTTPResponseJSON(w,
-				fmt.Sprintf(`{"isValid":false, "message":"%s"}`, invalid.Error()))
-		}
+		_, violations, validateErr = validator.ValidateJSON(inputStr)
 	} else {
-		util.SendHTTPClientErrorJSON(w, 400, -1, "input data type only accept either JSON nor XML")
+		violations, validateErr = validator.ValidateXML(inputStr)
+	}
+	if validateErr != nil {
+		util.LogError(validateErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
 	}
 
+	sendValidationResult(w, docSchema, violations)
 	return true
 }
+
+//getValidationSchema get schema revision to validate against, based on 'revision' or 'draft' URL parameter;
+//latest revision is used if neither is specified
+//return false if schema is not available and error response has been sent
+func getValidationSchema(db *sql.DB, w http.ResponseWriter, r *http.Request, docSchemaName string) (*gxschema.DxDoc, bool) {
+	schemaInfo, infoErr := document.GetSchemaInfo(db, docSchemaName)
+	if infoErr != nil {
+		util.LogError(infoErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, false
+	}
+	if schemaInfo == nil || !schemaInfo.IsActive {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+		return nil, false
+	}
+
+	query := r.URL.Query()
+	isDraft := strings.Compare(query.Get("draft"), "true") == 0
+	revisionStr := query.Get("revision")
+	if isDraft && revisionStr != "" {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "either 'revision' or 'draft' can be specified, not both")
+		return nil, false
+	}
+
+	var docSchema *gxschema.DxDoc
+	var schemaErr error
+	if isDraft {
+		docSchema, schemaErr = document.GetDraftSchema(db, docSchemaName)
+	} else if revisionStr != "" {
+		revision, revErr := strconv.Atoi(revisionStr)
+		if revErr != nil || revision < 1 {
+			util.SendHTTPClientErrorJSON(w, 400, -1, "revision must be a positive integer")
+			return nil, false
+		}
+
+		docSchema, schemaErr = document.GetSchemaByRevision(db, docSchemaName, revision)
+	} else {
+		docSchema, schemaErr = document.GetSchema(db, docSchemaName)
+	}
+	if schemaErr != nil {
+		util.LogError(schemaErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, false
+	}
+	if docSchema == nil {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "schema revision not found")
+		return nil, false
+	}
+
+	return docSchema, true
+}
+
+//validationResult response body of data validation
+type validationResult struct {
+	IsValid  bool                 `json:"isValid"`
+	Message  string               `json:"message"`
+	Revision int                  `json:"revision"` //schema revision used, -1 is draft
+	IsDraft  bool                 `json:"isDraft"`
+	Errors   []document.Violation `json:"errors"`
+}
+
+//sendValidationResult send every violation found as JSON response
+func sendValidationResult(w http.ResponseWriter, schema *gxschema.DxDoc, violations []document.Violation) {
+	result := validationResult{
+		IsValid:  len(violations) == 0,
+		Revision: schema.Revision,
+		IsDraft:  schema.Revision == -1,
+		Errors:   violations,
+	}
+	if result.Errors == nil {
+		result.Errors = []document.Violation{}
+	}
+	if !result.IsValid {
+		result.Message = violations[0].Error()
+	}
+
+	jsonRaw, jsonErr := json.Marshal(result)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+}
+
+//sendValidationErrors reject client request (HTTP 400) with every violation found
+func sendValidationErrors(w http.ResponseWriter, violations []document.Violation) {
+	jsonRaw, jsonErr := json.Marshal(struct {
+		ErrorCode    int                  `json:"errorCode"`
+		ErrorMessage string               `json:"errorMessage"`
+		Errors       []document.Violation `json:"errors"`
+	}{-1, "invalid data: " + violations[0].Error(), violations})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(400)
+	w.Write(jsonRaw)
+}
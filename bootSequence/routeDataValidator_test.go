@@ -0,0 +1,133 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/testutil"
+	"github.com/guinso/gxdoc/util"
+)
+
+//validateRequest send data to validate endpoint and return recorded response
+func validateRequest(url string, contentType string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/api/"+url, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+
+	sanatizeURL := strings.Split(url, "?")[0]
+	if !HandleDataValidationHTTP(sanatizeURL, w, r) {
+		w.Code = 0 //not handled
+	}
+
+	return w
+}
+
+func setupValidateTestDB(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+	}
+
+	util.SetDB(db)
+}
+
+func TestValidateUnsupportedContentType(t *testing.T) {
+	w := validateRequest("document/invoice/validate", "text/plain", "invNo=1")
+	if w.Code != 415 {
+		t.Errorf("expect HTTP 415 but get %d", w.Code)
+	}
+}
+
+func TestValidateOversizedBody(t *testing.T) {
+	originalLimit := validateBodyLimit
+	validateBodyLimit = 16
+	defer func() { validateBodyLimit = originalLimit }()
+
+	w := validateRequest("document/invoice/validate", "application/json", `{"invNo": "0123456789abcdef"}`)
+	if w.Code != 413 {
+		t.Errorf("expect HTTP 413 but get %d", w.Code)
+	}
+}
+
+func TestValidateUnknownSchema(t *testing.T) {
+	setupValidateTestDB(t)
+
+	w := validateRequest("document/invoice123/validate", "application/json", `{"invNo": "A"}`)
+	if w.Code != 404 {
+		t.Errorf("expect HTTP 404 for unknown schema but get %d", w.Code)
+	}
+
+	w = validateRequest("document/invoice/validate?revision=99", "application/json", `{"invNo": "A"}`)
+	if w.Code != 404 {
+		t.Errorf("expect HTTP 404 for unknown revision but get %d", w.Code)
+	}
+
+	w = validateRequest("document/invoice/validate?revision=abc", "application/json", `{"invNo": "A"}`)
+	if w.Code != 400 {
+		t.Errorf("expect HTTP 400 for invalid revision but get %d", w.Code)
+	}
+}
+
+func TestValidateInactiveSchema(t *testing.T) {
+	setupValidateTestDB(t)
+	db := util.GetDB()
+
+	name := "validateInactiveTest"
+	if err := document.AddSchemaInfo(db, name, "inactive schema"); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec(`DELETE FROM doc_schema WHERE name = ?`, name)
+
+	info, infoErr := document.GetSchemaInfo(db, name)
+	if infoErr != nil {
+		t.Fatal(infoErr)
+	}
+	info.IsActive = false
+	if err := document.UpdateSchemaInfo(db, info); err != nil {
+		t.Fatal(err)
+	}
+
+	w := validateRequest("document/"+name+"/validate", "application/json", `{}`)
+	if w.Code != 404 {
+		t.Errorf("expect HTTP 404 for inactive schema but get %d", w.Code)
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	setupValidateTestDB(t)
+
+	type response struct {
+		Response validationResult `json:"response"`
+	}
+
+	w := validateRequest("document/invoice/validate", "application/json; charset=utf8",
+		`{"invNo": "INV01", "price": 12.5}`)
+	if w.Code != 200 {
+		t.Fatalf("expect HTTP 200 but get %d: %s", w.Code, w.Body.String())
+	}
+
+	result := response{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Response.IsValid || result.Response.Revision != 2 {
+		t.Errorf("expect data is valid against revision 2 but get %s", w.Body.String())
+	}
+
+	w = validateRequest("document/invoice/validate?revision=1", "text/xml",
+		`<invoice><price>1.234</price></invoice>`)
+	if w.Code != 200 {
+		t.Fatalf("expect HTTP 200 but get %d: %s", w.Code, w.Body.String())
+	}
+
+	result = response{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Response.IsValid || result.Response.Revision != 1 || len(result.Response.Errors) != 2 {
+		t.Errorf("expect 2 violations against revision 1 but get %s", w.Body.String())
+	}
+}
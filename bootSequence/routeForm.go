@@ -0,0 +1,76 @@
+package bootSequence
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/codegen"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var formPattern = regexp.MustCompile(`^forms/[^/]+$`)
+
+//HandleFormHTTP handle HTTP routing for HTML form generated from latest schema revision
+//	sanatizeURL is path relative to development static directory; e.g. forms/invoice
+func HandleFormHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !formPattern.MatchString(sanatizeURL) || !util.IsGET(r) {
+		return false
+	}
+
+	name := strings.Split(sanatizeURL, "/")[1]
+	db := util.GetDB()
+
+	info, infoErr := document.GetSchemaInfo(db, name)
+	if infoErr != nil {
+		util.LogError(infoErr)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return true
+	}
+	if info == nil || !info.IsActive {
+		http.NotFound(w, r)
+		return true
+	}
+
+	schema, schemaErr := document.GetSchema(db, name)
+	if schemaErr != nil {
+		util.LogError(schemaErr)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return true
+	}
+	if schema == nil {
+		//schema has no released revision yet
+		http.NotFound(w, r)
+		return true
+	}
+
+	refs, refErr := document.GetReferences(db, name, schema.Revision)
+	if refErr != nil {
+		util.LogError(refErr)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return true
+	}
+
+	computed, computedErr := document.GetComputedFields(db, name, schema.Revision)
+	if computedErr != nil {
+		util.LogError(computedErr)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return true
+	}
+
+	//relative to forms/{name} so it works with or without development start URL prefix
+	action := "../api/document/" + url.PathEscape(name) + "/records"
+	page, pageErr := codegen.GenerateHTMLForm(schema, refs, computed, action)
+	if pageErr != nil {
+		util.LogError(pageErr)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return true
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write(page)
+	return true
+}
@@ -0,0 +1,110 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaDraftLocalePattern = regexp.MustCompile(`^document/schemas/[^/]+/draft/locales$`)
+var schemaRevisionLocalePattern = regexp.MustCompile(`^document/schemas/[^/]+/revisions/[1-9][0-9]*/locales$`)
+
+//HandleSchemaLocaleHTTP handle HTTP routing for localized metadata of schema
+func HandleSchemaLocaleHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if schemaRevisionLocalePattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get locales of released revision
+		rawArr := strings.Split(sanatizeURL, "/")
+		revision, _ := strconv.Atoi(rawArr[4])
+
+		sendSchemaLocales(w, rawArr[2], revision)
+		return true
+	} else if schemaDraftLocalePattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get locales of draft
+		sendSchemaLocales(w, strings.Split(sanatizeURL, "/")[2], -1)
+		return true
+	} else if schemaDraftLocalePattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//update locales of draft
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		locales, parseErr := document.ParseSchemaLocalesFromJSON(body)
+		if parseErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, parseErr.Error())
+			return true
+		}
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionSaveLocales, name)
+		if oldLocales, oldErr := document.GetSchemaLocales(trx, name, -1); oldErr == nil {
+			oldRaw, _ := json.Marshal(oldLocales)
+			auditEntry.Before = string(oldRaw)
+		}
+
+		saveErr := document.SaveDraftSchemaLocales(trx, name, locales)
+		if saveErr != nil {
+			trx.Rollback()
+
+			switch saveErr.(type) {
+			case document.ErrSchemaInfoNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+			case document.ErrDraftNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no draft")
+			case document.ErrInvalidLocale:
+				util.SendHTTPClientErrorJSON(w, 400, -1, saveErr.Error())
+			default:
+				util.LogError(saveErr)
+				util.SendHTTPServerErrorJSON(w)
+			}
+			return true
+		}
+
+		newRaw, _ := json.Marshal(locales)
+		auditEntry.After = string(newRaw)
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, "{}")
+		return true
+	}
+
+	return false
+}
+
+func sendSchemaLocales(w http.ResponseWriter, name string, revision int) {
+	locales, localeErr := document.GetSchemaLocales(util.GetDB(), name, revision)
+	if localeErr != nil {
+		util.LogError(localeErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	jsonRaw, jsonErr := json.Marshal(locales)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+}
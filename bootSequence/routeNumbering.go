@@ -0,0 +1,126 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaNumberingPattern = regexp.MustCompile(`^document/schema-infos/[^/]+/numbering$`)
+var schemaNumberingGapsPattern = regexp.MustCompile(`^document/schema-infos/[^/]+/numbering/gaps$`)
+
+//HandleNumberingHTTP handle HTTP routing for document numbering sequence
+func HandleNumberingHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if schemaNumberingPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get numbering sequence
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		sequence, seqErr := document.GetNumbering(util.GetDB(), name)
+		if seqErr != nil {
+			if _, ok := seqErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+				return true
+			}
+
+			util.LogError(seqErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		if sequence == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no numbering sequence")
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, sequence.JSON())
+		return true
+	} else if schemaNumberingPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//declare or update numbering sequence
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		sequence, parseErr := document.ParseNumberingFromJSON(body)
+		if parseErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, "invalid numbering sequence: "+parseErr.Error())
+			return true
+		}
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionSaveNumbering, name)
+		if oldSequence, oldErr := document.GetNumbering(trx, name); oldErr == nil && oldSequence != nil {
+			auditEntry.Before = oldSequence.JSON()
+		}
+
+		saveErr := document.SaveNumbering(trx, name, sequence)
+		if saveErr != nil {
+			trx.Rollback()
+
+			if _, ok := saveErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+				return true
+			}
+			if _, ok := saveErr.(document.ErrNumberingConflict); ok {
+				util.SendHTTPClientErrorJSON(w, 409, -1, saveErr.Error())
+				return true
+			}
+
+			util.LogError(saveErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry.After = sequence.JSON()
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, "{}")
+		return true
+	} else if schemaNumberingGapsPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//audit allocated numbers
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		reports, reportErr := document.GetNumberingGaps(util.GetDB(), name)
+		if reportErr != nil {
+			if _, ok := reportErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+				return true
+			}
+
+			util.LogError(reportErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		jsonRaw, jsonErr := json.Marshal(reports)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, string(jsonRaw))
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package bootSequence
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+//openAPICache last generated OpenAPI document, regenerated when its version changes
+var openAPICache = struct {
+	sync.Mutex
+	version string
+	doc     []byte
+}{}
+
+//HandleOpenAPIHTTP handle HTTP routing for OpenAPI document of REST API
+func HandleOpenAPIHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if sanatizeURL != "openapi.json" || !util.IsGET(r) {
+		return false
+	}
+
+	db := util.GetDB()
+	version, versionErr := document.GetOpenAPIVersion(db)
+	if versionErr != nil {
+		util.LogError(versionErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	openAPICache.Lock()
+	defer openAPICache.Unlock()
+
+	if openAPICache.doc == nil || openAPICache.version != version {
+		//a revision is released (or schema is activated/deactivated) since last generation
+		schemas, schemaErr := document.GetOpenAPISchemas(db)
+		if schemaErr != nil {
+			util.LogError(schemaErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		doc, docErr := document.ToOpenAPI(schemas, version)
+		if docErr != nil {
+			util.LogError(docErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		openAPICache.version = version
+		openAPICache.doc = doc
+	}
+
+	//serve bare OpenAPI document so gateway and client generators can consume it by URL
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(200)
+	w.Write(openAPICache.doc)
+	return true
+}
@@ -0,0 +1,427 @@
+package bootSequence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+	"github.com/guinso/gxschema"
+)
+
+var recordsPattern = regexp.MustCompile(`^document/[^/]+/records$`)
+var recordImportPattern = regexp.MustCompile(`^document/[^/]+/records/import$`)
+var recordPattern = regexp.MustCompile(`^document/[^/]+/records/[^/]+$`)
+var recordHistoryPattern = regexp.MustCompile(`^document/[^/]+/records/[^/]+/history$`)
+var recordVerifyPattern = regexp.MustCompile(`^document/[^/]+/records/[^/]+/verify$`)
+var recordReferencedByPattern = regexp.MustCompile(`^document/[^/]+/records/[^/]+/referenced-by$`)
+var recordTransitionPattern = regexp.MustCompile(`^document/[^/]+/records/[^/]+/transitions/[^/]+$`)
+
+//HandleRecordHTTP handle HTTP routing for document records
+func HandleRecordHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if recordsPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//submit new document record (input data must be JSON)
+		name := strings.Split(sanatizeURL, "/")[1]
+
+		db := util.GetDB()
+		schema, ok := getSubmissionSchema(db, w, name)
+		if !ok {
+			return true
+		}
+
+		if strings.Split(r.Header.Get("Content-Type"), ";")[0] != "application/json" {
+			util.SendHTTPClientErrorJSON(w, 415, -1, "document record only accept JSON")
+			return true
+		}
+
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		validator, validatorErr := newRecordValidator(db, r, schema)
+		if validatorErr != nil {
+			util.LogError(validatorErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		data, violations, validateErr := validator.ValidateJSON(body)
+		if validateErr != nil {
+			util.LogError(validateErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if len(violations) > 0 {
+			sendValidationErrors(w, violations)
+			return true
+		}
+
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		record, addErr := createRecord(trx, r, name, schema.Revision, data)
+		if addErr != nil {
+			trx.Rollback()
+
+			if _, ok := addErr.(document.ErrReferenceNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 400, -1, "invalid data: "+addErr.Error())
+				return true
+			}
+
+			util.LogError(addErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, record.JSON())
+		return true
+	} else if recordImportPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//import document records from CSV, all rows are stored or none
+		name := strings.Split(sanatizeURL, "/")[1]
+
+		db := util.GetDB()
+		schema, ok := getSubmissionSchema(db, w, name)
+		if !ok {
+			return true
+		}
+
+		if strings.Split(r.Header.Get("Content-Type"), ";")[0] != "text/csv" {
+			util.SendHTTPClientErrorJSON(w, 415, -1, "record import only accept CSV")
+			return true
+		}
+
+		//optional column mapping; e.g. ?column=Invoice No:code&column=Paid:isPaid
+		mapping := map[string]string{}
+		for _, pair := range r.URL.Query()["column"] {
+			index := strings.LastIndex(pair, ":")
+			if index < 0 {
+				util.SendHTTPClientErrorJSON(w, 400, -1, "column mapping must be in {CSV header}:{item name} format")
+				return true
+			}
+
+			mapping[strings.TrimSpace(pair[:index])] = strings.TrimSpace(pair[index+1:])
+		}
+
+		validator, validatorErr := newRecordValidator(db, r, schema)
+		if validatorErr != nil {
+			util.LogError(validatorErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		rows, rowErrors, csvErr := document.ConvertCSV(r.Body, schema, mapping, validator.fields)
+		if csvErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, csvErr.Error())
+			return true
+		}
+
+		//fill in computed fields and run cross-field rules on every row
+		for index := range rows {
+			data, violations, validateErr := validator.ValidateJSON(rows[index].Data)
+			if validateErr != nil {
+				util.LogError(validateErr)
+				util.SendHTTPServerErrorJSON(w)
+				return true
+			}
+			if len(violations) > 0 {
+				rowErrors = append(rowErrors, document.CSVRowError{Row: rows[index].Row, Errors: violations})
+			}
+
+			rows[index].Data = data
+		}
+		if len(rowErrors) > 0 {
+			sendImportErrors(w, rowErrors)
+			return true
+		}
+
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		records := []*document.Record{}
+		for _, row := range rows {
+			record, addErr := createRecord(trx, r, name, schema.Revision, row.Data)
+			if addErr != nil {
+				if _, ok := addErr.(document.ErrReferenceNotFound); ok {
+					rowErrors = append(rowErrors, document.CSVRowError{Row: row.Row, Errors: []document.Violation{
+						{Rule: document.RuleReference, Message: addErr.Error()}}})
+					continue
+				}
+
+				trx.Rollback()
+
+				util.LogError(addErr)
+				util.SendHTTPServerErrorJSON(w)
+				return true
+			}
+
+			records = append(records, record)
+		}
+
+		if len(rowErrors) > 0 {
+			trx.Rollback()
+
+			sendImportErrors(w, rowErrors)
+			return true
+		}
+		trx.Commit()
+
+		jsonRaw, jsonErr := json.Marshal(records)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, string(jsonRaw))
+		return true
+	} else if recordPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get document record
+		rawArr := strings.Split(sanatizeURL, "/")
+
+		record, recordErr := document.GetRecord(util.GetDB(), rawArr[1], rawArr[3])
+		if recordErr != nil {
+			util.LogError(recordErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if record == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "record not found")
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, record.JSON())
+		return true
+	} else if recordHistoryPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get document record history
+		rawArr := strings.Split(sanatizeURL, "/")
+
+		db := util.GetDB()
+		record, recordErr := document.GetRecord(db, rawArr[1], rawArr[3])
+		if recordErr != nil {
+			util.LogError(recordErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if record == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "record not found")
+			return true
+		}
+
+		versions, versionsErr := document.GetRecordHistory(db, record.ID)
+		if versionsErr != nil {
+			util.LogError(versionsErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		jsonRaw, jsonErr := json.Marshal(versions)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, string(jsonRaw))
+		return true
+	} else if recordReferencedByPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get records which reference to this record
+		rawArr := strings.Split(sanatizeURL, "/")
+
+		db := util.GetDB()
+		record, recordErr := document.GetRecord(db, rawArr[1], rawArr[3])
+		if recordErr != nil {
+			util.LogError(recordErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+		if record == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "record not found")
+			return true
+		}
+
+		refs, refsErr := document.GetReferencingRecords(db, record.ID, r.URL.Query().Get("schema"))
+		if refsErr != nil {
+			util.LogError(refsErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		jsonRaw, jsonErr := json.Marshal(refs)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, string(jsonRaw))
+		return true
+	} else if recordVerifyPattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//verify document record versions hash chain
+		rawArr := strings.Split(sanatizeURL, "/")
+
+		report, reportErr := document.VerifyRecordChain(util.GetDB(), rawArr[1], rawArr[3])
+		if reportErr != nil {
+			if _, ok := reportErr.(document.ErrRecordNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "record not found")
+				return true
+			}
+
+			util.LogError(reportErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		jsonRaw, jsonErr := json.Marshal(report)
+		if jsonErr != nil {
+			util.LogError(jsonErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, string(jsonRaw))
+		return true
+	} else if recordTransitionPattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//move document record to next workflow state
+		rawArr := strings.Split(sanatizeURL, "/")
+		name := rawArr[1]
+		id := rawArr[3]
+		transitionName := rawArr[5]
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionTransitRecord, name)
+		auditEntry.TargetDocument = id
+		if oldRecord, oldErr := document.GetRecord(trx, name, id); oldErr == nil && oldRecord != nil {
+			auditEntry.Before = oldRecord.JSON()
+		}
+
+		record, transitErr := document.TransitRecord(trx, name, id, transitionName,
+			util.GetRequestActor(r), util.GetRequestRoles(r))
+		if transitErr != nil {
+			trx.Rollback()
+
+			switch transitErr.(type) {
+			case document.ErrSchemaInfoNotFound, document.ErrRecordNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, transitErr.Error())
+			case document.ErrWorkflowNotFound, document.ErrTransitionNotAllowed:
+				util.SendHTTPClientErrorJSON(w, 409, -1, transitErr.Error())
+			case document.ErrTransitionNotAuthorize:
+				util.SendHTTPClientErrorJSON(w, 403, -1, transitErr.Error())
+			default:
+				util.LogError(transitErr)
+				util.SendHTTPServerErrorJSON(w)
+			}
+			return true
+		}
+
+		auditEntry.After = record.JSON()
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, record.JSON())
+		return true
+	}
+
+	return false
+}
+
+//sendImportErrors reject record import (HTTP 400) with violations of every failed row
+func sendImportErrors(w http.ResponseWriter, rowErrors []document.CSVRowError) {
+	sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+
+	jsonRaw, jsonErr := json.Marshal(struct {
+		ErrorCode    int                    `json:"errorCode"`
+		ErrorMessage string                 `json:"errorMessage"`
+		Rows         []document.CSVRowError `json:"rows"`
+	}{-1, fmt.Sprintf("%d rows failed validation, nothing is imported", len(rowErrors)), rowErrors})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(400)
+	w.Write(jsonRaw)
+}
+
+//getSubmissionSchema get latest schema revision which accept new document record
+//return false if schema not available and error response is sent
+func getSubmissionSchema(db *sql.DB, w http.ResponseWriter, name string) (*gxschema.DxDoc, bool) {
+	schemaInfo, infoErr := document.GetSchemaInfo(db, name)
+	if infoErr != nil {
+		util.LogError(infoErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, false
+	}
+	if schemaInfo == nil || !schemaInfo.IsActive {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+		return nil, false
+	}
+
+	schema, schemaErr := document.GetSchema(db, name)
+	if schemaErr != nil {
+		util.LogError(schemaErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, false
+	}
+	if schema == nil {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no released revision")
+		return nil, false
+	}
+
+	return schema, true
+}
+
+//createRecord create document record with its references and write audit log
+//NOTE: document.ErrReferenceNotFound error will return if referenced record not found
+func createRecord(trx *sql.Tx, r *http.Request, name string, revision int, data string) (*document.Record, error) {
+	recordRefs, refErr := document.ValidateRecordReferences(trx, name, revision, data)
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	record, addErr := document.AddRecord(trx, name, revision, data, util.GetRequestActor(r))
+	if addErr != nil {
+		return nil, addErr
+	}
+
+	if err := document.AddRecordReferences(trx, record.ID, recordRefs); err != nil {
+		return nil, err
+	}
+
+	auditEntry := audit.NewEntry(r, audit.ActionAddRecord, name)
+	auditEntry.TargetDocument = record.ID
+	auditEntry.After = record.JSON()
+	if err := audit.Write(trx, auditEntry); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
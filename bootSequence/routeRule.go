@@ -0,0 +1,102 @@
+package bootSequence
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaDraftRulePattern = regexp.MustCompile(`^document/schemas/[^/]+/draft/rules$`)
+var schemaRevisionRulePattern = regexp.MustCompile(`^document/schemas/[^/]+/revisions/[1-9][0-9]*/rules$`)
+
+//HandleRuleScriptHTTP handle HTTP routing for schema rule script
+func HandleRuleScriptHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if schemaRevisionRulePattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get rule script of released revision
+		rawArr := strings.Split(sanatizeURL, "/")
+		revision, _ := strconv.Atoi(rawArr[4])
+
+		rule, ruleErr := document.GetRuleScript(util.GetDB(), rawArr[2], revision)
+		if ruleErr != nil {
+			util.LogError(ruleErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, rule.JSON())
+		return true
+	} else if schemaDraftRulePattern.MatchString(sanatizeURL) && util.IsGET(r) {
+		//get rule script of draft
+		rule, ruleErr := document.GetRuleScript(util.GetDB(), strings.Split(sanatizeURL, "/")[2], -1)
+		if ruleErr != nil {
+			util.LogError(ruleErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, rule.JSON())
+		return true
+	} else if schemaDraftRulePattern.MatchString(sanatizeURL) && util.IsPOST(r) {
+		//update rule script of draft
+		name := strings.Split(sanatizeURL, "/")[2]
+
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		rule, parseErr := document.ParseRuleScriptFromJSON(body)
+		if parseErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, parseErr.Error())
+			return true
+		}
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionSaveRuleScript, name)
+		if oldRule, oldErr := document.GetRuleScript(trx, name, -1); oldErr == nil {
+			auditEntry.Before = oldRule.JSON()
+		}
+
+		saveErr := document.SaveDraftRuleScript(trx, name, rule)
+		if saveErr != nil {
+			trx.Rollback()
+
+			switch saveErr.(type) {
+			case document.ErrSchemaInfoNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+			case document.ErrDraftNotFound:
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no draft")
+			default:
+				util.LogError(saveErr)
+				util.SendHTTPServerErrorJSON(w)
+			}
+			return true
+		}
+
+		auditEntry.After = rule.JSON()
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, "{}")
+		return true
+	}
+
+	return false
+}
+
@@ -0,0 +1,136 @@
+package bootSequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+//bundleBodyLimit maximum size (in bytes) of schema bundle accepted by import endpoint
+var bundleBodyLimit int64 = 32 << 20
+
+//HandleSchemaBundleHTTP handle HTTP routing for exporting and importing schema bundle,
+//which carry schema infos with all revisions and drafts from one server to another
+func HandleSchemaBundleHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if sanatizeURL != "document/schemas.zip" {
+		return false
+	}
+
+	if util.IsGET(r) {
+		//export selected schemas, or every schema if none selected
+		buffer := bytes.Buffer{}
+		exportErr := document.ExportSchemaBundle(util.GetDB(), r.URL.Query()["schema"], &buffer)
+		if exportErr != nil {
+			if _, ok := exportErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, exportErr.Error())
+				return true
+			}
+
+			util.LogError(exportErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="schemas.zip"`)
+		w.WriteHeader(200)
+		w.Write(buffer.Bytes())
+		return true
+	} else if util.IsPOST(r) {
+		importSchemaBundle(w, r)
+		return true
+	}
+
+	return false
+}
+
+func importSchemaBundle(w http.ResponseWriter, r *http.Request) {
+	conflict := r.URL.Query().Get("conflict")
+	if conflict == "" {
+		conflict = document.BundleConflictFail
+	}
+	if conflict != document.BundleConflictFail && conflict != document.BundleConflictSkip &&
+		conflict != document.BundleConflictMerge {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "conflict must be either fail, skip or merge")
+		return
+	}
+
+	if r.ContentLength > bundleBodyLimit {
+		util.SendHTTPClientErrorJSON(w, 413, -1, "schema bundle is too large")
+		return
+	}
+	bodyRaw, bodyErr := ioutil.ReadAll(io.LimitReader(r.Body, bundleBodyLimit+1))
+	if bodyErr != nil {
+		util.LogError(bodyErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+	if int64(len(bodyRaw)) > bundleBodyLimit {
+		util.SendHTTPClientErrorJSON(w, 413, -1, "schema bundle is too large")
+		return
+	}
+
+	manifest, readErr := document.ReadSchemaBundle(bodyRaw)
+	if readErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, readErr.Error())
+		return
+	}
+
+	db := util.GetDB()
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		util.LogError(trxErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	//whole bundle is imported in one transaction, any conflict leaves database untouched
+	results, importErr := document.ImportSchemaBundle(trx, manifest, conflict)
+	if importErr != nil {
+		trx.Rollback()
+
+		if _, ok := importErr.(document.ErrBundleConflict); ok {
+			util.SendHTTPClientErrorJSON(w, 409, -1, importErr.Error())
+			return
+		}
+		if _, ok := importErr.(document.ErrInvalidBundle); ok {
+			util.SendHTTPClientErrorJSON(w, 400, -1, importErr.Error())
+			return
+		}
+
+		util.LogError(importErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	for _, result := range results {
+		if result.Status == document.BundleImportSkipped {
+			continue
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionImportBundle, result.Name)
+		resultRaw, _ := json.Marshal(result)
+		auditEntry.After = string(resultRaw)
+		if !writeAuditLog(trx, w, auditEntry) {
+			return
+		}
+	}
+	trx.Commit()
+
+	jsonRaw, jsonErr := json.Marshal(struct {
+		Schemas []document.BundleImportResult `json:"schemas"`
+	}{results})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+}
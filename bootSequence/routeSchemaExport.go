@@ -0,0 +1,161 @@
+package bootSequence
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxdoc/codegen"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+	"github.com/guinso/gxschema"
+)
+
+var schemaExportPattern = regexp.MustCompile(`^document/schemas/[^/]+/export\.(json-schema|xsd|d\.ts)$`)
+var schemaRevisionExportPattern = regexp.MustCompile(
+	`^document/schemas/[^/]+/revisions/[1-9][0-9]*/export\.(json-schema|xsd|d\.ts)$`)
+
+//HandleSchemaExportHTTP handle HTTP routing for exporting document schema into other schema languages
+//	export format is a separate path segment (export.json-schema, export.xsd, export.d.ts)
+//	so it never clash with schema name
+func HandleSchemaExportHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !util.IsGET(r) {
+		return false
+	}
+
+	rawArr := strings.Split(sanatizeURL, "/")
+	var schema *gxschema.DxDoc
+	var ok bool
+	if schemaExportPattern.MatchString(sanatizeURL) {
+		//export latest revision
+		schema, ok = getExportSchema(w, rawArr[2], 0)
+	} else if schemaRevisionExportPattern.MatchString(sanatizeURL) {
+		//export specific revision
+		revision, _ := strconv.Atoi(rawArr[4])
+		schema, ok = getExportSchema(w, rawArr[2], revision)
+	} else {
+		return false
+	}
+
+	if ok {
+		switch rawArr[len(rawArr)-1] {
+		case "export.json-schema":
+			sendJSONSchema(w, schema)
+		case "export.xsd":
+			sendXSD(w, schema)
+		case "export.d.ts":
+			sendTypeScript(w, r, schema)
+		}
+	}
+
+	return true
+}
+
+//getExportSchema get schema revision to export, 0 revision means latest revision
+//return false if schema not found and error response is sent
+func getExportSchema(w http.ResponseWriter, name string, revision int) (*gxschema.DxDoc, bool) {
+	var schema *gxschema.DxDoc
+	var schemaErr error
+	if revision == 0 {
+		schema, schemaErr = document.GetSchema(util.GetDB(), name)
+	} else {
+		schema, schemaErr = document.GetSchemaByRevision(util.GetDB(), name, revision)
+	}
+	if schemaErr != nil {
+		util.LogError(schemaErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, false
+	}
+	if schema == nil {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "record not found")
+		return nil, false
+	}
+
+	return schema, true
+}
+
+//getExportAttachments get references and computed fields of schema revision to export
+//return false if error response is sent
+func getExportAttachments(w http.ResponseWriter, schema *gxschema.DxDoc) ([]document.Reference,
+	[]document.ComputedField, bool) {
+	refs, refErr := document.GetReferences(util.GetDB(), schema.Name, schema.Revision)
+	if refErr != nil {
+		util.LogError(refErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, nil, false
+	}
+
+	computed, computedErr := document.GetComputedFields(util.GetDB(), schema.Name, schema.Revision)
+	if computedErr != nil {
+		util.LogError(computedErr)
+		util.SendHTTPServerErrorJSON(w)
+		return nil, nil, false
+	}
+
+	return refs, computed, true
+}
+
+func sendJSONSchema(w http.ResponseWriter, schema *gxschema.DxDoc) {
+	refs, computed, found := getExportAttachments(w, schema)
+	if !found {
+		return
+	}
+
+	jsonRaw, jsonErr := document.ToJSONSchema(schema, refs, computed)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	//serve bare JSON Schema document so tools can consume it by URL
+	w.Header().Set("Content-Type", "application/schema+json; charset=utf8")
+	w.WriteHeader(200)
+	w.Write(jsonRaw)
+}
+
+func sendXSD(w http.ResponseWriter, schema *gxschema.DxDoc) {
+	refs, computed, found := getExportAttachments(w, schema)
+	if !found {
+		return
+	}
+
+	xsdRaw, xsdErr := document.ToXSD(schema, refs, computed)
+	if xsdErr != nil {
+		//schema is valid but can't be expressed as XML element, e.g. item name with space
+		util.SendHTTPClientErrorJSON(w, 409, -1, xsdErr.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf8")
+	w.WriteHeader(200)
+	w.Write(xsdRaw)
+}
+
+func sendTypeScript(w http.ResponseWriter, r *http.Request, schema *gxschema.DxDoc) {
+	decimalType := r.URL.Query().Get("decimal")
+	if decimalType == "" {
+		decimalType = codegen.DecimalAsNumber
+	}
+	if decimalType != codegen.DecimalAsNumber && decimalType != codegen.DecimalAsString {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "decimal must be either number or string")
+		return
+	}
+
+	refs, computed, found := getExportAttachments(w, schema)
+	if !found {
+		return
+	}
+
+	source, genErr := codegen.GenerateTypeScript(schema, refs, computed, decimalType)
+	if genErr != nil {
+		util.LogError(genErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/typescript; charset=utf8")
+	w.WriteHeader(200)
+	w.Write(source)
+}
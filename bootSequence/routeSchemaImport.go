@@ -0,0 +1,131 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+	"github.com/guinso/gxschema"
+)
+
+var schemaDraftImportPattern = regexp.MustCompile(`^document/schemas/[^/]+/draft/import$`)
+
+//HandleSchemaImportHTTP handle HTTP routing for importing JSON Schema or XSD as schema draft
+func HandleSchemaImportHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !schemaDraftImportPattern.MatchString(sanatizeURL) || !util.IsPOST(r) {
+		return false
+	}
+
+	name := strings.Split(sanatizeURL, "/")[2]
+
+	var importFn func(string, string) (*gxschema.DxDoc, []document.ImportWarning, error)
+	switch strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]) {
+	case "application/schema+json", "application/json":
+		importFn = document.ImportFromJSONSchema
+	case "application/xml", "text/xml":
+		importFn = document.ImportFromXSD
+	default:
+		util.SendHTTPClientErrorJSON(w, 415, -1, "input data type only accept either JSON Schema nor XSD")
+		return true
+	}
+
+	body, bodyErr := util.GetHTTPRequestBody(r)
+	if bodyErr != nil {
+		util.LogError(bodyErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	schema, warnings, importErr := importFn(name, body)
+	if importErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, importErr.Error())
+		return true
+	}
+
+	db := util.GetDB()
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		util.LogError(trxErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	auditEntry := audit.NewEntry(r, audit.ActionImportSchemaDraft, name)
+	var snapshotErr error
+	auditEntry.Before, snapshotErr = getSchemaSnapshot(trx, name, -1)
+	if snapshotErr != nil {
+		trx.Rollback()
+
+		util.LogError(snapshotErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	//imported draft replaces whole draft definition, including references, includes, rule script,
+	//computed fields and locales of previous draft since they may not match imported items
+	saveErr := document.SaveSchemaAsDraft(trx, name, schema, "")
+	if saveErr == nil {
+		saveErr = document.SaveReferences(trx, name, -1, []document.Reference{})
+	}
+	if saveErr == nil {
+		saveErr = document.SaveIncludes(trx, name, -1, []document.Include{})
+	}
+	if saveErr == nil {
+		saveErr = document.SaveDraftRuleScript(trx, name, &document.RuleScript{})
+	}
+	if saveErr == nil {
+		saveErr = document.SaveDraftComputedFields(trx, name, []document.ComputedField{})
+	}
+	if saveErr == nil {
+		saveErr = document.SaveDraftSchemaLocales(trx, name, []document.SchemaLocale{})
+	}
+	if saveErr != nil {
+		trx.Rollback()
+
+		if lintErr, ok := saveErr.(document.ErrSchemaLint); ok {
+			sendLintErrors(w, lintErr)
+			return true
+		}
+		if _, ok := saveErr.(document.ErrSchemaInfoNotFound); ok {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+			return true
+		}
+
+		util.LogError(saveErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	auditEntry.After, snapshotErr = getSchemaSnapshot(trx, name, -1)
+	if snapshotErr != nil {
+		trx.Rollback()
+
+		util.LogError(snapshotErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+	if !writeAuditLog(trx, w, auditEntry) {
+		return true
+	}
+	trx.Commit()
+
+	for _, issue := range document.LintSchema(schema) {
+		warnings = append(warnings, document.ImportWarning{Path: issue.Path, Message: issue.Message})
+	}
+
+	jsonRaw, jsonErr := json.Marshal(struct {
+		Warnings []document.ImportWarning `json:"warnings"`
+	}{warnings})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+	return true
+}
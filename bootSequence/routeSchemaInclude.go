@@ -0,0 +1,51 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaDependentsPattern = regexp.MustCompile(`^document/schemas/[^/]+/dependents$`)
+
+//HandleSchemaDependentHTTP handle HTTP routing for listing schemas which include a base schema
+func HandleSchemaDependentHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !schemaDependentsPattern.MatchString(sanatizeURL) || !util.IsGET(r) {
+		return false
+	}
+
+	name := strings.Split(sanatizeURL, "/")[2]
+	db := util.GetDB()
+
+	info, infoErr := document.GetSchemaInfo(db, name)
+	if infoErr != nil {
+		util.LogError(infoErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+	if info == nil {
+		util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+		return true
+	}
+
+	dependents, dependentErr := document.GetIncludeDependents(db, name)
+	if dependentErr != nil {
+		util.LogError(dependentErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	raw, jsonErr := json.Marshal(dependents)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	util.SendHTTPResponseJSON(w, string(raw))
+	return true
+}
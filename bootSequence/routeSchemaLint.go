@@ -0,0 +1,78 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+//lintResult result of schema lint endpoint
+type lintResult struct {
+	IsValid bool                 `json:"isValid"` //false if any issue is error
+	Issues  []document.LintIssue `json:"issues"`
+}
+
+//HandleSchemaLintHTTP handle HTTP routing for checking schema definition without saving it
+func HandleSchemaLintHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if sanatizeURL != "document/lint" || !util.IsPOST(r) {
+		return false
+	}
+
+	body, bodyErr := util.GetHTTPRequestBody(r)
+	if bodyErr != nil {
+		util.LogError(bodyErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	schema, _, _, parseErr := parseSchemaDefinition(r, body)
+	if parseErr != nil {
+		util.SendHTTPClientErrorJSON(w, 400, -1, "invalid input data: "+parseErr.Error())
+		return true
+	}
+
+	issues := document.LintSchema(schema)
+	jsonRaw, jsonErr := json.Marshal(lintResult{IsValid: !document.HasLintError(issues), Issues: issues})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+	return true
+}
+
+//sendLintErrors reject schema definition (HTTP 400) with every lint issue found
+func sendLintErrors(w http.ResponseWriter, lintErr document.ErrSchemaLint) {
+	jsonRaw, jsonErr := json.Marshal(struct {
+		ErrorCode    int                  `json:"errorCode"`
+		ErrorMessage string               `json:"errorMessage"`
+		Issues       []document.LintIssue `json:"issues"`
+	}{-1, lintErr.Error(), lintErr.Issues})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(400)
+	w.Write(jsonRaw)
+}
+
+//sendLintWarnings accept saved schema definition with lint warnings found
+func sendLintWarnings(w http.ResponseWriter, issues []document.LintIssue) {
+	jsonRaw, jsonErr := json.Marshal(struct {
+		Warnings []document.LintIssue `json:"warnings"`
+	}{issues})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+}
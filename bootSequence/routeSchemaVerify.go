@@ -0,0 +1,44 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaVerifyPattern = regexp.MustCompile(`^document/schemas/[^/]+/verify$`)
+
+//HandleSchemaVerifyHTTP handle HTTP routing for verifying schema revision hash chain
+func HandleSchemaVerifyHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !schemaVerifyPattern.MatchString(sanatizeURL) || !util.IsGET(r) {
+		return false //URL pattern not match
+	}
+
+	name := strings.Split(sanatizeURL, "/")[2]
+
+	report, reportErr := document.VerifySchemaChain(util.GetDB(), name)
+	if reportErr != nil {
+		if _, ok := reportErr.(document.ErrSchemaInfoNotFound); ok {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+			return true
+		}
+
+		util.LogError(reportErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	jsonRaw, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return true
+	}
+
+	util.SendHTTPResponseJSON(w, string(jsonRaw))
+	return true
+}
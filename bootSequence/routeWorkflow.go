@@ -0,0 +1,118 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxdoc/util"
+)
+
+var schemaWorkflowPattern = regexp.MustCompile(`^document/schema-infos/[^/]+/workflow$`)
+
+//HandleWorkflowHTTP handle HTTP routing for document schema workflow definition
+func HandleWorkflowHTTP(sanatizeURL string, w http.ResponseWriter, r *http.Request) bool {
+	if !schemaWorkflowPattern.MatchString(sanatizeURL) {
+		return false //URL pattern not match
+	}
+
+	name := strings.Split(sanatizeURL, "/")[2]
+
+	if util.IsGET(r) {
+		workflow, workflowErr := document.GetWorkflow(util.GetDB(), name)
+		if workflowErr != nil {
+			if _, ok := workflowErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+				return true
+			}
+
+			util.LogError(workflowErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		if workflow == nil {
+			util.SendHTTPClientErrorJSON(w, 404, -1, "schema has no workflow")
+			return true
+		}
+
+		util.SendHTTPResponseJSON(w, workflow.JSON())
+		return true
+	} else if util.IsPOST(r) {
+		body, bodyErr := util.GetHTTPRequestBody(r)
+		if bodyErr != nil {
+			util.LogError(bodyErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		workflow, parseErr := document.ParseWorkflowFromJSON(body)
+		if parseErr != nil {
+			util.SendHTTPClientErrorJSON(w, 400, -1, "invalid workflow: "+parseErr.Error())
+			return true
+		}
+
+		db := util.GetDB()
+		trx, trxErr := db.Begin()
+		if trxErr != nil {
+			util.LogError(trxErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry := audit.NewEntry(r, audit.ActionSaveWorkflow, name)
+		if oldWorkflow, oldErr := document.GetWorkflow(trx, name); oldErr == nil && oldWorkflow != nil {
+			auditEntry.Before = oldWorkflow.JSON()
+		}
+
+		saveErr := document.SaveWorkflow(trx, name, workflow)
+		if saveErr != nil {
+			trx.Rollback()
+
+			if _, ok := saveErr.(document.ErrSchemaInfoNotFound); ok {
+				util.SendHTTPClientErrorJSON(w, 404, -1, "schema not found")
+				return true
+			}
+			if inUseErr, ok := saveErr.(document.ErrWorkflowStateInUse); ok {
+				sendWorkflowStatesInUse(w, inUseErr)
+				return true
+			}
+
+			util.LogError(saveErr)
+			util.SendHTTPServerErrorJSON(w)
+			return true
+		}
+
+		auditEntry.After = workflow.JSON()
+		if !writeAuditLog(trx, w, auditEntry) {
+			return true
+		}
+		trx.Commit()
+
+		util.SendHTTPResponseJSON(w, "{}")
+		return true
+	}
+
+	return false
+}
+
+//sendWorkflowStatesInUse reject workflow (HTTP 409) with states document records are still in
+func sendWorkflowStatesInUse(w http.ResponseWriter, inUseErr document.ErrWorkflowStateInUse) {
+	jsonRaw, jsonErr := json.Marshal(struct {
+		ErrorCode    int      `json:"errorCode"`
+		ErrorMessage string   `json:"errorMessage"`
+		States       []string `json:"states"`
+	}{-1, inUseErr.Error(), inUseErr.States})
+	if jsonErr != nil {
+		util.LogError(jsonErr)
+		util.SendHTTPServerErrorJSON(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(409)
+	w.Write(jsonRaw)
+}
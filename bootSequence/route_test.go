@@ -0,0 +1,47 @@
+package bootSequence
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+)
+
+//TestRouteOpenAPIOperations send every fixed operation advertised in OpenAPI document
+//through router; unknown schema and malformed body keep requests from changing data
+func TestRouteOpenAPIOperations(t *testing.T) {
+	setupValidateTestDB(t)
+
+	raw, err := document.ToOpenAPI(nil, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	params := strings.NewReplacer(
+		"{schema-name}", "route-test-unknown",
+		"{revision-number}", "1",
+		"{record-id}", "00000000-0000-0000-0000-000000000000",
+		"{transition-name}", "approve")
+
+	for path, operations := range doc.Paths {
+		url := params.Replace(path)[1:] //trim leading '/'
+		for method := range operations {
+			r := httptest.NewRequest(strings.ToUpper(method), "/api/"+url, strings.NewReader("{"))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			routeDevelopmentAPI(w, r, url)
+			if w.Code == 404 && strings.HasPrefix(w.Body.String(), "path not found") {
+				t.Errorf("expect %s %s is routed but get path not found", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+//goReservedNames type and function names declared by generated client code
+var goReservedNames = []string{"Record", "ValidationResult", "Violation", "APIError", "Client", "NewClient",
+	"SchemaName", "SchemaRevision"}
+
+//GoPackageName get default Go package name of schema; e.g. purchase-order become purchaseorder
+func GoPackageName(schemaName string) string {
+	name := strings.ToLower(ToIdentifier(schemaName))
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "doc" + name
+	}
+
+	return name
+}
+
+//ToIdentifier convert schema or item name into exported identifier; e.g. invNo become InvNo, pr number become PrNumber
+func ToIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+
+	buffer := bytes.Buffer{}
+	for _, part := range parts {
+		runes := []rune(part)
+		buffer.WriteRune(unicode.ToUpper(runes[0]))
+		buffer.WriteString(string(runes[1:]))
+	}
+
+	identifier := buffer.String()
+	if identifier == "" {
+		return "Item"
+	}
+	if unicode.IsDigit([]rune(identifier)[0]) {
+		return "X" + identifier
+	}
+
+	return identifier
+}
+
+//uniqueName get name which isn't used yet, by appending number if needed
+func uniqueName(used map[string]bool, name string) string {
+	result := name
+	for index := 2; used[result]; index++ {
+		result = fmt.Sprintf("%s%d", name, index)
+	}
+	used[result] = true
+
+	return result
+}
+
+//goGenerator generate Go source of document schema
+type goGenerator struct {
+	schema    *gxschema.DxDoc
+	refs      map[string]document.Reference
+	computed  map[string]bool
+	typeNames map[string]bool
+	types     []string //source of every struct type, root first
+}
+
+//GenerateGo generate Go source with struct of schema revision and typed client of its validate and record endpoints
+//	optional item become pointer, array item become slice and section become nested struct;
+//	decimal item become json.Number to keep its precision; computed item become pointer which is left nil
+//	refs (optional) are references of the schema revision
+//	computed (optional) are computed fields of the schema revision
+func GenerateGo(schema *gxschema.DxDoc, refs []document.Reference, computed []document.ComputedField,
+	packageName string) ([]byte, error) {
+	generator := goGenerator{
+		schema:    schema,
+		refs:      map[string]document.Reference{},
+		computed:  document.GetComputedPaths(computed),
+		typeNames: map[string]bool{},
+	}
+	for _, ref := range refs {
+		generator.refs[ref.ItemPath] = ref
+	}
+	for _, name := range goReservedNames {
+		generator.typeNames[name] = true
+	}
+
+	rootName := uniqueName(generator.typeNames, ToIdentifier(schema.Name))
+	xmlTag := "-"
+	if document.IsXMLName(schema.Name) {
+		xmlTag = schema.Name
+	}
+	if err := generator.addStruct(rootName, schema.Items, "",
+		fmt.Sprintf("//%s data of gxdoc schema %s revision %d\n", rootName, schema.Name, schema.Revision),
+		fmt.Sprintf("XMLName xml.Name `json:\"-\" xml:\"%s\"`\n", xmlTag)); err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString("// Code generated by gxdoc codegen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buffer, "package %s\n\n", packageName)
+	buffer.WriteString(goImports)
+	fmt.Fprintf(&buffer, "//SchemaName name of gxdoc schema\nconst SchemaName = %q\n\n", schema.Name)
+	fmt.Fprintf(&buffer, "//SchemaRevision gxdoc schema revision which structs are generated from\nconst SchemaRevision = %d\n\n",
+		schema.Revision)
+	for _, source := range generator.types {
+		buffer.WriteString(source)
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString(strings.NewReplacer(
+		"{{root}}", rootName,
+		"{{schemaPath}}", "/document/"+url.PathEscape(schema.Name),
+	).Replace(goClientTemplate))
+
+	source, formatErr := format.Source(buffer.Bytes())
+	if formatErr != nil {
+		return nil, fmt.Errorf("failed to format generated Go source: %s", formatErr.Error())
+	}
+
+	return source, nil
+}
+
+func (generator *goGenerator) addStruct(typeName string, items []gxschema.DxItem, path string,
+	comment string, extraFields string) error {
+	index := len(generator.types)
+	generator.types = append(generator.types, "") //reserve position so parent is declared before children
+
+	fieldNames := map[string]bool{"XMLName": true}
+	buffer := bytes.Buffer{}
+	buffer.WriteString(comment)
+	fmt.Fprintf(&buffer, "type %s struct {\n", typeName)
+	buffer.WriteString(extraFields)
+
+	for _, item := range items {
+		info, infoErr := document.GetItemInfo(item)
+		if infoErr != nil {
+			return infoErr
+		}
+		if strings.ContainsAny(info.Name, "`\",\\") {
+			return fmt.Errorf("item name '%s' can't be used as Go struct tag", info.Name)
+		}
+
+		itemPath := info.Name
+		if path != "" {
+			itemPath = path + "/" + info.Name
+		}
+
+		fieldName := uniqueName(fieldNames, ToIdentifier(info.Name))
+		fieldType := ""
+		comment := ""
+		switch info.Kind {
+		case document.ItemInt:
+			fieldType = "int64"
+		case document.ItemStr:
+			fieldType = "string"
+			if ref, ok := generator.refs[itemPath]; ok {
+				comment = " //ID of " + ref.TargetSchema + " record"
+			} else if info.LenLimit > 0 {
+				comment = fmt.Sprintf(" //max %d characters", info.LenLimit)
+			}
+		case document.ItemBool:
+			fieldType = "bool"
+		case document.ItemDecimal:
+			fieldType = "json.Number"
+			comment = fmt.Sprintf(" //%d decimal places", info.Precision)
+		case document.ItemFile:
+			fieldType = "string"
+		case document.ItemSection:
+			fieldType = uniqueName(generator.typeNames, typeName+ToIdentifier(info.Name))
+			if err := generator.addStruct(fieldType, info.Items, itemPath,
+				fmt.Sprintf("//%s section %s of %s\n", fieldType, itemPath, generator.schema.Name), ""); err != nil {
+				return err
+			}
+		}
+
+		isComputed := generator.computed[itemPath]
+		if isComputed && comment == "" {
+			comment = " //computed by server, leave it nil on submit"
+		} else if isComputed {
+			comment += "; computed by server, leave it nil on submit"
+		}
+
+		if info.IsArray {
+			fieldType = "[]" + fieldType
+		} else if info.IsOptional || isComputed {
+			fieldType = "*" + fieldType
+		}
+
+		omitEmpty := ""
+		if info.IsOptional || isComputed {
+			omitEmpty = ",omitempty"
+		}
+		xmlTag := "-"
+		if document.IsXMLName(info.Name) {
+			xmlTag = info.Name + omitEmpty
+		}
+
+		fmt.Fprintf(&buffer, "%s %s `json:\"%s%s\" xml:\"%s\"`%s\n",
+			fieldName, fieldType, info.Name, omitEmpty, xmlTag, comment)
+	}
+
+	buffer.WriteString("}\n")
+	generator.types[index] = buffer.String()
+
+	return nil
+}
+
+const goImports = `import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+`
+
+//goClientTemplate typed client source, {{root}} is root struct name and {{schemaPath}} is URL path of schema
+const goClientTemplate = `//Record document record of {{root}}
+type Record struct {
+	ID       string    ` + "`json:\"id\"`" + `
+	Number   string    ` + "`json:\"number,omitempty\"`" + `
+	Schema   string    ` + "`json:\"schema\"`" + `
+	Revision int       ` + "`json:\"revision\"`" + `
+	State    string    ` + "`json:\"state\"`" + `
+	Version  int       ` + "`json:\"version\"`" + `
+	Data     {{root}}  ` + "`json:\"data\"`" + `
+	Created  time.Time ` + "`json:\"created\"`" + `
+	Updated  time.Time ` + "`json:\"updated\"`" + `
+}
+
+//Violation single validation failure reported by gxdoc
+type Violation struct {
+	Path    string      ` + "`json:\"path\"`" + `
+	Rule    string      ` + "`json:\"rule\"`" + `
+	Value   interface{} ` + "`json:\"value,omitempty\"`" + `
+	Message string      ` + "`json:\"message\"`" + `
+}
+
+//ValidationResult result of validate endpoint
+type ValidationResult struct {
+	IsValid  bool        ` + "`json:\"isValid\"`" + `
+	Message  string      ` + "`json:\"message\"`" + `
+	Revision int         ` + "`json:\"revision\"`" + `
+	IsDraft  bool        ` + "`json:\"isDraft\"`" + `
+	Errors   []Violation ` + "`json:\"errors\"`" + `
+}
+
+//APIError request rejected by gxdoc
+type APIError struct {
+	StatusCode int         ` + "`json:\"-\"`" + `
+	Code       int         ` + "`json:\"errorCode\"`" + `
+	Message    string      ` + "`json:\"errorMessage\"`" + `
+	Errors     []Violation ` + "`json:\"errors\"`" + `
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("gxdoc: HTTP %d: %s", err.StatusCode, err.Message)
+}
+
+//Client typed client of gxdoc validate and record endpoints of {{root}}
+type Client struct {
+	BaseURL    string       //gxdoc API base URL; e.g. http://localhost:8080/api
+	HTTPClient *http.Client //http.DefaultClient is used if nil
+}
+
+//NewClient create client of gxdoc API at baseURL; e.g. http://localhost:8080/api
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+//Validate validate data against schema revision which structs are generated from
+func (client *Client) Validate(ctx context.Context, data *{{root}}) (*ValidationResult, error) {
+	result := ValidationResult{}
+	if err := client.do(ctx, "POST", "/validate?revision="+strconv.Itoa(SchemaRevision), data, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+//Submit submit a new document record, data is validated against latest schema revision;
+//invalid data is rejected with *APIError listing every violation
+func (client *Client) Submit(ctx context.Context, data *{{root}}) (*Record, error) {
+	record := Record{}
+	if err := client.do(ctx, "POST", "/records", data, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+//Get get document record by ID
+func (client *Client) Get(ctx context.Context, id string) (*Record, error) {
+	record := Record{}
+	if err := client.do(ctx, "GET", "/records/"+url.PathEscape(id), nil, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (client *Client) do(ctx context.Context, method string, path string, input interface{}, output interface{}) error {
+	var body io.Reader
+	if input != nil {
+		inputRaw, inputErr := json.Marshal(input)
+		if inputErr != nil {
+			return inputErr
+		}
+		body = bytes.NewReader(inputRaw)
+	}
+
+	request, requestErr := http.NewRequest(method, client.BaseURL+"{{schemaPath}}"+path, body)
+	if requestErr != nil {
+		return requestErr
+	}
+	request = request.WithContext(ctx)
+	if input != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+
+	responseRaw, readErr := ioutil.ReadAll(response.Body)
+	if readErr != nil {
+		return readErr
+	}
+
+	if response.StatusCode != http.StatusOK {
+		apiErr := APIError{StatusCode: response.StatusCode}
+		if json.Unmarshal(responseRaw, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(response.StatusCode)
+		}
+
+		return &apiErr
+	}
+
+	envelope := struct {
+		Response interface{} ` + "`json:\"response\"`" + `
+	}{output}
+
+	return json.Unmarshal(responseRaw, &envelope)
+}
+`
@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+func TestGenerateGo(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 3,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxStr{Name: "customer", IsOptional: true},
+			gxschema.DxInt{Name: "total qty", IsOptional: true},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+			}},
+			gxschema.DxDecimal{Name: "total", Precision: 2},
+		},
+	}
+	refs := []document.Reference{{ItemPath: "customer", TargetSchema: "customer"}}
+
+	computed := []document.ComputedField{{Path: "total", Expression: "1"}}
+
+	source, err := GenerateGo(schema, refs, computed, "invoice")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//compare with whitespace collapsed, gofmt aligns struct fields
+	normalized := strings.Join(strings.Fields(string(source)), " ")
+	expects := []string{
+		"package invoice",
+		"const SchemaRevision = 3",
+		"InvNo string `json:\"invNo\" xml:\"invNo\"` //max 10 characters",
+		"Customer *string `json:\"customer,omitempty\" xml:\"customer,omitempty\"` //ID of customer record",
+		"TotalQty *int64 `json:\"total qty,omitempty\" xml:\"-\"`",
+		"Items []InvoiceItems `json:\"items\" xml:\"items\"`",
+		"Price json.Number `json:\"price\" xml:\"price\"` //2 decimal places",
+		"Total *json.Number `json:\"total,omitempty\" xml:\"total,omitempty\"` //2 decimal places; computed by server",
+		"func (client *Client) Submit(ctx context.Context, data *Invoice) (*Record, error)",
+	}
+	for _, expect := range expects {
+		if !strings.Contains(normalized, expect) {
+			t.Errorf("expect generated source contains\n%s\nbut get:\n%s", expect, source)
+		}
+	}
+
+	//generated source must compile
+	fset := token.NewFileSet()
+	file, parseErr := parser.ParseFile(fset, "invoice.go", source, 0)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+		return
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("invoice", fset, []*ast.File{file}, nil); err != nil {
+		t.Errorf("generated source doesn't compile: %s", err.Error())
+	}
+}
+
+func TestToIdentifier(t *testing.T) {
+	cases := map[string]string{
+		"invNo":          "InvNo",
+		"pr number":      "PrNumber",
+		"purchase-order": "PurchaseOrder",
+		"2nd_copy":       "X2ndCopy",
+		"--":             "Item",
+	}
+
+	for name, expect := range cases {
+		if result := ToIdentifier(name); result != expect {
+			t.Errorf("expect %s become %s but get %s", name, expect, result)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+//formItem schema item rendered as form input or group
+type formItem struct {
+	Name      string
+	Kind      string
+	Required  bool
+	IsArray   bool
+	MaxLength int
+	Step      string //decimal only
+	RefSchema string //str only, referenced schema
+	Items     []formItem
+}
+
+//formPage data of HTML form template
+type formPage struct {
+	SchemaName string
+	Revision   int
+	Action     string
+	Items      []formItem
+}
+
+//GenerateHTMLForm generate HTML form of schema revision which submit JSON data to actionURL
+//	int and decimal become number input, str become text input, bool become checkbox, file become file input
+//	(submitted as data URL), section become fieldset and array item become repeatable group;
+//	computed fields are skipped since they are filled in by server;
+//	violations of 400 response are shown next to offending input
+func GenerateHTMLForm(schema *gxschema.DxDoc, refs []document.Reference, computed []document.ComputedField,
+	actionURL string) ([]byte, error) {
+	refMap := map[string]document.Reference{}
+	for _, ref := range refs {
+		refMap[ref.ItemPath] = ref
+	}
+	computedPaths := map[string]bool{}
+	for _, field := range computed {
+		computedPaths[field.Path] = true
+	}
+
+	items, itemsErr := getFormItems(schema.Items, "", refMap, computedPaths)
+	if itemsErr != nil {
+		return nil, itemsErr
+	}
+
+	buffer := bytes.Buffer{}
+	page := formPage{SchemaName: schema.Name, Revision: schema.Revision, Action: actionURL, Items: items}
+	if err := formTemplate.Execute(&buffer, page); err != nil {
+		return nil, fmt.Errorf("failed to render form of %s: %s", schema.Name, err.Error())
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func getFormItems(items []gxschema.DxItem, path string, refMap map[string]document.Reference,
+	computedPaths map[string]bool) ([]formItem, error) {
+	results := []formItem{}
+	for _, item := range items {
+		info, infoErr := document.GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+
+		itemPath := info.Name
+		if path != "" {
+			itemPath = path + "/" + info.Name
+		}
+		if computedPaths[itemPath] {
+			continue
+		}
+
+		result := formItem{Name: info.Name, Kind: info.Kind, Required: !info.IsOptional, IsArray: info.IsArray}
+		switch info.Kind {
+		case document.ItemStr:
+			result.MaxLength = info.LenLimit
+			if ref, ok := refMap[itemPath]; ok {
+				result.RefSchema = ref.TargetSchema
+			}
+		case document.ItemDecimal:
+			result.Step = strconv.FormatFloat(math.Pow10(-info.Precision), 'f', -1, 64)
+		case document.ItemSection:
+			subItems, subErr := getFormItems(info.Items, itemPath, refMap, computedPaths)
+			if subErr != nil {
+				return nil, subErr
+			}
+			result.Items = subItems
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+var formTemplate = template.Must(template.New("form").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.SchemaName}}</title>
+<style>
+  form[data-gxdoc-form] { font-family: sans-serif; max-width: 40em; }
+  .gxdoc-item, .gxdoc-entry { margin: 0.5em 0; }
+  .gxdoc-entry { border-left: 3px solid #ccc; padding-left: 0.5em; }
+  .gxdoc-error, .gxdoc-summary-errors { color: #c00; }
+  .gxdoc-invalid > label > input, .gxdoc-invalid > input { border-color: #c00; }
+</style>
+</head>
+<body>
+<form data-gxdoc-form action="{{.Action}}" novalidate>
+<h1>{{.SchemaName}} <small>revision {{.Revision}}</small></h1>
+<div class="gxdoc-items">
+{{range .Items}}{{template "item" .}}{{end}}
+</div>
+<div class="gxdoc-summary"></div>
+<ul class="gxdoc-summary-errors"></ul>
+<button type="submit">Submit</button>
+</form>
+<script>
+` + formScript + `
+</script>
+</body>
+</html>
+{{define "input"}}{{if eq .Kind "section"}}{{range .Items}}{{template "item" .}}{{end}}{{else if eq .Kind "bool"}}<input type="checkbox">{{else if eq .Kind "int"}}<input type="number" step="1"{{if .Required}} required{{end}}>{{else if eq .Kind "decimal"}}<input type="number" step="{{.Step}}"{{if .Required}} required{{end}}>{{else if eq .Kind "file"}}<input type="file"{{if .Required}} required{{end}}>{{else}}<input type="text"{{if .MaxLength}} maxlength="{{.MaxLength}}"{{end}}{{if .RefSchema}} placeholder="ID of {{.RefSchema}} record"{{end}}{{if .Required}} required{{end}}>{{end}}{{end}}
+{{define "item"}}{{if .IsArray}}<fieldset class="gxdoc-item" data-item="{{.Name}}" data-kind="{{.Kind}}" data-array{{if not .Required}} data-optional{{end}}>
+<legend>{{.Name}}</legend>
+<div class="gxdoc-entries"></div>
+<template><div class="gxdoc-entry">
+{{template "input" .}}
+<button type="button" data-remove>Remove</button>
+<div class="gxdoc-error"></div>
+</div></template>
+<button type="button" data-add>Add {{.Name}}</button>
+<div class="gxdoc-error"></div>
+</fieldset>
+{{else if eq .Kind "section"}}<fieldset class="gxdoc-item" data-item="{{.Name}}" data-kind="section"{{if not .Required}} data-optional{{end}}>
+<legend>{{.Name}}</legend>
+{{template "input" .}}
+<div class="gxdoc-error"></div>
+</fieldset>
+{{else}}<div class="gxdoc-item" data-item="{{.Name}}" data-kind="{{.Kind}}"{{if not .Required}} data-optional{{end}}>
+<label>{{.Name}} {{template "input" .}}</label>
+<div class="gxdoc-error"></div>
+</div>
+{{end}}{{end}}`))
+
+//formScript collect form input into JSON data, submit it and show violations next to offending input
+const formScript = `(function () {
+  var form = document.querySelector("form[data-gxdoc-form]");
+  var summary = form.querySelector(".gxdoc-summary");
+  var summaryErrors = form.querySelector(".gxdoc-summary-errors");
+  var fileContents = new WeakMap();
+
+  function escapePointer(name) {
+    return name.replace(/~/g, "~0").replace(/\//g, "~1");
+  }
+
+  function readInput(kind, element, optional) {
+    var input = element.querySelector(":scope > input, :scope > label > input");
+    if (kind === "bool") {
+      return input.checked;
+    }
+    if (kind === "file") {
+      return fileContents.get(input);
+    }
+    if (input.value === "") {
+      return optional ? undefined : (kind === "str" ? "" : null);
+    }
+    if (kind === "int" || kind === "decimal") {
+      return Number(input.value);
+    }
+    return input.value;
+  }
+
+  function readValue(kind, element, path, fields, optional) {
+    if (kind === "section") {
+      var obj = {};
+      readItems(element, obj, path, fields);
+      return obj;
+    }
+    return readInput(kind, element, optional);
+  }
+
+  function readItems(container, obj, path, fields) {
+    container.querySelectorAll(":scope > .gxdoc-item").forEach(function (element) {
+      var name = element.dataset.item;
+      var kind = element.dataset.kind;
+      var optional = element.hasAttribute("data-optional");
+      var itemPath = path + "/" + escapePointer(name);
+      fields[itemPath] = element;
+
+      if (element.hasAttribute("data-array")) {
+        var entries = element.querySelector(":scope > .gxdoc-entries").children;
+        if (entries.length === 0 && optional) {
+          return;
+        }
+        var values = [];
+        for (var i = 0; i < entries.length; i++) {
+          fields[itemPath + "/" + i] = entries[i];
+          var value = readValue(kind, entries[i], itemPath + "/" + i, fields, false);
+          values.push(value === undefined ? null : value);
+        }
+        obj[name] = values;
+        return;
+      }
+
+      var value = readValue(kind, element, itemPath, fields, optional);
+      if (value !== undefined) {
+        obj[name] = value;
+      }
+    });
+  }
+
+  function clearErrors() {
+    summary.textContent = "";
+    summaryErrors.innerHTML = "";
+    form.querySelectorAll(".gxdoc-error").forEach(function (box) { box.textContent = ""; });
+    form.querySelectorAll(".gxdoc-invalid").forEach(function (element) { element.classList.remove("gxdoc-invalid"); });
+  }
+
+  function showErrors(body, fields) {
+    summary.textContent = body.errorMessage || "failed to submit";
+    (body.errors || []).forEach(function (violation) {
+      //fall back to nearest parent when offending value has no input; e.g. missing array element
+      var path = violation.path || "";
+      var element = fields[path];
+      while (!element && path.lastIndexOf("/") > 0) {
+        path = path.substring(0, path.lastIndexOf("/"));
+        element = fields[path];
+      }
+      var box = element ? element.querySelector(":scope > .gxdoc-error") : null;
+      if (!box) {
+        var line = document.createElement("li");
+        line.textContent = (violation.path ? violation.path + ": " : "") + violation.message;
+        summaryErrors.appendChild(line);
+        return;
+      }
+      box.textContent += (box.textContent ? "; " : "") + violation.message;
+      element.classList.add("gxdoc-invalid");
+    });
+  }
+
+  form.addEventListener("click", function (event) {
+    var target = event.target;
+    if (target.hasAttribute("data-add")) {
+      var group = target.closest("[data-array]");
+      var entry = group.querySelector(":scope > template").content.firstElementChild.cloneNode(true);
+      group.querySelector(":scope > .gxdoc-entries").appendChild(entry);
+    } else if (target.hasAttribute("data-remove")) {
+      target.closest(".gxdoc-entry").remove();
+    }
+  });
+
+  form.addEventListener("change", function (event) {
+    var input = event.target;
+    if (input.type !== "file") {
+      return;
+    }
+    fileContents.delete(input);
+    if (input.files.length > 0) {
+      var reader = new FileReader();
+      reader.onload = function () { fileContents.set(input, reader.result); };
+      reader.readAsDataURL(input.files[0]);
+    }
+  });
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    clearErrors();
+
+    var data = {};
+    var fields = {};
+    readItems(form.querySelector(".gxdoc-items"), data, "", fields);
+
+    fetch(form.getAttribute("action"), {
+      method: "POST",
+      headers: {"Content-Type": "application/json"},
+      body: JSON.stringify(data)
+    }).then(function (response) {
+      return response.json().then(function (body) {
+        return {status: response.status, body: body};
+      });
+    }).then(function (result) {
+      if (result.status === 200) {
+        summary.textContent = "record " + result.body.response.id + " is created";
+        return;
+      }
+      showErrors(result.body, fields);
+    }).catch(function (err) {
+      summary.textContent = "failed to submit: " + err.message;
+    });
+  });
+})();`
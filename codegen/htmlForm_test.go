@@ -0,0 +1,80 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+func TestGenerateHTMLForm(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 3,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxStr{Name: "customer", IsOptional: true},
+			gxschema.DxBool{Name: "paid"},
+			gxschema.DxFile{Name: "attachment", IsOptional: true},
+			gxschema.DxDecimal{Name: "total", Precision: 2},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxStr{Name: "<note>", IsOptional: true},
+			}},
+		},
+	}
+	refs := []document.Reference{{ItemPath: "customer", TargetSchema: "customer"}}
+	computed := []document.ComputedField{{Path: "total", Expression: "return 0"}}
+
+	page, err := GenerateHTMLForm(schema, refs, computed, "../api/document/invoice/records")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	html := string(page)
+
+	expects := []string{
+		`action="../api/document/invoice/records"`,
+		`data-item="invNo" data-kind="str"`,
+		`maxlength="10"`,
+		`placeholder="ID of customer record"`,
+		`<input type="checkbox">`,
+		`data-item="attachment" data-kind="file" data-optional`,
+		`<input type="file">`,
+		`data-item="items" data-kind="section" data-array`,
+		`<template><div class="gxdoc-entry">`,
+		`<input type="number" step="1" required>`,
+		`data-item="&lt;note&gt;"`,
+	}
+	for _, expect := range expects {
+		if !strings.Contains(html, expect) {
+			t.Errorf("expect generated form contains\n%s\nbut get:\n%s", expect, html)
+		}
+	}
+
+	if strings.Contains(html, `data-item="total"`) {
+		t.Errorf("expect computed field is excluded from form")
+	}
+	if strings.Contains(html, "<note>") {
+		t.Errorf("expect item name is escaped")
+	}
+}
+
+func TestGenerateHTMLFormDecimalStep(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "price",
+		Revision: 1,
+		Items:    []gxschema.DxItem{gxschema.DxDecimal{Name: "amount", Precision: 3}},
+	}
+
+	page, err := GenerateHTMLForm(schema, nil, nil, "records")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if !strings.Contains(string(page), `<input type="number" step="0.001" required>`) {
+		t.Errorf("expect decimal step follows precision but get:\n%s", page)
+	}
+}
@@ -0,0 +1,154 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+//TypeScript type of decimal item
+const (
+	DecimalAsNumber = "number" //same as JSON accepted by gxdoc
+	DecimalAsString = "string" //keep precision, convert to number before submit
+)
+
+var tsIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+//tsGenerator generate TypeScript declaration of document schema
+type tsGenerator struct {
+	schema      *gxschema.DxDoc
+	refs        map[string]document.Reference
+	computed    map[string]bool
+	decimalType string
+	typeNames   map[string]bool
+	types       []string //source of every interface, root first
+}
+
+//GenerateTypeScript generate TypeScript declaration (.d.ts) of schema revision
+//	optional and computed item become optional property, array item become array and section become nested interface;
+//	decimalType is either DecimalAsNumber or DecimalAsString
+//	refs (optional) are references of the schema revision
+//	computed (optional) are computed fields of the schema revision
+func GenerateTypeScript(schema *gxschema.DxDoc, refs []document.Reference, computed []document.ComputedField,
+	decimalType string) ([]byte, error) {
+	if decimalType != DecimalAsNumber && decimalType != DecimalAsString {
+		return nil, fmt.Errorf("decimal type must be either %s or %s", DecimalAsNumber, DecimalAsString)
+	}
+
+	generator := tsGenerator{
+		schema:      schema,
+		refs:        map[string]document.Reference{},
+		computed:    document.GetComputedPaths(computed),
+		decimalType: decimalType,
+		typeNames:   map[string]bool{},
+	}
+	for _, ref := range refs {
+		generator.refs[ref.ItemPath] = ref
+	}
+
+	rootName := uniqueName(generator.typeNames, ToIdentifier(schema.Name))
+	revisionName := uniqueName(generator.typeNames, rootName+"Revision")
+	if err := generator.addInterface(rootName, schema.Items, "",
+		fmt.Sprintf("/** data of gxdoc schema %s revision %d */\n", tsComment(schema.Name), schema.Revision)); err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString("// Code generated by gxdoc codegen; DO NOT EDIT.\n")
+	fmt.Fprintf(&buffer, "// gxdoc schema %s revision %d, decimal as %s\n\n", tsComment(schema.Name), schema.Revision,
+		decimalType)
+	fmt.Fprintf(&buffer, "/** gxdoc schema revision which declarations are generated from */\n")
+	fmt.Fprintf(&buffer, "export type %s = %d;\n\n", revisionName, schema.Revision)
+	for _, source := range generator.types {
+		buffer.WriteString(source)
+		buffer.WriteString("\n")
+	}
+
+	return append(bytes.TrimRight(buffer.Bytes(), "\n"), '\n'), nil
+}
+
+func (generator *tsGenerator) addInterface(typeName string, items []gxschema.DxItem, path string, comment string) error {
+	index := len(generator.types)
+	generator.types = append(generator.types, "") //reserve position so parent is declared before children
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString(comment)
+	fmt.Fprintf(&buffer, "export interface %s {\n", typeName)
+
+	for _, item := range items {
+		info, infoErr := document.GetItemInfo(item)
+		if infoErr != nil {
+			return infoErr
+		}
+
+		itemPath := info.Name
+		if path != "" {
+			itemPath = path + "/" + info.Name
+		}
+
+		propertyType := ""
+		comment := ""
+		switch info.Kind {
+		case document.ItemInt:
+			propertyType = "number"
+			comment = "integer"
+		case document.ItemStr:
+			propertyType = "string"
+			if ref, ok := generator.refs[itemPath]; ok {
+				comment = "ID of " + tsComment(ref.TargetSchema) + " record"
+			} else if info.LenLimit > 0 {
+				comment = fmt.Sprintf("max %d characters", info.LenLimit)
+			}
+		case document.ItemBool:
+			propertyType = "boolean"
+		case document.ItemDecimal:
+			propertyType = generator.decimalType
+			comment = fmt.Sprintf("decimal, %d decimal places", info.Precision)
+		case document.ItemFile:
+			propertyType = "string"
+			comment = "file"
+		case document.ItemSection:
+			propertyType = uniqueName(generator.typeNames, typeName+ToIdentifier(info.Name))
+			if err := generator.addInterface(propertyType, info.Items, itemPath,
+				fmt.Sprintf("/** section %s of %s */\n", tsComment(itemPath), tsComment(generator.schema.Name))); err != nil {
+				return err
+			}
+		}
+
+		if info.IsArray {
+			propertyType += "[]"
+		}
+
+		propertyName := info.Name
+		if !tsIdentifierPattern.MatchString(propertyName) {
+			quoted, _ := json.Marshal(propertyName)
+			propertyName = string(quoted)
+		}
+		if info.IsOptional || generator.computed[itemPath] {
+			propertyName += "?"
+		}
+		if generator.computed[itemPath] {
+			comment = strings.TrimPrefix(comment+", computed by server, omit on submit", ", ")
+		}
+
+		if comment != "" {
+			fmt.Fprintf(&buffer, "  /** %s */\n", comment)
+		}
+		fmt.Fprintf(&buffer, "  %s: %s;\n", propertyName, propertyType)
+	}
+
+	buffer.WriteString("}\n")
+	generator.types[index] = buffer.String()
+
+	return nil
+}
+
+//tsComment escape text within TypeScript comment
+func tsComment(text string) string {
+	return strings.NewReplacer("*/", "* /", "\n", " ").Replace(text)
+}
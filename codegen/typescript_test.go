@@ -0,0 +1,61 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 3,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxStr{Name: "customer", IsOptional: true},
+			gxschema.DxInt{Name: "total qty", IsOptional: true},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+			}},
+			gxschema.DxDecimal{Name: "total", Precision: 2},
+		},
+	}
+	refs := []document.Reference{{ItemPath: "customer", TargetSchema: "customer"}}
+
+	computed := []document.ComputedField{{Path: "total", Expression: "1"}}
+
+	source, err := GenerateTypeScript(schema, refs, computed, DecimalAsString)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	expects := []string{
+		"export type InvoiceRevision = 3;",
+		"export interface Invoice {",
+		"  invNo: string;",
+		"  /** ID of customer record */\n  customer?: string;",
+		"  \"total qty\"?: number;",
+		"  items: InvoiceItems[];",
+		"export interface InvoiceItems {",
+		"  price: string;",
+		"  /** decimal, 2 decimal places, computed by server, omit on submit */\n  total?: string;",
+	}
+	for _, expect := range expects {
+		if !strings.Contains(string(source), expect) {
+			t.Errorf("expect generated source contains\n%s\nbut get:\n%s", expect, source)
+		}
+	}
+
+	numberSource, _ := GenerateTypeScript(schema, refs, nil, DecimalAsNumber)
+	if !strings.Contains(string(numberSource), "  price: number;") {
+		t.Errorf("expect decimal as number but get:\n%s", numberSource)
+	}
+
+	if _, err := GenerateTypeScript(schema, refs, nil, "bigint"); err == nil {
+		t.Errorf("expect unknown decimal type is rejected")
+	}
+}
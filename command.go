@@ -0,0 +1,289 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/guinso/gxdoc/audit"
+	"github.com/guinso/gxdoc/codegen"
+	"github.com/guinso/gxdoc/configuration"
+	"github.com/guinso/gxdoc/document"
+	"github.com/guinso/gxschema"
+	"github.com/guinso/stringtool"
+)
+
+const commandUsage = `usage:
+  gxdoc                      start web server
+  gxdoc codegen go [flags]   generate Go structs and typed client of a schema revision
+  gxdoc codegen ts [flags]   generate TypeScript declaration (.d.ts) of a schema revision
+  gxdoc sync [flags]         synchronise schemas with directory of XML definitions
+
+run 'gxdoc codegen go -h', 'gxdoc codegen ts -h' or 'gxdoc sync -h' to list flags
+`
+
+//runCommand run command line tool instead of web server, return process exit code
+func runCommand(args []string) int {
+	if len(args) >= 1 && args[0] == "sync" {
+		return runSync(args[1:])
+	}
+	if len(args) >= 2 && args[0] == "codegen" {
+		switch args[1] {
+		case "go":
+			return runCodegenGo(args[2:])
+		case "ts":
+			return runCodegenTypeScript(args[2:])
+		}
+	}
+
+	fmt.Fprint(os.Stderr, commandUsage)
+	return 2
+}
+
+func runCodegenGo(args []string) int {
+	flags := flag.NewFlagSet("codegen go", flag.ContinueOnError)
+	schemaName := flags.String("schema", "", "schema name (required)")
+	revision := flags.Int("revision", 0, "schema revision, latest revision if not specified")
+	packageName := flags.String("package", "", "Go package name, derived from schema name if not specified")
+	output := flags.String("out", "", "output file, standard output if not specified")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *schemaName == "" {
+		fmt.Fprintln(os.Stderr, "-schema is required")
+		flags.Usage()
+		return 2
+	}
+	if *packageName == "" {
+		*packageName = codegen.GoPackageName(*schemaName)
+	}
+
+	schema, refs, computed, schemaErr := loadCommandSchema(*schemaName, *revision)
+	if schemaErr != nil {
+		fmt.Fprintln(os.Stderr, schemaErr.Error())
+		return 1
+	}
+
+	source, genErr := codegen.GenerateGo(schema, refs, computed, *packageName)
+	if genErr != nil {
+		fmt.Fprintln(os.Stderr, genErr.Error())
+		return 1
+	}
+
+	return writeCommandOutput(*output, source)
+}
+
+func runCodegenTypeScript(args []string) int {
+	flags := flag.NewFlagSet("codegen ts", flag.ContinueOnError)
+	schemaName := flags.String("schema", "", "schema name (required)")
+	revision := flags.Int("revision", 0, "schema revision, latest revision if not specified")
+	decimalType := flags.String("decimal", codegen.DecimalAsNumber, "TypeScript type of decimal item, number or string")
+	output := flags.String("out", "", "output file, standard output if not specified")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *schemaName == "" {
+		fmt.Fprintln(os.Stderr, "-schema is required")
+		flags.Usage()
+		return 2
+	}
+
+	if *decimalType != codegen.DecimalAsNumber && *decimalType != codegen.DecimalAsString {
+		fmt.Fprintln(os.Stderr, "-decimal must be either number or string")
+		return 2
+	}
+
+	schema, refs, computed, schemaErr := loadCommandSchema(*schemaName, *revision)
+	if schemaErr != nil {
+		fmt.Fprintln(os.Stderr, schemaErr.Error())
+		return 1
+	}
+
+	source, genErr := codegen.GenerateTypeScript(schema, refs, computed, *decimalType)
+	if genErr != nil {
+		fmt.Fprintln(os.Stderr, genErr.Error())
+		return 1
+	}
+
+	return writeCommandOutput(*output, source)
+}
+
+//runSync bring schemas of database in line with directory of XML definitions, see document.PlanSchemaSync
+//	with -check nothing is written, exit code is 1 if database and directory disagree
+func runSync(args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dir := flags.String("dir", ".", "directory of schema definitions: {name}.xml and optional {name}/revisions/{revision}.xml")
+	check := flags.Bool("check", false, "report differences only, exit with 1 if database and directory disagree")
+	strict := flags.Bool("strict", false, "with -check, schemas in database without definition file count as disagreement")
+	release := flags.Bool("release", false, "release changed definition as new revision instead of saving it as draft")
+	remark := flags.String("remark", "", "remark of saved draft and released revisions")
+	actor := flags.String("actor", "sync", "actor recorded in audit log")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files, dirErr := document.ReadSchemaDirectory(*dir)
+	if dirErr != nil {
+		fmt.Fprintln(os.Stderr, dirErr.Error())
+		return 1
+	}
+
+	if err := configuration.LoadINIConfigFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration file: %s\n", err.Error())
+		return 1
+	}
+	db, dbErr := checkDbConnection(configuration.GetConfig())
+	if dbErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect database: %s\n", dbErr.Error())
+		return 1
+	}
+	defer db.Close()
+
+	changes, planErr := document.PlanSchemaSync(db, files, *release)
+	if planErr != nil {
+		fmt.Fprintln(os.Stderr, planErr.Error())
+		return 1
+	}
+
+	hasConflict := false
+	for _, change := range changes {
+		fmt.Printf("%s: %s\n", change.Schema, change.Message)
+		hasConflict = hasConflict || change.Action == document.SyncConflict
+	}
+
+	if *check {
+		if isSyncPending(changes, *strict) {
+			return 1
+		}
+
+		fmt.Println("database and directory are in sync")
+		return 0
+	}
+	if hasConflict {
+		fmt.Fprintln(os.Stderr, "released revisions can't be changed, nothing is synchronised")
+		return 1
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		fmt.Fprintln(os.Stderr, trxErr.Error())
+		return 1
+	}
+	if err := applySyncChanges(trx, changes, *remark, *actor); err != nil {
+		trx.Rollback()
+
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if err := trx.Commit(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+//isSyncPending check any planned change means database and directory disagree;
+//untracked schemas are only counted in strict mode since sync never touches them
+func isSyncPending(changes []document.SyncChange, strict bool) bool {
+	for _, change := range changes {
+		if change.Action != document.SyncUntracked || strict {
+			return true
+		}
+	}
+
+	return false
+}
+
+//applySyncChanges write every sync change into database with audit log, changes of one run share request ID
+func applySyncChanges(trx *sql.Tx, changes []document.SyncChange, remark string, actor string) error {
+	requestID, idErr := stringtool.GenerateRandomUUID()
+	if idErr != nil {
+		return fmt.Errorf("failed to generate request ID: %s", idErr.Error())
+	}
+
+	actions := map[string]string{
+		document.SyncCreateSchema: audit.ActionAddSchemaInfo,
+		document.SyncRelease:      audit.ActionAddSchema,
+		document.SyncSaveDraft:    audit.ActionSaveSchemaDraft,
+	}
+	for _, change := range changes {
+		action, ok := actions[change.Action]
+		if !ok {
+			continue //conflict and untracked schema are left untouched
+		}
+
+		if err := document.ApplySyncChange(trx, change, remark); err != nil {
+			return fmt.Errorf("%s: %s", change.Schema, err.Error())
+		}
+
+		entry := audit.Entry{Actor: actor, Action: action, TargetSchema: change.Schema,
+			After: change.GetDefinition(), RequestID: requestID}
+		if err := audit.Write(trx, &entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//loadCommandSchema get schema revision with its references and computed fields from database configured in config.ini
+//	revision 0 means latest revision
+func loadCommandSchema(name string, revision int) (*gxschema.DxDoc, []document.Reference,
+	[]document.ComputedField, error) {
+	if err := configuration.LoadINIConfigFile(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load configuration file: %s", err.Error())
+	}
+
+	db, dbErr := checkDbConnection(configuration.GetConfig())
+	if dbErr != nil {
+		return nil, nil, nil, fmt.Errorf("failed to connect database: %s", dbErr.Error())
+	}
+	defer db.Close()
+
+	var schema *gxschema.DxDoc
+	var schemaErr error
+	if revision == 0 {
+		schema, schemaErr = document.GetSchema(db, name)
+	} else {
+		schema, schemaErr = document.GetSchemaByRevision(db, name, revision)
+	}
+	if schemaErr != nil {
+		return nil, nil, nil, schemaErr
+	}
+	if schema == nil && revision == 0 {
+		return nil, nil, nil, fmt.Errorf("schema %s has no released revision", name)
+	}
+	if schema == nil {
+		return nil, nil, nil, fmt.Errorf("schema %s revision %d not found", name, revision)
+	}
+
+	refs, refErr := document.GetReferences(db, name, schema.Revision)
+	if refErr != nil {
+		return nil, nil, nil, refErr
+	}
+
+	computed, computedErr := document.GetComputedFields(db, name, schema.Revision)
+	if computedErr != nil {
+		return nil, nil, nil, computedErr
+	}
+
+	return schema, refs, computed, nil
+}
+
+//writeCommandOutput write generated content into file, or standard output if filename is empty
+func writeCommandOutput(filename string, content []byte) int {
+	if filename == "" {
+		os.Stdout.Write(content)
+		return 0
+	}
+
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guinso/gxdoc/document"
+)
+
+func TestRunSyncExitCode(t *testing.T) {
+	if code := runSync([]string{"-unknown"}); code != 2 {
+		t.Errorf("expect exit code 2 on unknown flag but get %d", code)
+	}
+
+	missing := filepath.Join(os.TempDir(), "gxdoc-sync-not-exists")
+	if code := runSync([]string{"-check", "-dir", missing}); code != 1 {
+		t.Errorf("expect exit code 1 on missing directory but get %d", code)
+	}
+}
+
+func TestIsSyncPending(t *testing.T) {
+	untracked := document.SyncChange{Schema: "pr", Action: document.SyncUntracked}
+	release := document.SyncChange{Schema: "invoice", Action: document.SyncRelease}
+
+	cases := []struct {
+		changes []document.SyncChange
+		strict  bool
+		expect  bool
+	}{
+		{[]document.SyncChange{}, false, false},
+		{[]document.SyncChange{untracked}, false, false},
+		{[]document.SyncChange{untracked}, true, true},
+		{[]document.SyncChange{untracked, release}, false, true},
+	}
+	for index, c := range cases {
+		if result := isSyncPending(c.changes, c.strict); result != c.expect {
+			t.Errorf("case %d: expect pending %v but get %v", index, c.expect, result)
+		}
+	}
+}
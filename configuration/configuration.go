@@ -33,6 +33,8 @@ type ConfigInfo struct {
 	LogicDir    string //directory where store logical physical files; e.g. pay-slip.pdf
 	StaticDir   string //directory where store direct access physical files; e.g. index.html
 
+	TrustRoleHeader bool //TrustRoleHeader accept 'X-Actor' and 'X-Roles' header; only enable behind trusted proxy
+
 	AdminUsername string //AdminUsername system admin username
 	AdminPassword string //AdminPassword system admin password
 
@@ -84,6 +86,9 @@ func LoadINIConfigFile() error {
 		if _, err = sec.NewKey("logical_dir", "logical-files"); err != nil {
 			return err
 		}
+		if _, err = sec.NewKey("trust_role_header", "false"); err != nil {
+			return err
+		}
 
 		sec, err = cfg.NewSection("admin")
 		if _, err = sec.NewKey("username", "admin"); err != nil {
@@ -175,6 +180,11 @@ func loadConfiguration() (*ConfigInfo, error) {
 	if config.StaticDir, err = getConfigString(httpSection, "static_dir", "static-files"); err != nil {
 		return nil, err
 	}
+	trustRaw, err := getConfigString(httpSection, "trust_role_header", "false")
+	if err != nil {
+		return nil, err
+	}
+	config.TrustRoleHeader = strings.Compare(strings.ToLower(trustRaw), "true") == 0
 
 	adminSection, adminErr := cfg.GetSection("admin")
 	if adminErr != nil {
@@ -0,0 +1,214 @@
+package document
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+//batch data formats
+const (
+	BatchJSONArray = "json"   //JSON array of objects
+	BatchNDJSON    = "ndjson" //newline delimited JSON, one object per line
+	BatchXML       = "xml"    //XML envelope, every child element of root is a document
+)
+
+//batchDocumentLimit maximum size (in bytes) of a single document in batch
+const batchDocumentLimit = 16 << 20
+
+//BatchResult validation result of a single document in batch
+type BatchResult struct {
+	Index   int         `json:"index"` //zero based position in batch input
+	IsValid bool        `json:"isValid"`
+	Errors  []Violation `json:"errors"`
+	Error   string      `json:"error,omitempty"` //set if document can't be validated due to server error
+}
+
+//BatchValidator validate a single document of batch, data is JSON or XML based on batch format
+type BatchValidator func(data string) ([]Violation, error)
+
+type batchJob struct {
+	index int
+	data  string
+}
+
+//ValidateBatch validate every document read from reader with a pool of workers, one worker per validator
+//	validator is only used by its own worker, so it needs not be safe for concurrent use
+//	output is invoked once per document (from calling goroutine) in order of completion, not input order
+//	reading stop at first malformed document, which is reported as format violation
+//	first validator error is returned after whole batch is processed
+func ValidateBatch(reader io.Reader, format string, validators []BatchValidator,
+	output func(BatchResult)) error {
+	var produce func(emit func(string)) error
+	switch format {
+	case BatchJSONArray:
+		produce = func(emit func(string)) error { return readJSONArrayBatch(reader, emit) }
+	case BatchNDJSON:
+		produce = func(emit func(string)) error { return readNDJSONBatch(reader, emit) }
+	case BatchXML:
+		produce = func(emit func(string)) error { return readXMLBatch(reader, emit) }
+	default:
+		return fmt.Errorf("unsupported batch format '%s'", format)
+	}
+
+	if len(validators) == 0 {
+		return fmt.Errorf("batch validation requires at least one validator")
+	}
+
+	jobs := make(chan batchJob, len(validators))
+	results := make(chan BatchResult, len(validators))
+
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	for _, validator := range validators {
+		wg.Add(1)
+		go func(validate BatchValidator) {
+			defer wg.Done()
+			for job := range jobs {
+				violations, err := validate(job.data)
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					results <- BatchResult{Index: job.index, Errors: []Violation{},
+						Error: "document can't be validated due to server error"}
+					continue
+				}
+
+				results <- BatchResult{Index: job.index, IsValid: len(violations) == 0, Errors: violations}
+			}
+		}(validator)
+	}
+
+	go func() {
+		count := 0
+		readErr := produce(func(data string) {
+			jobs <- batchJob{index: count, data: data}
+			count++
+		})
+		if readErr != nil {
+			results <- BatchResult{Index: count, Errors: []Violation{{Rule: RuleFormat, Message: readErr.Error()}}}
+		}
+
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		output(result)
+	}
+
+	return firstErr
+}
+
+func readJSONArrayBatch(reader io.Reader, emit func(string)) error {
+	decoder := json.NewDecoder(reader)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %s", err.Error())
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("JSON batch must be an array")
+	}
+
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("invalid JSON: %s", err.Error())
+		}
+
+		emit(string(raw))
+	}
+
+	return nil
+}
+
+func readNDJSONBatch(reader io.Reader, emit func(string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), batchDocumentLimit) //allow large document per line
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		emit(line)
+	}
+
+	return scanner.Err()
+}
+
+//xmlBatchRecorder keep bytes read by XML decoder which are not yet emitted as document
+type xmlBatchRecorder struct {
+	reader io.Reader
+	data   []byte
+	base   int64 //input offset of data[0]
+}
+
+func (recorder *xmlBatchRecorder) Read(p []byte) (int, error) {
+	if len(recorder.data) > batchDocumentLimit {
+		return 0, fmt.Errorf("document is larger than %d bytes", batchDocumentLimit)
+	}
+
+	n, err := recorder.reader.Read(p)
+	recorder.data = append(recorder.data, p[:n]...)
+
+	return n, err
+}
+
+//take get input between offsets and forget input before end offset
+func (recorder *xmlBatchRecorder) take(start int64, end int64) string {
+	result := string(recorder.data[start-recorder.base : end-recorder.base])
+	recorder.discard(end)
+
+	return result
+}
+
+func (recorder *xmlBatchRecorder) discard(end int64) {
+	recorder.data = append([]byte{}, recorder.data[end-recorder.base:]...)
+	recorder.base = end
+}
+
+func readXMLBatch(reader io.Reader, emit func(string)) error {
+	//stream envelope; only document being decoded is kept in memory
+	recorder := &xmlBatchRecorder{reader: reader}
+	decoder := xml.NewDecoder(recorder)
+	depth := 0
+	for {
+		offset := decoder.InputOffset()
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				depth++ //envelope element
+				recorder.discard(decoder.InputOffset())
+				continue
+			}
+
+			//child of envelope is a complete document
+			if err := decoder.Skip(); err != nil {
+				return fmt.Errorf("invalid XML: %s", err.Error())
+			}
+			emit(recorder.take(offset, decoder.InputOffset()))
+		case xml.EndElement:
+			depth--
+		default:
+			recorder.discard(decoder.InputOffset())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package document
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func collectBatchResults(t *testing.T, input string, format string) []BatchResult {
+	schema := getValidationTestSchema()
+	validators := []BatchValidator{}
+	for i := 0; i < 3; i++ {
+		validators = append(validators, func(data string) ([]Violation, error) {
+			if format == BatchXML {
+				return ValidateDataFromXML(data, schema), nil
+			}
+
+			return ValidateDataFromJSON(data, schema), nil
+		})
+	}
+
+	results := []BatchResult{}
+	err := ValidateBatch(strings.NewReader(input), format, validators,
+		func(result BatchResult) {
+			results = append(results, result)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	return results
+}
+
+func TestValidateBatchJSON(t *testing.T) {
+	arrayInput := `[
+		{"code": "A", "isPaid": true},
+		{"code": "A", "isPaid": 1, "items": []},
+		{"isPaid": true, "items": []}
+	]`
+	ndjsonInput := `{"code": "A", "isPaid": true}
+{"code": "A", "isPaid": 1, "items": []}
+
+{"isPaid": true, "items": []}
+`
+
+	for format, input := range map[string]string{BatchJSONArray: arrayInput, BatchNDJSON: ndjsonInput} {
+		results := collectBatchResults(t, input, format)
+		if len(results) != 3 {
+			t.Fatalf("[%s] expect 3 results but get %d", format, len(results))
+		}
+
+		expected := []string{"/items", "/isPaid", "/code"}
+		for index, path := range expected {
+			if results[index].IsValid || len(results[index].Errors) != 1 ||
+				strings.Compare(results[index].Errors[0].Path, path) != 0 {
+				t.Errorf("[%s] expect record %d violate %s but get %v", format, index, path, results[index].Errors)
+			}
+		}
+	}
+}
+
+func TestValidateBatchMalformed(t *testing.T) {
+	results := collectBatchResults(t, "{\"code\": \"A\", \"isPaid\": 1, \"items\": []}\n{\"code\":", BatchNDJSON)
+	if len(results) != 2 {
+		t.Fatalf("expect 2 results but get %d", len(results))
+	}
+	if results[1].Errors[0].Rule != RuleFormat {
+		t.Errorf("expect malformed record is reported as format violation but get %v", results[1].Errors)
+	}
+
+	results = collectBatchResults(t, `{"code": "A"}`, BatchJSONArray)
+	if len(results) != 1 || results[0].Errors[0].Rule != RuleFormat {
+		t.Errorf("expect non array input is rejected but get %v", results)
+	}
+}
+
+func TestValidateBatchXML(t *testing.T) {
+	results := collectBatchResults(t, `<batch>
+		<invoice><code>A</code><isPaid>no way</isPaid></invoice>
+		<invoice><code>ABCDEFG</code><isPaid>true</isPaid></invoice>
+	</batch>`, BatchXML)
+	if len(results) != 2 {
+		t.Fatalf("expect 2 results but get %d", len(results))
+	}
+
+	if results[0].IsValid || results[0].Errors[0].Path != "/isPaid" {
+		t.Errorf("expect first document violate /isPaid but get %v", results[0].Errors)
+	}
+	if results[1].IsValid || results[1].Errors[0].Rule != RuleLenLimit {
+		t.Errorf("expect second document violate lenLimit but get %v", results[1].Errors)
+	}
+}
+
+func TestValidateBatchValidatorError(t *testing.T) {
+	failing := func(data string) ([]Violation, error) {
+		if strings.Contains(data, "B") {
+			return nil, fmt.Errorf("runner failed")
+		}
+
+		return []Violation{}, nil
+	}
+
+	results := []BatchResult{}
+	err := ValidateBatch(strings.NewReader(`[{"code": "A"}, {"code": "B"}]`), BatchJSONArray,
+		[]BatchValidator{failing}, func(result BatchResult) {
+			results = append(results, result)
+		})
+	if err == nil {
+		t.Errorf("expect validator error is returned")
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	if len(results) != 2 || !results[0].IsValid || results[1].IsValid || results[1].Error == "" {
+		t.Errorf("expect second document is reported as not validated but get %v", results)
+	}
+}
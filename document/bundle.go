@@ -0,0 +1,639 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+)
+
+//BundleVersion format version of schema bundle written by ExportSchemaBundle
+const BundleVersion = 1
+
+//BundleManifestFile name of manifest within schema bundle archive
+const BundleManifestFile = "manifest.json"
+
+//bundleFileLimit maximum uncompressed size (in bytes) of a file within schema bundle archive
+const bundleFileLimit = 16 << 20
+
+//bundleTotalLimit maximum uncompressed size (in bytes) of all files read from schema bundle archive
+const bundleTotalLimit = 64 << 20
+
+//conflict policy of ImportSchemaBundle, applied when schema of bundle already exists in database
+const (
+	BundleConflictFail  = "fail"  //abort whole import
+	BundleConflictSkip  = "skip"  //keep existing schema untouched
+	BundleConflictMerge = "merge" //append revisions missing from database and replace draft; shared revisions must be identical
+)
+
+//status of schema after import
+const (
+	BundleImportCreated = "created"
+	BundleImportMerged  = "merged"
+	BundleImportSkipped = "skipped"
+)
+
+//BundleManifest content of manifest.json within schema bundle archive
+type BundleManifest struct {
+	Version int            `json:"version"`
+	Schemas []BundleSchema `json:"schemas"`
+}
+
+//BundleSchema schema info with all its revisions and draft
+type BundleSchema struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	IsActive    bool             `json:"isActive"`
+	Revisions   []BundleRevision `json:"revisions"` //released revisions in ascending order, then draft
+}
+
+//BundleRevision schema revision (-1 for draft) with everything released together with it
+//	XML definition is stored as separate file of archive, exactly as stored in database so hash chain stays intact
+type BundleRevision struct {
+	Revision    int             `json:"revision"`
+	File        string          `json:"file"` //path of XML definition within archive
+	Remark      string          `json:"remark"`
+	ContentHash string          `json:"contentHash,omitempty"`
+	PrevHash    string          `json:"prevHash,omitempty"`
+	References  []Reference     `json:"references,omitempty"`
+	Includes    []Include       `json:"includes,omitempty"`
+	RuleScript  string          `json:"ruleScript,omitempty"`
+	Computed    []ComputedField `json:"computed,omitempty"`
+	Locales     []SchemaLocale  `json:"locales,omitempty"`
+
+	xmlDef string
+}
+
+//BundleImportResult outcome of importing one schema of bundle
+type BundleImportResult struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Revisions []int  `json:"revisions"` //revisions written into database, -1 means draft
+}
+
+//ExportSchemaBundle write schema infos with all revisions and drafts into ZIP archive:
+//	manifest.json describe every schema and revision, XML definitions are stored as
+//	schemas/{name}/{revision}.xml and schemas/{name}/draft.xml
+//	names is list of schema to export, every schema is exported if empty
+//NOTE: ErrSchemaInfoNotFound error will return if any named schema not register in doc_schema datatable
+func ExportSchemaBundle(db rdbmstool.DbHandlerProxy, names []string, writer io.Writer) error {
+	infos := []SchemaInfo{}
+	if len(names) == 0 {
+		allInfos, infoErr := GetAllSchemaInfo(db)
+		if infoErr != nil {
+			return infoErr
+		}
+		infos = allInfos
+	}
+	for _, name := range names {
+		info, infoErr := GetSchemaInfo(db, name)
+		if infoErr != nil {
+			return infoErr
+		}
+		if info == nil {
+			return ErrSchemaInfoNotFound{msg: name + " not found in database"}
+		}
+		infos = append(infos, *info)
+	}
+
+	manifest := BundleManifest{Version: BundleVersion, Schemas: []BundleSchema{}}
+	for _, info := range infos {
+		schema, schemaErr := getBundleSchema(db, info)
+		if schemaErr != nil {
+			return schemaErr
+		}
+		manifest.Schemas = append(manifest.Schemas, *schema)
+	}
+
+	archive := zip.NewWriter(writer)
+	for _, schema := range manifest.Schemas {
+		for _, revision := range schema.Revisions {
+			file, fileErr := archive.Create(revision.File)
+			if fileErr != nil {
+				return fmt.Errorf("failed to write %s into bundle: %s", revision.File, fileErr.Error())
+			}
+			if _, err := io.WriteString(file, revision.xmlDef); err != nil {
+				return fmt.Errorf("failed to write %s into bundle: %s", revision.File, err.Error())
+			}
+		}
+	}
+
+	manifestRaw, jsonErr := json.MarshalIndent(manifest, "", "  ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+	file, fileErr := archive.Create(BundleManifestFile)
+	if fileErr != nil {
+		return fmt.Errorf("failed to write manifest into bundle: %s", fileErr.Error())
+	}
+	if _, err := file.Write(manifestRaw); err != nil {
+		return fmt.Errorf("failed to write manifest into bundle: %s", err.Error())
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %s", err.Error())
+	}
+
+	return nil
+}
+
+//getBundleSchema read schema info with all revisions and draft from database
+func getBundleSchema(db rdbmstool.DbHandlerProxy, info SchemaInfo) (*BundleSchema, error) {
+	rows, rowsErr := db.Query(`SELECT revision, xml_definition, remark, content_hash, prev_hash
+	FROM doc_schema_revision WHERE schema_id = ? ORDER BY revision`, info.ID)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+
+	revisions := []BundleRevision{}
+	var draft *BundleRevision
+	for rows.Next() {
+		revision := BundleRevision{}
+		if err := rows.Scan(&revision.Revision, &revision.xmlDef, &revision.Remark,
+			&revision.ContentHash, &revision.PrevHash); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		if revision.Revision == -1 {
+			revision.File = "schemas/" + info.Name + "/draft.xml"
+			draft = &revision
+		} else {
+			revision.File = "schemas/" + info.Name + "/" + strconv.Itoa(revision.Revision) + ".xml"
+			revisions = append(revisions, revision)
+		}
+	}
+	rows.Close()
+	if draft != nil {
+		revisions = append(revisions, *draft)
+	}
+
+	for index := range revisions {
+		if err := getBundleRevisionData(db, info.Name, &revisions[index]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &BundleSchema{
+		ID:          info.ID,
+		Name:        info.Name,
+		Description: info.Description,
+		IsActive:    info.IsActive,
+		Revisions:   revisions,
+	}, nil
+}
+
+//getBundleRevisionData read references, includes, rule script, computed fields and locales of revision
+func getBundleRevisionData(db rdbmstool.DbHandlerProxy, name string, revision *BundleRevision) error {
+	var err error
+	if revision.References, err = getDeclaredReferences(db, name, revision.Revision); err != nil {
+		return err
+	}
+	if revision.Includes, err = GetIncludes(db, name, revision.Revision); err != nil {
+		return err
+	}
+	if revision.Computed, err = GetComputedFields(db, name, revision.Revision); err != nil {
+		return err
+	}
+	if revision.Locales, err = GetSchemaLocales(db, name, revision.Revision); err != nil {
+		return err
+	}
+
+	rule, ruleErr := GetRuleScript(db, name, revision.Revision)
+	if ruleErr != nil {
+		return ruleErr
+	}
+	revision.RuleScript = rule.Script
+
+	return nil
+}
+
+//ReadSchemaBundle read ZIP archive written by ExportSchemaBundle and check it is intact:
+//	revisions are numbered 1, 2, 3... with at most one draft, every XML definition is well formed,
+//	hash chain of released revisions is not broken and files are within uncompressed size limit
+//NOTE: ErrInvalidBundle error will return if archive is malformed
+func ReadSchemaBundle(data []byte) (*BundleManifest, error) {
+	archive, zipErr := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if zipErr != nil {
+		return nil, ErrInvalidBundle{msg: "bundle is not a ZIP archive: " + zipErr.Error()}
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	remaining := int64(bundleTotalLimit)
+	manifestRaw, manifestErr := readBundleFile(files, BundleManifestFile, &remaining)
+	if manifestErr != nil {
+		return nil, manifestErr
+	}
+	manifest := BundleManifest{}
+	if err := json.Unmarshal(manifestRaw, &manifest); err != nil {
+		return nil, ErrInvalidBundle{msg: "invalid manifest: " + err.Error()}
+	}
+	if manifest.Version != BundleVersion {
+		return nil, ErrInvalidBundle{msg: fmt.Sprintf("unsupported bundle version %d", manifest.Version)}
+	}
+
+	names := map[string]bool{}
+	ids := map[string]bool{}
+	for index := range manifest.Schemas {
+		schema := &manifest.Schemas[index]
+		if schema.Name == "" || schema.ID == "" {
+			return nil, ErrInvalidBundle{msg: fmt.Sprintf("schema #%d has no name or ID", index+1)}
+		}
+		if names[schema.Name] || ids[schema.ID] {
+			return nil, ErrInvalidBundle{msg: fmt.Sprintf("schema %s is declared more than once", schema.Name)}
+		}
+		names[schema.Name] = true
+		ids[schema.ID] = true
+
+		for revIndex := range schema.Revisions {
+			revision := &schema.Revisions[revIndex]
+			xmlRaw, xmlErr := readBundleFile(files, revision.File, &remaining)
+			if xmlErr != nil {
+				return nil, xmlErr
+			}
+			revision.xmlDef = string(xmlRaw)
+
+			if _, err := gxschema.ParseSchemaFromXML(revision.xmlDef); err != nil {
+				return nil, ErrInvalidBundle{msg: fmt.Sprintf("invalid XML definition %s: %s", revision.File, err.Error())}
+			}
+		}
+
+		if err := checkBundleChain(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return &manifest, nil
+}
+
+//readBundleFile read content of file within bundle archive
+//	remaining is uncompressed size (in bytes) left for the rest of bundle, it is reduced by size of file read
+func readBundleFile(files map[string]*zip.File, name string, remaining *int64) ([]byte, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, ErrInvalidBundle{msg: name + " not found in bundle"}
+	}
+
+	limit := int64(bundleFileLimit)
+	if *remaining < limit {
+		limit = *remaining
+	}
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, ErrInvalidBundle{msg: fmt.Sprintf("%s exceeds uncompressed size limit of bundle", name)}
+	}
+
+	reader, openErr := file.Open()
+	if openErr != nil {
+		return nil, ErrInvalidBundle{msg: fmt.Sprintf("failed to read %s: %s", name, openErr.Error())}
+	}
+	defer reader.Close()
+
+	//declared size may lie, so never read beyond limit
+	content, readErr := ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if readErr != nil {
+		return nil, ErrInvalidBundle{msg: fmt.Sprintf("failed to read %s: %s", name, readErr.Error())}
+	}
+	if int64(len(content)) > limit {
+		return nil, ErrInvalidBundle{msg: fmt.Sprintf("%s exceeds uncompressed size limit of bundle", name)}
+	}
+
+	*remaining -= int64(len(content))
+	return content, nil
+}
+
+//checkBundleChain check revision numbering and hash chain of schema, same as VerifySchemaChain
+func checkBundleChain(schema *BundleSchema) error {
+	expectedPrevHash := ""
+	for index, revision := range schema.Revisions {
+		if revision.Revision == -1 && index == len(schema.Revisions)-1 {
+			break //draft is excluded from hash chain
+		}
+		if revision.Revision != index+1 {
+			return ErrInvalidBundle{msg: fmt.Sprintf("schema %s revision %d is missing or out of order",
+				schema.Name, index+1)}
+		}
+		contentHash, hashErr := computeRevisionHash(revision.PrevHash, schema.ID, &schema.Revisions[index])
+		if hashErr != nil || revision.PrevHash != expectedPrevHash || contentHash != revision.ContentHash {
+			return ErrInvalidBundle{msg: fmt.Sprintf("schema %s revision %d does not match its hash",
+				schema.Name, revision.Revision)}
+		}
+
+		expectedPrevHash = revision.ContentHash
+	}
+
+	return nil
+}
+
+//ImportSchemaBundle recreate schemas of bundle in database, preserving IDs, revision numbers, remarks and hashes;
+//everything is written as is, so schema is not linted
+//	conflict is policy applied to schema which name or ID already exists in database,
+//	see BundleConflictFail, BundleConflictSkip and BundleConflictMerge
+//NOTE: ErrBundleConflict error will return if existing schema can't be imported under conflict policy
+//NOTE: ErrInvalidBundle error will return if referenced or included schema revision is found neither in
+//database nor in imported revisions of bundle
+func ImportSchemaBundle(db rdbmstool.DbHandlerProxy, manifest *BundleManifest,
+	conflict string) ([]BundleImportResult, error) {
+	if conflict != BundleConflictFail && conflict != BundleConflictSkip && conflict != BundleConflictMerge {
+		return nil, fmt.Errorf("unknown conflict policy %s", conflict)
+	}
+
+	//every conflict and target is checked before anything is written
+	plans := []bundleImportPlan{}
+	for index := range manifest.Schemas {
+		plan, planErr := planBundleSchema(db, &manifest.Schemas[index], conflict)
+		if planErr != nil {
+			return nil, planErr
+		}
+
+		plans = append(plans, *plan)
+	}
+
+	if err := checkBundleTargets(db, plans); err != nil {
+		return nil, err
+	}
+
+	results := []BundleImportResult{}
+	for _, plan := range plans {
+		if err := writeBundleSchema(db, &plan); err != nil {
+			return nil, err
+		}
+
+		results = append(results, plan.result)
+	}
+
+	return results, nil
+}
+
+//bundleImportPlan schema of bundle with revisions to be written into database
+type bundleImportPlan struct {
+	schema    *BundleSchema
+	result    BundleImportResult
+	revisions []BundleRevision //revisions to be written, -1 means draft
+}
+
+//planBundleSchema decide what to write for schema of bundle under conflict policy, without writing anything
+func planBundleSchema(db rdbmstool.DbHandlerProxy, schema *BundleSchema, conflict string) (*bundleImportPlan, error) {
+	plan := bundleImportPlan{
+		schema:    schema,
+		result:    BundleImportResult{Name: schema.Name, Revisions: []int{}},
+		revisions: []BundleRevision{},
+	}
+
+	byName, nameErr := GetSchemaInfo(db, schema.Name)
+	if nameErr != nil {
+		return nil, nameErr
+	}
+	var idCount int
+	if err := db.QueryRow(`SELECT COUNT(id) FROM doc_schema WHERE id = ?`, schema.ID).Scan(&idCount); err != nil {
+		return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+	}
+
+	if byName == nil && idCount == 0 {
+		plan.result.Status = BundleImportCreated
+		plan.revisions = append(plan.revisions, schema.Revisions...)
+		return &plan, nil
+	}
+
+	switch {
+	case conflict == BundleConflictSkip:
+		plan.result.Status = BundleImportSkipped
+		return &plan, nil
+	case conflict == BundleConflictFail:
+		return nil, ErrBundleConflict{msg: fmt.Sprintf("schema %s already exists", schema.Name)}
+	case byName == nil || byName.ID != schema.ID:
+		return nil, ErrBundleConflict{msg: fmt.Sprintf("schema %s exists with different ID or name", schema.Name)}
+	}
+
+	//merge: shared revisions must be identical, so appended revisions continue same hash chain
+	plan.result.Status = BundleImportMerged
+	for _, revision := range schema.Revisions {
+		if revision.Revision == -1 || revision.Revision > byName.LatestRevision {
+			plan.revisions = append(plan.revisions, revision)
+			continue
+		}
+
+		hash, hashErr := getRevisionHash(db, schema.ID, revision.Revision)
+		if hashErr != nil {
+			return nil, hashErr
+		}
+		existing := BundleRevision{Revision: revision.Revision}
+		if err := getBundleRevisionData(db, schema.Name, &existing); err != nil {
+			return nil, err
+		}
+		if hash != revision.ContentHash || !isSameBundleRevisionData(&existing, &revision) {
+			return nil, ErrBundleConflict{msg: fmt.Sprintf("schema %s revision %d differs from database",
+				schema.Name, revision.Revision)}
+		}
+	}
+
+	return &plan, nil
+}
+
+//isSameBundleRevisionData check references, includes, rule script, computed fields and locales of
+//two revisions are identical
+func isSameBundleRevisionData(a *BundleRevision, b *BundleRevision) bool {
+	getData := func(revision *BundleRevision) string {
+		raw, _ := json.Marshal(BundleRevision{
+			References: revision.References,
+			Includes:   revision.Includes,
+			RuleScript: revision.RuleScript,
+			Computed:   revision.Computed,
+			Locales:    revision.Locales,
+		})
+
+		return string(raw)
+	}
+
+	return getData(a) == getData(b)
+}
+
+//checkBundleTargets check every schema revision referenced or included by revisions to be written
+//is found either in database or in revisions to be written
+func checkBundleTargets(db rdbmstool.DbHandlerProxy, plans []bundleImportPlan) error {
+	//latest released revision of each schema written by bundle
+	imported := map[string]int{}
+	for _, plan := range plans {
+		if plan.result.Status == BundleImportSkipped {
+			continue
+		}
+
+		latest := imported[plan.schema.Name]
+		for _, revision := range plan.revisions {
+			if revision.Revision > latest {
+				latest = revision.Revision
+			}
+		}
+		imported[plan.schema.Name] = latest
+	}
+
+	getLatestRevision := func(name string) (int, bool, error) {
+		latest, found := imported[name]
+
+		info, infoErr := GetSchemaInfo(db, name)
+		if infoErr != nil {
+			return 0, false, infoErr
+		}
+		if info != nil {
+			found = true
+			if info.LatestRevision > latest {
+				latest = info.LatestRevision
+			}
+		}
+
+		return latest, found, nil
+	}
+
+	for _, plan := range plans {
+		for _, revision := range plan.revisions {
+			for _, ref := range revision.References {
+				latest, found, err := getLatestRevision(ref.TargetSchema)
+				if err != nil {
+					return err
+				}
+				if !found || ref.TargetRevision > latest {
+					return ErrInvalidBundle{msg: fmt.Sprintf("schema %s revision %d references %s revision %d "+
+						"which is found neither in database nor in bundle",
+						plan.schema.Name, revision.Revision, ref.TargetSchema, ref.TargetRevision)}
+				}
+			}
+
+			for _, include := range revision.Includes {
+				latest, found, err := getLatestRevision(include.TargetSchema)
+				if err != nil {
+					return err
+				}
+				if !found || include.TargetRevision < 1 || include.TargetRevision > latest {
+					return ErrInvalidBundle{msg: fmt.Sprintf("schema %s revision %d includes %s revision %d "+
+						"which is found neither in database nor in bundle",
+						plan.schema.Name, revision.Revision, include.TargetSchema, include.TargetRevision)}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+//writeBundleSchema write schema info and planned revisions into database
+func writeBundleSchema(db rdbmstool.DbHandlerProxy, plan *bundleImportPlan) error {
+	schema := plan.schema
+
+	switch plan.result.Status {
+	case BundleImportSkipped:
+		return nil
+	case BundleImportCreated:
+		_, dbErr := db.Exec(`INSERT INTO doc_schema (id, name, description, is_active) VALUES (?,?,?,?)`,
+			schema.ID, schema.Name, schema.Description, schema.IsActive)
+		if dbErr != nil {
+			return fmt.Errorf("failed to create %s schemaInfo into database: %s", schema.Name, dbErr.Error())
+		}
+	default:
+		if _, err := db.Exec(`UPDATE doc_schema SET description = ?, is_active = ? WHERE id = ?`,
+			schema.Description, schema.IsActive, schema.ID); err != nil {
+			return fmt.Errorf("failed to update %s schemaInfo: %s", schema.Name, err.Error())
+		}
+	}
+
+	for index := range plan.revisions {
+		revision := &plan.revisions[index]
+		if revision.Revision == -1 && plan.result.Status == BundleImportMerged {
+			if err := deleteBundleDraft(db, schema.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := insertBundleRevision(db, schema, revision); err != nil {
+			return err
+		}
+		plan.result.Revisions = append(plan.result.Revisions, revision.Revision)
+	}
+
+	return nil
+}
+
+//deleteBundleDraft remove draft and everything saved with it, to be replaced by draft of bundle
+func deleteBundleDraft(db rdbmstool.DbHandlerProxy, schemaID string) error {
+	tables := []string{"doc_schema_revision", "doc_schema_reference", "doc_schema_include", "doc_schema_rule",
+		"doc_schema_computed", "doc_schema_locale"}
+	for _, table := range tables {
+		if _, err := db.Exec(`DELETE FROM `+table+` WHERE schema_id = ? AND revision = -1`, schemaID); err != nil {
+			return fmt.Errorf("failed to clear draft from %s: %s", table, err.Error())
+		}
+	}
+
+	return nil
+}
+
+//insertBundleRevision write revision and everything released together with it into database
+func insertBundleRevision(db rdbmstool.DbHandlerProxy, schema *BundleSchema, revision *BundleRevision) error {
+	_, revErr := db.Exec(`INSERT INTO doc_schema_revision
+	(schema_id, revision, xml_definition, remark, content_hash, prev_hash) VALUES (?,?,?,?,?,?)`,
+		schema.ID, revision.Revision, revision.xmlDef, revision.Remark, revision.ContentHash, revision.PrevHash)
+	if revErr != nil {
+		return fmt.Errorf("failed to import %s revision %d into database: %s",
+			schema.Name, revision.Revision, revErr.Error())
+	}
+
+	for _, ref := range revision.References {
+		if _, err := db.Exec(`INSERT INTO doc_schema_reference
+		(schema_id, revision, item_path, target_schema, target_revision, is_optional, is_array)
+		VALUES (?,?,?,?,?,?,?)`,
+			schema.ID, revision.Revision, ref.ItemPath, ref.TargetSchema, ref.TargetRevision,
+			ref.IsOptional, ref.IsArray); err != nil {
+			return fmt.Errorf("failed to import %s reference %s into database: %s",
+				schema.Name, ref.ItemPath, err.Error())
+		}
+	}
+
+	for seq, include := range revision.Includes {
+		if _, err := db.Exec(`INSERT INTO doc_schema_include
+		(schema_id, revision, seq, item_path, position, target_schema, target_revision, section)
+		VALUES (?,?,?,?,?,?,?,?)`,
+			schema.ID, revision.Revision, seq, include.ItemPath, include.Position,
+			include.TargetSchema, include.TargetRevision, include.Section); err != nil {
+			return fmt.Errorf("failed to import %s include of %s into database: %s",
+				schema.Name, include.TargetSchema, err.Error())
+		}
+	}
+
+	if revision.RuleScript != "" {
+		if _, err := db.Exec(`INSERT INTO doc_schema_rule (schema_id, revision, script) VALUES (?,?,?)`,
+			schema.ID, revision.Revision, revision.RuleScript); err != nil {
+			return fmt.Errorf("failed to import rule script of %s into database: %s", schema.Name, err.Error())
+		}
+	}
+
+	for index, field := range revision.Computed {
+		if _, err := db.Exec(`INSERT INTO doc_schema_computed (schema_id, revision, item_path, seq, expression)
+		VALUES (?,?,?,?,?)`, schema.ID, revision.Revision, field.Path, index+1, field.Expression); err != nil {
+			return fmt.Errorf("failed to import %s computed field %s into database: %s",
+				schema.Name, field.Path, err.Error())
+		}
+	}
+
+	for _, locale := range revision.Locales {
+		metadata, jsonErr := json.Marshal(locale.Items)
+		if jsonErr != nil {
+			return jsonErr
+		}
+
+		if _, err := db.Exec(`INSERT INTO doc_schema_locale (schema_id, revision, locale, metadata)
+		VALUES (?,?,?,?)`, schema.ID, revision.Revision, locale.Locale, string(metadata)); err != nil {
+			return fmt.Errorf("failed to import %s locale %s into database: %s",
+				schema.Name, locale.Locale, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+const bundleTestID = "733bee1b-f79a-4cb7-b675-842317b994b5"
+
+//getBundleTestManifest manifest of invoice schema with 2 released revisions and a draft
+func getBundleTestManifest() (*BundleManifest, map[string]string) {
+	return getBundleTestManifestOf(bundleTestID, "invoice")
+}
+
+//getBundleTestManifestOf manifest of schema with 2 released revisions and a draft
+func getBundleTestManifestOf(id string, name string) (*BundleManifest, map[string]string) {
+	dir := "schemas/" + name + "/"
+	files := map[string]string{
+		dir + "1.xml":     `<dxdoc name="` + name + `" revision="1"><dxstr name="invNo"></dxstr></dxdoc>`,
+		dir + "2.xml":     `<dxdoc name="` + name + `" revision="2"><dxstr name="invNo"></dxstr><dxint name="qty"></dxint></dxdoc>`,
+		dir + "draft.xml": `<dxdoc name="` + name + `" revision="-1"><dxstr name="invNo"></dxstr></dxdoc>`,
+	}
+
+	revisions := []BundleRevision{
+		{Revision: 1, File: dir + "1.xml", Remark: "first", xmlDef: files[dir+"1.xml"]},
+		{Revision: 2, File: dir + "2.xml", xmlDef: files[dir+"2.xml"],
+			Computed: []ComputedField{{Path: "qty", Expression: "1"}}},
+		{Revision: -1, File: dir + "draft.xml"},
+	}
+	revisions[0].ContentHash, _ = computeRevisionHash("", id, &revisions[0])
+	revisions[1].PrevHash = revisions[0].ContentHash
+	revisions[1].ContentHash, _ = computeRevisionHash(revisions[1].PrevHash, id, &revisions[1])
+
+	return &BundleManifest{Version: BundleVersion, Schemas: []BundleSchema{{
+		ID: id, Name: name, Description: name, IsActive: true, Revisions: revisions,
+	}}}, files
+}
+
+func writeBundleTestArchive(t *testing.T, manifest *BundleManifest, files map[string]string) []byte {
+	buffer := bytes.Buffer{}
+	archive := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, _ := archive.Create(name)
+		file.Write([]byte(content))
+	}
+
+	manifestRaw, jsonErr := json.Marshal(manifest)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	file, _ := archive.Create(BundleManifestFile)
+	file.Write(manifestRaw)
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestReadSchemaBundle(t *testing.T) {
+	manifest, files := getBundleTestManifest()
+
+	result, err := ReadSchemaBundle(writeBundleTestArchive(t, manifest, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Schemas) != 1 || len(result.Schemas[0].Revisions) != 3 {
+		t.Fatalf("unexpected manifest: %v", result)
+	}
+
+	revision := result.Schemas[0].Revisions[1]
+	if revision.xmlDef != files["schemas/invoice/2.xml"] {
+		t.Errorf("expect XML definition of revision 2 loaded but get %s", revision.xmlDef)
+	}
+	if len(revision.Computed) != 1 || revision.Computed[0].Path != "qty" {
+		t.Errorf("expect computed field of revision 2 but get %v", revision.Computed)
+	}
+}
+
+func TestReadSchemaBundleInvalid(t *testing.T) {
+	cases := map[string]func(manifest *BundleManifest, files map[string]string){
+		"unsupported version": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Version = BundleVersion + 1
+		},
+		"missing file": func(manifest *BundleManifest, files map[string]string) {
+			delete(files, "schemas/invoice/2.xml")
+		},
+		"tampered XML": func(manifest *BundleManifest, files map[string]string) {
+			files["schemas/invoice/1.xml"] = `<dxdoc name="invoice" revision="1"></dxdoc>`
+		},
+		"tampered computed fields": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas[0].Revisions[1].Computed[0].Expression = "2"
+		},
+		"tampered rule script": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas[0].Revisions[0].RuleScript = "addError('/invNo', 'rejected')"
+		},
+		"tampered references": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas[0].Revisions[0].References = []Reference{
+				{ItemPath: "invNo", TargetSchema: "pr", TargetRevision: 1}}
+		},
+		"broken chain": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas[0].Revisions[1].PrevHash = ""
+		},
+		"missing revision": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas[0].Revisions = manifest.Schemas[0].Revisions[1:]
+		},
+		"duplicate schema": func(manifest *BundleManifest, files map[string]string) {
+			manifest.Schemas = append(manifest.Schemas, manifest.Schemas[0])
+		},
+		"oversized file": func(manifest *BundleManifest, files map[string]string) {
+			files["schemas/invoice/draft.xml"] = `<dxdoc name="invoice" revision="-1">` +
+				strings.Repeat(" ", bundleFileLimit) + `</dxdoc>`
+		},
+	}
+
+	for name, modify := range cases {
+		manifest, files := getBundleTestManifest()
+		modify(manifest, files)
+
+		_, err := ReadSchemaBundle(writeBundleTestArchive(t, manifest, files))
+		if _, ok := err.(ErrInvalidBundle); !ok {
+			t.Errorf("%s: expect ErrInvalidBundle but get %v", name, err)
+		}
+	}
+
+	if _, err := ReadSchemaBundle([]byte("not a zip")); err == nil {
+		t.Error("expect error on non ZIP input")
+	}
+}
+
+func TestImportSchemaBundleUnknownConflict(t *testing.T) {
+	manifest, _ := getBundleTestManifest()
+
+	if _, err := ImportSchemaBundle(nil, manifest, "overwrite"); err == nil {
+		t.Error("expect error on unknown conflict policy")
+	}
+}
+
+//readBundleTestManifest write manifest into archive and read it back, so XML definitions are loaded
+func readBundleTestManifest(t *testing.T, manifest *BundleManifest, files map[string]string) *BundleManifest {
+	result, err := ReadSchemaBundle(writeBundleTestArchive(t, manifest, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestImportSchemaBundle(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	//create: schema of bundle is new to database
+	created, createdFiles := getBundleTestManifestOf("0b6f3c7e-1f6c-4b8e-9a55-3d1c1e7a2b01", "bundle-invoice")
+	manifest := readBundleTestManifest(t, created, createdFiles)
+	results, importErr := ImportSchemaBundle(trx, manifest, BundleConflictFail)
+	if importErr != nil {
+		t.Fatal(importErr)
+		return
+	}
+	if len(results) != 1 || results[0].Status != BundleImportCreated || len(results[0].Revisions) != 3 {
+		t.Fatalf("expect bundle-invoice created with 3 revisions but get %+v", results)
+		return
+	}
+
+	info, infoErr := GetSchemaInfo(trx, "bundle-invoice")
+	if infoErr != nil {
+		t.Fatal(infoErr)
+		return
+	}
+	if info == nil || info.LatestRevision != 2 {
+		t.Fatalf("expect bundle-invoice revision 2 in database but get %+v", info)
+		return
+	}
+	if computed, _ := GetComputedFields(trx, "bundle-invoice", 2); len(computed) != 1 {
+		t.Errorf("expect computed field of revision 2 is imported but get %v", computed)
+	}
+
+	//skip and fail: invoice already exists in database
+	existing, existingFiles := getBundleTestManifest()
+	manifest = readBundleTestManifest(t, existing, existingFiles)
+	results, importErr = ImportSchemaBundle(trx, manifest, BundleConflictSkip)
+	if importErr != nil {
+		t.Fatal(importErr)
+		return
+	}
+	if len(results) != 1 || results[0].Status != BundleImportSkipped || len(results[0].Revisions) != 0 {
+		t.Errorf("expect invoice skipped but get %+v", results)
+	}
+
+	if _, err := ImportSchemaBundle(trx, manifest, BundleConflictFail); err == nil {
+		t.Error("expect existing invoice fails import")
+	} else if _, ok := err.(ErrBundleConflict); !ok {
+		t.Errorf("expect ErrBundleConflict but get %v", err)
+	}
+}
+
+func TestImportSchemaBundleMerge(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	id := "0b6f3c7e-1f6c-4b8e-9a55-3d1c1e7a2b02"
+
+	//database holds revision 1 only
+	manifest, files := getBundleTestManifestOf(id, "bundle-invoice")
+	full := readBundleTestManifest(t, manifest, files)
+	manifest.Schemas[0].Revisions = manifest.Schemas[0].Revisions[:1]
+	if _, err := ImportSchemaBundle(trx, readBundleTestManifest(t, manifest, files), BundleConflictFail); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//revision 1 with different rule script is a conflict even though its XML definition is identical
+	changed, changedFiles := getBundleTestManifestOf(id, "bundle-invoice")
+	changed.Schemas[0].Revisions[0].RuleScript = `addError("/invNo", "rejected")`
+	_, mergeErr := ImportSchemaBundle(trx, readBundleTestManifest(t, changed, changedFiles), BundleConflictMerge)
+	if _, ok := mergeErr.(ErrBundleConflict); !ok {
+		t.Errorf("expect ErrBundleConflict on revision 1 with different rule script but get %v", mergeErr)
+	}
+
+	results, importErr := ImportSchemaBundle(trx, full, BundleConflictMerge)
+	if importErr != nil {
+		t.Fatal(importErr)
+		return
+	}
+	if len(results) != 1 || results[0].Status != BundleImportMerged ||
+		len(results[0].Revisions) != 2 || results[0].Revisions[0] != 2 || results[0].Revisions[1] != -1 {
+		t.Fatalf("expect revision 2 and draft merged but get %+v", results)
+		return
+	}
+
+	report, verifyErr := VerifySchemaChain(trx, "bundle-invoice")
+	if verifyErr != nil {
+		t.Fatal(verifyErr)
+		return
+	}
+	if !report.IsValid {
+		t.Errorf("expect merged hash chain intact but get %+v", report)
+	}
+}
+
+func TestImportSchemaBundleTargets(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	//bundle-po revision 2 includes bundle-invoice revision 2 of same bundle, and references pr of database
+	manifest, files := getBundleTestManifestOf("0b6f3c7e-1f6c-4b8e-9a55-3d1c1e7a2b03", "bundle-invoice")
+	po, poFiles := getBundleTestManifestOf("0b6f3c7e-1f6c-4b8e-9a55-3d1c1e7a2b04", "bundle-po")
+	po.Schemas[0].Revisions[1].Includes = []Include{{TargetSchema: "bundle-invoice", TargetRevision: 2}}
+	po.Schemas[0].Revisions[1].References = []Reference{{ItemPath: "invNo", TargetSchema: "pr"}}
+	manifest.Schemas = append(manifest.Schemas, po.Schemas[0])
+	for name, content := range poFiles {
+		files[name] = content
+	}
+
+	missing := readBundleTestManifest(t, manifest, files)
+	missing.Schemas[1].Revisions[1].Includes[0].TargetRevision = 3
+	if _, err := ImportSchemaBundle(trx, missing, BundleConflictFail); err == nil {
+		t.Error("expect include of missing revision is rejected")
+	} else if _, ok := err.(ErrInvalidBundle); !ok {
+		t.Errorf("expect ErrInvalidBundle on include of missing revision but get %v", err)
+	}
+	if info, _ := GetSchemaInfo(trx, "bundle-invoice"); info != nil {
+		t.Error("expect nothing is written when bundle is rejected")
+	}
+
+	unknown := readBundleTestManifest(t, manifest, files)
+	unknown.Schemas[1].Revisions[1].References[0].TargetSchema = "unknown"
+	if _, err := ImportSchemaBundle(trx, unknown, BundleConflictFail); err == nil {
+		t.Error("expect reference to unknown schema is rejected")
+	} else if _, ok := err.(ErrInvalidBundle); !ok {
+		t.Errorf("expect ErrInvalidBundle on reference to unknown schema but get %v", err)
+	}
+
+	if _, err := ImportSchemaBundle(trx, readBundleTestManifest(t, manifest, files), BundleConflictFail); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if includes, _ := GetIncludes(trx, "bundle-po", 2); len(includes) != 1 {
+		t.Errorf("expect include of bundle-po revision 2 is imported but get %v", includes)
+	}
+}
@@ -0,0 +1,397 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+	lua "github.com/yuin/gopher-lua"
+)
+
+//ComputedField schema item which value is computed by server on record submission
+//
+//	Path is item name, nested section item is joined by '/'; e.g. items/amount
+//	Expression is Lua expression, or Lua function body if it not compile as expression;
+//	document data is exposed as global table 'doc' and the object holding the item as 'row'; e.g.
+//
+//	items/amount:  row.qty * row.price
+//	total price:   local sum = 0 for _, item in ipairs(doc.items) do sum = sum + item.amount end return sum
+//
+//	fields are computed in declared order, so a field can use result of fields declared before it;
+//	item within array section is computed once per array element
+type ComputedField struct {
+	Path       string `json:"item"`
+	Expression string `json:"expression"`
+}
+
+//ParseComputedFieldsFromJSON convert JSON array into list of ComputedField and check Lua syntax
+func ParseComputedFieldsFromJSON(jsonStr string) ([]ComputedField, error) {
+	fields := []ComputedField{}
+	if err := json.Unmarshal([]byte(jsonStr), &fields); err != nil {
+		return nil, fmt.Errorf("invalid computed fields JSON: %s", err.Error())
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	paths := map[string]bool{}
+	for _, field := range fields {
+		if paths[field.Path] {
+			return nil, fmt.Errorf("computed field %s is declared more than once", field.Path)
+		}
+		paths[field.Path] = true
+
+		if _, err := field.load(L); err != nil {
+			return nil, fmt.Errorf("invalid expression of computed field %s: %s", field.Path, err.Error())
+		}
+	}
+
+	return fields, nil
+}
+
+//load compile expression into Lua function which return computed value;
+//expression is compiled as 'return <expression>' first, then as function body if that fails
+func (field *ComputedField) load(L *lua.LState) (*lua.LFunction, error) {
+	if fn, err := L.LoadString("return " + field.Expression); err == nil {
+		return fn, nil
+	}
+
+	return L.LoadString(field.Expression)
+}
+
+//ValidateComputedFields check every computed field refer to an int, str, bool or decimal item of schema
+//NOTE: ErrInvalidComputedField error will return if any computed field not match with schema
+func ValidateComputedFields(schema *gxschema.DxDoc, fields []ComputedField) error {
+	for _, field := range fields {
+		info, infoErr := findItemInfo(schema.Items, strings.Split(field.Path, "/"))
+		if infoErr != nil {
+			return ErrInvalidComputedField{msg: infoErr.Error()}
+		}
+		if info == nil {
+			return ErrInvalidComputedField{
+				msg: fmt.Sprintf("computed field %s not found in schema %s", field.Path, schema.Name)}
+		}
+
+		switch info.Kind {
+		case ItemInt, ItemStr, ItemBool, ItemDecimal:
+		default:
+			return ErrInvalidComputedField{
+				msg: fmt.Sprintf("computed field %s must be int, str, bool or decimal item", field.Path)}
+		}
+		if info.IsArray {
+			return ErrInvalidComputedField{msg: fmt.Sprintf("computed field %s must not be an array", field.Path)}
+		}
+	}
+
+	return nil
+}
+
+//findItemInfo find schema item by path segments, return nil if not found
+func findItemInfo(items []gxschema.DxItem, segments []string) (*ItemInfo, error) {
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		if info.Name != segments[0] {
+			continue
+		}
+
+		if len(segments) == 1 {
+			return info, nil
+		}
+		if info.Kind != ItemSection {
+			return nil, nil
+		}
+
+		return findItemInfo(info.Items, segments[1:])
+	}
+
+	return nil, nil
+}
+
+//GetComputedFields get computed fields of schema revision (use -1 for draft)
+func GetComputedFields(db rdbmstool.DbHandlerProxy, schemaName string, revision int) ([]ComputedField, error) {
+	rows, rowsErr := db.Query(`SELECT a.item_path, a.expression FROM doc_schema_computed a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.revision = ?
+	ORDER BY a.seq`, schemaName, revision)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	fields := []ComputedField{}
+	for rows.Next() {
+		field := ComputedField{}
+		if err := rows.Scan(&field.Path, &field.Expression); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+//SaveDraftComputedFields declare or overwrite computed fields of schema draft
+//computed fields are released together with draft, see SaveDraftToNewRevision
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrDraftNotFound error will return if schema has no draft
+//NOTE: ErrInvalidComputedField error will return if any computed field not match with draft schema
+func SaveDraftComputedFields(db rdbmstool.DbHandlerProxy, schemaName string, fields []ComputedField) error {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	draft, draftErr := GetDraftSchema(db, schemaName)
+	if draftErr != nil {
+		return draftErr
+	}
+	if draft == nil {
+		return ErrDraftNotFound{msg: fmt.Sprintf("no draft found for %s", schemaName)}
+	}
+
+	if err := ValidateComputedFields(draft, fields); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM doc_schema_computed WHERE schema_id = ? AND revision = -1`,
+		schemaInfo.ID); err != nil {
+		return fmt.Errorf("failed to clear %s computed fields from database: %s", schemaName, err.Error())
+	}
+
+	for index, field := range fields {
+		_, dbErr := db.Exec(`INSERT INTO doc_schema_computed (schema_id, revision, item_path, seq, expression)
+		VALUES (?,-1,?,?,?)`, schemaInfo.ID, field.Path, index+1, field.Expression)
+		if dbErr != nil {
+			return fmt.Errorf("failed to save %s computed field %s into database: %s",
+				schemaName, field.Path, dbErr.Error())
+		}
+	}
+
+	return nil
+}
+
+//ValidateSubmissionFromJSON validate JSON data submitted by client against schema with computed fields
+//	computed item is not required, and it is a violation if client supply its value
+func ValidateSubmissionFromJSON(jsonStr string, schema *gxschema.DxDoc, fields []ComputedField) []Violation {
+	data, jsonErr := DecodeJSONData(jsonStr)
+	if jsonErr != nil {
+		return []Violation{{Rule: RuleFormat, Message: jsonErr.Error()}}
+	}
+
+	violations := CheckComputedData(data, fields)
+
+	return append(violations, FilterComputedViolations(ValidateDataFromJSON(jsonStr, schema), fields)...)
+}
+
+//CheckComputedData report every computed field which value is supplied in data
+func CheckComputedData(data map[string]interface{}, fields []ComputedField) []Violation {
+	violations := []Violation{}
+	for _, field := range fields {
+		violations = append(violations, findSuppliedValues(data, strings.Split(field.Path, "/"), "")...)
+	}
+
+	return violations
+}
+
+func findSuppliedValues(obj map[string]interface{}, segments []string, path string) []Violation {
+	value, exists := obj[segments[0]]
+	if !exists || value == nil {
+		return []Violation{}
+	}
+
+	itemPath := path + "/" + escapeJSONPointer(segments[0])
+	if len(segments) == 1 {
+		return []Violation{{Path: itemPath, Rule: RuleComputed, Value: value,
+			Message: "value is computed by server and must not be supplied"}}
+	}
+
+	violations := []Violation{}
+	switch x := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, findSuppliedValues(x, segments[1:], itemPath)...)
+	case []interface{}:
+		for index, element := range x {
+			if subObj, ok := element.(map[string]interface{}); ok {
+				violations = append(violations,
+					findSuppliedValues(subObj, segments[1:], itemPath+"/"+strconv.Itoa(index))...)
+			}
+		}
+	}
+
+	return violations
+}
+
+//GetComputedPaths get item path of every computed field
+func GetComputedPaths(fields []ComputedField) map[string]bool {
+	computedPaths := map[string]bool{}
+	for _, field := range fields {
+		computedPaths[field.Path] = true
+	}
+
+	return computedPaths
+}
+
+//FilterComputedViolations drop 'required' violations of computed fields, they are filled in by server
+func FilterComputedViolations(violations []Violation, fields []ComputedField) []Violation {
+	computedPaths := GetComputedPaths(fields)
+
+	results := []Violation{}
+	for _, violation := range violations {
+		if violation.Rule == RuleRequired && computedPaths[getItemPath(violation.Path)] {
+			continue
+		}
+
+		results = append(results, violation)
+	}
+
+	return results
+}
+
+//getItemPath convert JSON pointer into item path by removing array index; e.g. /items/0/qty become items/qty
+func getItemPath(pointer string) string {
+	segments := []string{}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if _, err := strconv.Atoi(token); err == nil {
+			continue
+		}
+
+		segments = append(segments, strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1))
+	}
+
+	return strings.Join(segments, "/")
+}
+
+//ApplyComputedFields compute value of every computed field with Lua runner and write it into data
+//return error if expression fail to run
+func ApplyComputedFields(L *lua.LState, schema *gxschema.DxDoc, data map[string]interface{},
+	fields []ComputedField) error {
+	ctx, cancel := context.WithTimeout(context.Background(), RuleScriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	for _, field := range fields {
+		fn, loadErr := field.load(L)
+		if loadErr != nil {
+			return fmt.Errorf("invalid expression of computed field %s: %s", field.Path, loadErr.Error())
+		}
+
+		L.SetGlobal("doc", toLuaValue(L, data)) //refresh with fields computed so far
+
+		if err := applyComputedField(L, fn, schema.Items, data, strings.Split(field.Path, "/")); err != nil {
+			return fmt.Errorf("failed to compute %s: %s", field.Path, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func applyComputedField(L *lua.LState, fn *lua.LFunction, items []gxschema.DxItem,
+	obj map[string]interface{}, segments []string) error {
+	info, infoErr := findItemInfo(items, segments[:1])
+	if infoErr != nil {
+		return infoErr
+	}
+	if info == nil {
+		return fmt.Errorf("item %s not found in schema", segments[0])
+	}
+
+	if len(segments) == 1 {
+		L.SetGlobal("row", toLuaValue(L, obj))
+		if err := L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}); err != nil {
+			return err
+		}
+
+		result := L.Get(-1)
+		L.Pop(1)
+
+		if value := fromLuaValue(result, info); value != nil {
+			obj[info.Name] = value
+		} else {
+			delete(obj, info.Name)
+		}
+
+		return nil
+	}
+
+	switch x := obj[info.Name].(type) {
+	case map[string]interface{}:
+		return applyComputedField(L, fn, info.Items, x, segments[1:])
+	case []interface{}:
+		for _, element := range x {
+			if subObj, ok := element.(map[string]interface{}); ok {
+				if err := applyComputedField(L, fn, info.Items, subObj, segments[1:]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil //optional section not supplied
+}
+
+//fromLuaValue convert Lua value into JSON value of item type, return nil for Lua nil
+func fromLuaValue(value lua.LValue, info *ItemInfo) interface{} {
+	switch x := value.(type) {
+	case lua.LNumber:
+		tmpFloat := float64(x)
+		switch info.Kind {
+		case ItemDecimal:
+			if rounded, ok := roundDecimal(tmpFloat, info.Precision); ok {
+				return json.Number(rounded)
+			}
+		case ItemInt:
+			if tmpFloat == math.Trunc(tmpFloat) && !math.IsInf(tmpFloat, 0) {
+				return json.Number(strconv.FormatFloat(tmpFloat, 'f', 0, 64))
+			}
+		case ItemStr:
+			return x.String()
+		}
+
+		return x.String() //leave it to validation to report type or precision violation
+	case lua.LString:
+		return string(x)
+	case lua.LBool:
+		return bool(x)
+	}
+
+	if value == lua.LNil {
+		return nil
+	}
+
+	return value.String()
+}
+
+//roundDecimal format Lua number with decimal places of item precision
+//	only binary floating point noise (e.g. 0.1 + 0.2) is rounded away; value which needs more decimal
+//	places, or too large to be exact at that precision, is rejected so validation report it
+func roundDecimal(value float64, precision int) (string, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", false
+	}
+
+	scale := math.Pow10(precision)
+	ulp := math.Nextafter(math.Abs(value), math.Inf(1)) - math.Abs(value)
+	noise := 64 * ulp //tolerated error accumulated by arithmetic
+	if noise*scale >= 0.5 {
+		return "", false
+	}
+
+	rounded := math.Round(value*scale) / scale
+	if math.Abs(rounded-value) > noise {
+		return "", false
+	}
+
+	return strconv.FormatFloat(rounded, 'f', precision, 64), true
+}
@@ -0,0 +1,209 @@
+package document
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/guinso/gxdoc/luascript"
+	"github.com/guinso/gxdoc/testutil"
+	"github.com/guinso/gxschema"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func getComputedTestSchema() *gxschema.DxDoc {
+	return &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+				gxschema.DxDecimal{Name: "amount", Precision: 2},
+			}},
+			gxschema.DxDecimal{Name: "total price", Precision: 2},
+		},
+	}
+}
+
+func getComputedTestFields() []ComputedField {
+	return []ComputedField{
+		{Path: "items/amount", Expression: "row.qty * row.price"},
+		{Path: "total price", Expression: `local sum = 0
+for _, item in ipairs(doc.items) do sum = sum + item.amount end
+return sum`},
+	}
+}
+
+func TestValidateComputedFields(t *testing.T) {
+	schema := getComputedTestSchema()
+
+	if err := ValidateComputedFields(schema, getComputedTestFields()); err != nil {
+		t.Errorf("expect computed fields are valid: %s", err.Error())
+	}
+
+	if err := ValidateComputedFields(schema, []ComputedField{{Path: "items/discount", Expression: "0"}}); err == nil {
+		t.Errorf("expect unknown item is rejected")
+	}
+
+	if err := ValidateComputedFields(schema, []ComputedField{{Path: "items", Expression: "0"}}); err == nil {
+		t.Errorf("expect section item is rejected")
+	}
+}
+
+func TestValidateSubmissionFromJSON(t *testing.T) {
+	schema := getComputedTestSchema()
+	fields := getComputedTestFields()
+
+	//computed items are not required
+	violations := ValidateSubmissionFromJSON(`{"items": [{"qty": 2, "price": 1.5}]}`, schema, fields)
+	for _, violation := range violations {
+		if violation.Rule == RuleRequired {
+			t.Errorf("expect computed item is not required but get %v", violation)
+		}
+	}
+
+	//client must not supply computed values
+	violations = ValidateSubmissionFromJSON(
+		`{"items": [{"qty": 2, "price": 1.5}, {"qty": 1, "price": 1, "amount": 1}], "total price": 4}`, schema, fields)
+	if len(violations) != 2 {
+		t.Fatalf("expect 2 violations but get %d: %v", len(violations), violations)
+	}
+	if violations[0].Path != "/items/1/amount" || violations[0].Rule != RuleComputed {
+		t.Errorf("expect /items/1/amount is reported but get %v", violations[0])
+	}
+	if violations[1].Path != "/total price" || violations[1].Rule != RuleComputed {
+		t.Errorf("expect /total price is reported but get %v", violations[1])
+	}
+}
+
+func TestGetItemPath(t *testing.T) {
+	if path := getItemPath("/items/12/a~1b"); path != "items/a/b" {
+		t.Errorf("expect items/a/b but get %s", path)
+	}
+}
+
+func TestApplyComputedFields(t *testing.T) {
+	data, _ := DecodeJSONData(`{"items": [{"qty": 2, "price": 1.5}, {"qty": 3, "price": 0.1}]}`)
+
+	L := lua.NewState()
+	defer L.Close()
+
+	if err := ApplyComputedFields(L, getComputedTestSchema(), data, getComputedTestFields()); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	items := data["items"].([]interface{})
+	if amount := items[1].(map[string]interface{})["amount"]; amount != json.Number("0.30") {
+		t.Errorf("expect second item amount 0.30 but get %v", amount)
+	}
+	if total := data["total price"]; total != json.Number("3.30") {
+		t.Errorf("expect total price 3.30 but get %v", total)
+	}
+}
+
+func TestComputedFieldLoad(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	cases := map[string]string{
+		"1 + 2":                               "3",
+		"'a' .. ' return'":                    "a return",
+		"4 -- return later":                   "4",
+		"[[return]] .. 5":                     "return5",
+		"local x = 2 return x":                "2",
+		"if 1 > 2 then return 1 end return 0": "0",
+	}
+	for expression, expected := range cases {
+		field := ComputedField{Path: "x", Expression: expression}
+		fn, loadErr := field.load(L)
+		if loadErr != nil {
+			t.Errorf("expect expression '%s' compiles but get %s", expression, loadErr.Error())
+			continue
+		}
+
+		if err := L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}); err != nil {
+			t.Errorf("expect expression '%s' runs but get %s", expression, err.Error())
+			continue
+		}
+		if result := L.Get(-1).String(); result != expected {
+			t.Errorf("expect expression '%s' return %s but get %s", expression, expected, result)
+		}
+		L.Pop(1)
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	cases := []struct {
+		value     float64
+		precision int
+		expected  string
+	}{
+		{0.1 + 0.2, 2, "0.30"},
+		{3 * 1.1, 1, "3.3"},
+		{12.5, 2, "12.50"},
+		{1.0 / 3, 2, ""},
+		{1.005, 1, ""},
+		{1e17 + 1, 2, ""},
+	}
+	for _, item := range cases {
+		result, ok := roundDecimal(item.value, item.precision)
+		if ok != (item.expected != "") || result != item.expected {
+			t.Errorf("expect %v with precision %d is '%s' but get '%s'", item.value, item.precision, item.expected, result)
+		}
+	}
+}
+
+func TestReleasedComputedFields(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	draftFields := []ComputedField{{Path: "qty", Expression: `string.len(doc["pr number"])`}}
+	if err := SaveDraftComputedFields(trx, "pr", draftFields); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if err := SaveDraftToNewRevision(trx, "pr"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	fields, fieldsErr := GetComputedFields(trx, "pr", 2)
+	if fieldsErr != nil {
+		t.Fatal(fieldsErr)
+		return
+	}
+	if len(fields) != 1 || fields[0] != draftFields[0] {
+		t.Fatalf("expect released revision 2 carry draft computed fields but get %v", fields)
+	}
+
+	schema, schemaErr := GetSchemaByRevision(trx, "pr", 2)
+	if schemaErr != nil {
+		t.Fatal(schemaErr)
+		return
+	}
+
+	L := luascript.GetSandboxRunner()
+	defer L.Close()
+
+	data, _ := DecodeJSONData(`{"pr number": "PR01"}`)
+	if err := ApplyComputedFields(L, schema, data, fields); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if qty := data["qty"]; qty != json.Number("4") {
+		t.Errorf("expect released computed field fill in qty 4 but get %v", qty)
+	}
+}
@@ -0,0 +1,149 @@
+package document
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+)
+
+//CSVRowError validation failure of a CSV row
+type CSVRowError struct {
+	Row    int         `json:"row"` //line number in CSV, header is row 1
+	Errors []Violation `json:"errors"`
+}
+
+//CSVRow CSV row converted into JSON document data
+type CSVRow struct {
+	Row  int
+	Data string
+}
+
+//ConvertCSV convert every CSV row into JSON document data and validate it against a flat schema
+//	CSV header is matched against schema's top level item name,
+//	mapping (optional) rename CSV header into item name; e.g. {"Invoice No": "code"}
+//	only int, str, bool and decimal items are supported; other items must be optional
+//	empty cell is treated as missing value
+//	computed fields (optional) are not required and CSV must not supply their values
+//return error if CSV or its header cannot be mapped onto schema
+func ConvertCSV(reader io.Reader, schema *gxschema.DxDoc, mapping map[string]string,
+	fields []ComputedField) ([]CSVRow, []CSVRowError, error) {
+	flatItems := map[string]*ItemInfo{}
+	for _, item := range schema.Items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, nil, infoErr
+		}
+
+		switch info.Kind {
+		case ItemInt, ItemStr, ItemBool, ItemDecimal:
+			if !info.IsArray {
+				flatItems[info.Name] = info
+				continue
+			}
+		}
+
+		if !info.IsOptional {
+			return nil, nil, fmt.Errorf("schema is not flat: item '%s' cannot be imported from CSV", info.Name)
+		}
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1 //field count is checked per row, so one bad row doesn't abort import
+
+	header, headerErr := csvReader.Read()
+	if headerErr == io.EOF {
+		return nil, nil, fmt.Errorf("CSV has no header row")
+	}
+	if headerErr != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %s", headerErr.Error())
+	}
+
+	columns := make([]*ItemInfo, len(header))
+	for index, column := range header {
+		name := strings.TrimSpace(column)
+		if mapped, ok := mapping[name]; ok {
+			name = mapped
+		}
+
+		info, ok := flatItems[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("CSV column '%s' does not map to any item of %s", column, schema.Name)
+		}
+		for _, other := range columns[:index] {
+			if other == info {
+				return nil, nil, fmt.Errorf("item '%s' is mapped by more than one CSV column", name)
+			}
+		}
+
+		columns[index] = info
+	}
+
+	rows := []CSVRow{}
+	rowErrors := []CSVRowError{}
+	for rowNo := 2; ; rowNo++ {
+		record, readErr := csvReader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %s", readErr.Error())
+		}
+
+		if len(record) != len(header) {
+			rowErrors = append(rowErrors, CSVRowError{Row: rowNo, Errors: []Violation{{
+				Rule:    RuleFormat,
+				Message: fmt.Sprintf("row has %d fields but header has %d", len(record), len(header)),
+			}}})
+			continue
+		}
+
+		obj := map[string]interface{}{}
+		for index, cell := range record {
+			if cell = strings.TrimSpace(cell); cell != "" {
+				obj[columns[index].Name] = convertCSVCell(columns[index], cell)
+			}
+		}
+
+		jsonRaw, jsonErr := json.Marshal(obj)
+		if jsonErr != nil {
+			return nil, nil, jsonErr
+		}
+
+		if violations := ValidateSubmissionFromJSON(string(jsonRaw), schema, fields); len(violations) > 0 {
+			rowErrors = append(rowErrors, CSVRowError{Row: rowNo, Errors: violations})
+			continue
+		}
+
+		rows = append(rows, CSVRow{Row: rowNo, Data: string(jsonRaw)})
+	}
+
+	return rows, rowErrors, nil
+}
+
+//convertCSVCell convert CSV text into JSON value of item type
+//text is kept as is if conversion failed, so validation report it as type violation
+//NOTE: number must also be valid JSON number literal; e.g. +5, NaN and Inf are rejected
+func convertCSVCell(info *ItemInfo, cell string) interface{} {
+	switch info.Kind {
+	case ItemInt:
+		if _, err := strconv.ParseInt(cell, 10, 64); err == nil && json.Valid([]byte(cell)) {
+			return json.Number(cell)
+		}
+	case ItemDecimal:
+		if _, err := strconv.ParseFloat(cell, 64); err == nil && json.Valid([]byte(cell)) {
+			return json.Number(cell)
+		}
+	case ItemBool:
+		if tmpBool, err := strconv.ParseBool(cell); err == nil {
+			return tmpBool
+		}
+	}
+
+	return cell
+}
@@ -0,0 +1,74 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func TestConvertCSV(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "payment",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "code", EnableLenLimit: true, LenLimit: 5},
+			gxschema.DxInt{Name: "qty"},
+			gxschema.DxDecimal{Name: "amount", Precision: 2},
+			gxschema.DxBool{Name: "isPaid", IsOptional: true},
+		},
+	}
+
+	input := "Payment No,qty,amount,isPaid\n" +
+		"ABCDEFG,2,1.5,true\n" +
+		"P2,two,1.555,\n" +
+		"P3,2,1.5\n" +
+		"P4,2,1.5,true\n"
+
+	rows, rowErrors, err := ConvertCSV(strings.NewReader(input), schema, map[string]string{"Payment No": "code"}, nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if len(rows) != 1 || rows[0].Row != 5 {
+		t.Errorf("expect only row 5 is valid but get %v", rows)
+	}
+	if len(rowErrors) != 3 {
+		t.Fatalf("expect 3 failed rows but get %d", len(rowErrors))
+	}
+
+	if rowErrors[0].Row != 2 || len(rowErrors[0].Errors) != 1 || rowErrors[0].Errors[0].Rule != RuleLenLimit {
+		t.Errorf("expect row 2 violate lenLimit but get %v", rowErrors[0])
+	}
+	if rowErrors[1].Row != 3 || len(rowErrors[1].Errors) != 2 ||
+		rowErrors[1].Errors[0].Path != "/qty" || rowErrors[1].Errors[1].Rule != RulePrecision {
+		t.Errorf("expect row 3 violate qty type and amount precision but get %v", rowErrors[1])
+	}
+	if rowErrors[2].Row != 4 || len(rowErrors[2].Errors) != 1 || rowErrors[2].Errors[0].Rule != RuleFormat {
+		t.Errorf("expect row 4 violate field count but get %v", rowErrors[2])
+	}
+}
+
+func TestConvertCSVHeader(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name: "payment",
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "code"},
+			gxschema.DxSection{Name: "lines", IsOptional: true},
+		},
+	}
+
+	if _, _, err := ConvertCSV(strings.NewReader("code,remark\nA,B\n"), schema, nil, nil); err == nil {
+		t.Errorf("expect unknown column is rejected")
+	}
+
+	if _, _, err := ConvertCSV(strings.NewReader("code,lines\nA,B\n"), schema, nil, nil); err == nil {
+		t.Errorf("expect section item cannot be mapped by column")
+	}
+
+	schema.Items[1] = gxschema.DxSection{Name: "lines"}
+	if _, _, err := ConvertCSV(strings.NewReader("code\nA\n"), schema, nil, nil); err == nil {
+		t.Errorf("expect schema with mandatory section is rejected")
+	}
+}
@@ -20,3 +20,96 @@ type ErrDraftNotFound struct {
 }
 
 func (err ErrDraftNotFound) Error() string { return err.msg }
+
+//ErrRecordNotFound error to indicate specified document record is not found in database
+type ErrRecordNotFound struct {
+	msg string
+}
+
+func (err ErrRecordNotFound) Error() string { return err.msg }
+
+//ErrWorkflowNotFound error to indicate document schema has no workflow declared
+type ErrWorkflowNotFound struct {
+	msg string
+}
+
+func (err ErrWorkflowNotFound) Error() string { return err.msg }
+
+//ErrTransitionNotAllowed error to indicate workflow transition is unknown or not allowed from current state
+type ErrTransitionNotAllowed struct {
+	msg string
+}
+
+func (err ErrTransitionNotAllowed) Error() string { return err.msg }
+
+//ErrTransitionNotAuthorize error to indicate requestor has no role required by workflow transition
+type ErrTransitionNotAuthorize struct {
+	msg string
+}
+
+func (err ErrTransitionNotAuthorize) Error() string { return err.msg }
+
+//ErrReferenceNotFound error to indicate record referenced by document data is not found in database
+type ErrReferenceNotFound struct {
+	msg string
+}
+
+func (err ErrReferenceNotFound) Error() string { return err.msg }
+
+//ErrInvalidComputedField error to indicate computed field not match with schema item
+type ErrInvalidComputedField struct {
+	msg string
+}
+
+func (err ErrInvalidComputedField) Error() string { return err.msg }
+
+//ErrInvalidInclude error to indicate included schema can't be resolved; e.g. not found, cyclic or name conflict
+type ErrInvalidInclude struct {
+	msg string
+}
+
+func (err ErrInvalidInclude) Error() string { return err.msg }
+
+//ErrSchemaLint error to indicate schema definition has lint error, see LintSchema
+type ErrSchemaLint struct {
+	msg    string
+	Issues []LintIssue //every issue found, including warnings
+}
+
+func (err ErrSchemaLint) Error() string { return err.msg }
+
+//ErrInvalidLocale error to indicate localized metadata not match with schema item
+type ErrInvalidLocale struct {
+	msg string
+}
+
+func (err ErrInvalidLocale) Error() string { return err.msg }
+
+//ErrInvalidBundle error to indicate schema bundle archive is malformed or its hash chain is broken
+type ErrInvalidBundle struct {
+	msg string
+}
+
+func (err ErrInvalidBundle) Error() string { return err.msg }
+
+//ErrBundleConflict error to indicate schema of bundle can't be imported under conflict policy
+type ErrBundleConflict struct {
+	msg string
+}
+
+func (err ErrBundleConflict) Error() string { return err.msg }
+
+//ErrNumberingConflict error to indicate new numbering sequence may repeat allocated document number
+type ErrNumberingConflict struct {
+	msg string
+}
+
+func (err ErrNumberingConflict) Error() string { return err.msg }
+
+//ErrWorkflowStateInUse error to indicate new workflow drops states which document records are still in
+type ErrWorkflowStateInUse struct {
+	msg    string
+	States []string
+}
+
+func (err ErrWorkflowStateInUse) Error() string { return err.msg }
@@ -0,0 +1,199 @@
+package document
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/guinso/rdbmstool"
+)
+
+//ChainReport result of walking through a hash chain
+type ChainReport struct {
+	Name           string `json:"name"`
+	CheckedCount   int    `json:"checked"`
+	IsValid        bool   `json:"isValid"`
+	BrokenRevision int    `json:"brokenRevision,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+//ComputeChainHash calculate SHA-256 hash (hex encoded) of a chain link
+//	prevHash is hash of previous link, use empty string for first link
+//	parts are content of current link; e.g. ID, revision number and XML definition
+func ComputeChainHash(prevHash string, parts ...string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(prevHash))
+	for _, part := range parts {
+		//length prefix avoid ambiguous concatenation; e.g. "ab"+"c" vs "a"+"bc"
+		hasher.Write([]byte("\n" + strconv.Itoa(len(part)) + ":" + part))
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+//computeRevisionHash calculate hash of a released schema revision
+//	hashed content is declared definition (XML definition with <dxref> and <dxinclude> written back)
+//	plus canonical JSON of rule script, computed fields and locales, so none of them can be
+//	changed without breaking the chain
+func computeRevisionHash(prevHash string, schemaID string, revision *BundleRevision) (string, error) {
+	declared, declaredErr := ApplyReferences(revision.xmlDef, revision.References)
+	if declaredErr == nil {
+		declared, declaredErr = ApplyIncludes(declared, revision.Includes)
+	}
+	if declaredErr != nil {
+		return "", fmt.Errorf("failed to get declared definition of revision %d: %s",
+			revision.Revision, declaredErr.Error())
+	}
+
+	attachments, jsonErr := json.Marshal(struct {
+		RuleScript string          `json:"ruleScript,omitempty"`
+		Computed   []ComputedField `json:"computed,omitempty"`
+		Locales    []SchemaLocale  `json:"locales,omitempty"`
+	}{revision.RuleScript, revision.Computed, revision.Locales})
+	if jsonErr != nil {
+		return "", jsonErr
+	}
+
+	return ComputeChainHash(prevHash, schemaID, strconv.Itoa(revision.Revision), declared, string(attachments)), nil
+}
+
+//SealSchemaRevision recalculate hash of released schema revision once its references, includes,
+//rule script, computed fields and locales are saved
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func SealSchemaRevision(db rdbmstool.DbHandlerProxy, schemaName string, revision int) error {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	row := db.QueryRow(`SELECT xml_definition, prev_hash FROM doc_schema_revision WHERE schema_id = ? AND revision = ?`,
+		schemaInfo.ID, revision)
+	var tmpXML, tmpPrevHash string
+	if err := row.Scan(&tmpXML, &tmpPrevHash); err != nil {
+		return fmt.Errorf("failed to fetch record from database: %s", err.Error())
+	}
+
+	stored := BundleRevision{Revision: revision, xmlDef: tmpXML}
+	if err := getBundleRevisionData(db, schemaInfo.Name, &stored); err != nil {
+		return err
+	}
+
+	contentHash, hashErr := computeRevisionHash(tmpPrevHash, schemaInfo.ID, &stored)
+	if hashErr != nil {
+		return hashErr
+	}
+
+	if _, err := db.Exec(`UPDATE doc_schema_revision SET content_hash = ? WHERE schema_id = ? AND revision = ?`,
+		contentHash, schemaInfo.ID, revision); err != nil {
+		return fmt.Errorf("failed to update hash of %s revision %d: %s", schemaName, revision, err.Error())
+	}
+
+	return nil
+}
+
+//getRevisionHash get stored hash of specified schema revision
+//return empty string if revision not found
+func getRevisionHash(db rdbmstool.DbHandlerProxy, schemaID string, revision int) (string, error) {
+	row := db.QueryRow(`SELECT content_hash FROM doc_schema_revision WHERE schema_id = ? AND revision = ?`,
+		schemaID, revision)
+
+	var tmpHash string
+	if err := row.Scan(&tmpHash); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to fetch revision hash from database: %s", err.Error())
+	}
+
+	return tmpHash, nil
+}
+
+//VerifySchemaChain walk through all released revisions of specified document schema
+//and report first broken link found (if any)
+//NOTE: draft (revision -1) is excluded since it is allowed to be overwritten
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func VerifySchemaChain(db rdbmstool.DbHandlerProxy, schemaName string) (*ChainReport, error) {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	if schemaInfo == nil {
+		return nil, ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	rows, rowsErr := db.Query(`SELECT revision, xml_definition, content_hash, prev_hash
+	FROM doc_schema_revision WHERE schema_id = ? AND revision > 0 ORDER BY revision`, schemaInfo.ID)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	type chainLink struct {
+		revision int
+		xmlDef   string
+		hash     string
+		prevHash string
+	}
+
+	//read all links first; revision data is queried while walking through chain
+	links := []chainLink{}
+	for rows.Next() {
+		link := chainLink{}
+		if err := rows.Scan(&link.revision, &link.xmlDef, &link.hash, &link.prevHash); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+	}
+	rows.Close()
+
+	report := ChainReport{Name: schemaInfo.Name, IsValid: true}
+
+	expectedRev := 1
+	expectedPrevHash := ""
+	for _, link := range links {
+		report.CheckedCount++
+
+		if link.revision != expectedRev {
+			report.IsValid = false
+			report.BrokenRevision = expectedRev
+			report.Reason = fmt.Sprintf("revision %d is missing from chain", expectedRev)
+			return &report, nil
+		}
+
+		if link.prevHash != expectedPrevHash {
+			report.IsValid = false
+			report.BrokenRevision = link.revision
+			report.Reason = fmt.Sprintf("revision %d does not link to hash of revision %d", link.revision, link.revision-1)
+			return &report, nil
+		}
+
+		stored := BundleRevision{Revision: link.revision, xmlDef: link.xmlDef}
+		if err := getBundleRevisionData(db, schemaInfo.Name, &stored); err != nil {
+			return nil, err
+		}
+
+		//definition which can't be written back is tampered as well
+		contentHash, hashErr := computeRevisionHash(link.prevHash, schemaInfo.ID, &stored)
+		if hashErr != nil || contentHash != link.hash {
+			report.IsValid = false
+			report.BrokenRevision = link.revision
+			report.Reason = fmt.Sprintf("revision %d content does not match its hash", link.revision)
+			return &report, nil
+		}
+
+		expectedRev++
+		expectedPrevHash = link.hash
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,96 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+func TestComputeChainHash(t *testing.T) {
+	hash1 := ComputeChainHash("", "ab", "c")
+	hash2 := ComputeChainHash("", "a", "bc")
+
+	if len(hash1) != 64 {
+		t.Errorf("expect hash is 64 characters long but get %d", len(hash1))
+	}
+
+	if strings.Compare(hash1, hash2) == 0 {
+		t.Errorf("expect different parts produce different hash")
+	}
+
+	if strings.Compare(hash1, ComputeChainHash("", "ab", "c")) != 0 {
+		t.Errorf("expect same input produce same hash")
+	}
+
+	if strings.Compare(hash1, ComputeChainHash(hash2, "ab", "c")) == 0 {
+		t.Errorf("expect previous hash affect current hash")
+	}
+}
+
+func TestVerifySchemaChain(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	report, reportErr := VerifySchemaChain(db, "invoice")
+	if reportErr != nil {
+		t.Fatal(reportErr)
+		return
+	}
+
+	if !report.IsValid {
+		t.Errorf("expect invoice hash chain is valid but get: %s", report.Reason)
+	}
+
+	if report.CheckedCount != 2 {
+		t.Errorf("expect 2 revisions checked but get %d", report.CheckedCount)
+	}
+
+	_, reportErr = VerifySchemaChain(db, "invoice123")
+	if _, ok := reportErr.(ErrSchemaInfoNotFound); !ok {
+		t.Errorf("expect ErrSchemaInfoNotFound for unregistered schema")
+	}
+}
+
+func TestVerifySchemaChainAttachments(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+	defer trx.Rollback()
+
+	//rule script slipped into released revision must break the chain
+	if _, err := trx.Exec(`INSERT INTO doc_schema_rule (schema_id, revision, script) VALUES (?, 2, ?)`,
+		"733bee1b-f79a-4cb7-b675-842317b994b5", "addError('/invNo', 'rejected')"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	report, reportErr := VerifySchemaChain(trx, "invoice")
+	if reportErr != nil {
+		t.Fatal(reportErr)
+		return
+	}
+	if report.IsValid || report.BrokenRevision != 2 {
+		t.Errorf("expect revision 2 is broken after rule script changed but get %+v", report)
+	}
+
+	//sealing revision again accept current content
+	if err := SealSchemaRevision(trx, "invoice", 2); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if report, reportErr = VerifySchemaChain(trx, "invoice"); reportErr != nil || !report.IsValid {
+		t.Errorf("expect sealed revision is valid but get %+v, %v", report, reportErr)
+	}
+}
@@ -0,0 +1,572 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+)
+
+//Include declare items of another schema revision which are spliced into schema definition when loading
+//
+//	in XML definition it is written as <dxinclude schema="header" revision="2"></dxinclude> within <dxdoc> or
+//	<dxsection>; section="requester" includes only top level section requester of header instead of every item;
+//	<dxdoc extends="header" extendsRevision="2"> is stored as including every item of header before own items
+type Include struct {
+	ItemPath       string `json:"item"`              //section where items are spliced, empty means top level
+	Position       int    `json:"position"`          //index among own items of the section where items are spliced
+	TargetSchema   string `json:"schema"`            //included schema name
+	TargetRevision int    `json:"revision"`          //included schema revision, must be released
+	Section        string `json:"section,omitempty"` //top level section of included schema, empty means every item
+}
+
+//IncludeDependent schema revision which includes (directly or through another schema) a base schema
+type IncludeDependent struct {
+	Schema       string `json:"schema"`
+	Revision     int    `json:"revision"`     //-1 means draft
+	BaseSchema   string `json:"baseSchema"`   //schema included directly by dependent
+	BaseRevision int    `json:"baseRevision"` //revision included directly by dependent
+}
+
+//includeContainer XML element visited while rewriting <dxinclude>
+type includeContainer struct {
+	isContainer bool   //<dxdoc> or <dxsection>
+	path        string //item path of <dxsection>
+	count       int    //number of own items visited so far
+}
+
+//includedItems items of included schema revision, ready to be spliced
+type includedItems struct {
+	Include
+	items []gxschema.DxItem
+}
+
+//ExtractIncludes remove <dxinclude> items and extends attribute of XML definition
+//so it is able to parse by gxschema.ParseSchemaFromXML
+//RETURN:
+//	string: XML definition without <dxinclude>
+//	[]Include: includes found in XML definition, in declaration order
+func ExtractIncludes(xmlStr string) (string, []Include, error) {
+	includes := []Include{}
+	stack := []*includeContainer{}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", nil, fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			var parent *includeContainer
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			if element.Name.Local == "dxinclude" {
+				if parent == nil || !parent.isContainer {
+					return "", nil, fmt.Errorf("dxinclude must be placed within dxdoc or dxsection")
+				}
+				include, includeErr := newInclude(getXMLAttr(element, "schema"), getXMLAttr(element, "revision"),
+					getXMLAttr(element, "section"), parent.path, parent.count)
+				if includeErr != nil {
+					return "", nil, includeErr
+				}
+				includes = append(includes, include)
+
+				if err := decoder.Skip(); err != nil {
+					return "", nil, fmt.Errorf("invalid XML: %s", err.Error())
+				}
+				continue
+			}
+
+			entry := includeContainer{}
+			if parent != nil && parent.isContainer {
+				parent.count++
+			}
+			if element.Name.Local == "dxdoc" {
+				entry.isContainer = true
+				if base := getXMLAttr(element, "extends"); base != "" {
+					include, includeErr := newInclude(base, getXMLAttr(element, "extendsRevision"), "", "", 0)
+					if includeErr != nil {
+						return "", nil, includeErr
+					}
+					includes = append(includes, include)
+				}
+				element.Attr = removeXMLAttr(removeXMLAttr(element.Attr, "extends"), "extendsRevision")
+				token = element
+			} else if element.Name.Local == "dxsection" && parent != nil {
+				entry.isContainer = true
+				entry.path = joinItemPath(parent.path, getXMLAttr(element, "name"))
+			}
+			stack = append(stack, &entry)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", nil, fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", nil, fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), includes, nil
+}
+
+func newInclude(schema string, rawRev string, section string, path string, position int) (Include, error) {
+	include := Include{ItemPath: path, Position: position, TargetSchema: schema, Section: section}
+	if schema == "" {
+		return include, fmt.Errorf("dxinclude at %s must specify included schema", describeIncludePath(path))
+	}
+
+	rev, revErr := strconv.Atoi(rawRev)
+	if revErr != nil || rev < 1 {
+		return include, fmt.Errorf("dxinclude of %s must specify released revision: %s", schema, rawRev)
+	}
+	include.TargetRevision = rev
+
+	return include, nil
+}
+
+func describeIncludePath(path string) string {
+	if path == "" {
+		return "top level"
+	}
+
+	return path
+}
+
+//ApplyIncludes write includes back into XML definition as <dxinclude>, reverse of ExtractIncludes
+//	xmlStr must be definition without included items; i.e. from GetDeclaredSchemaByRevision
+func ApplyIncludes(xmlStr string, includes []Include) (string, error) {
+	if len(includes) == 0 {
+		return xmlStr, nil
+	}
+
+	pending := map[string][]Include{}
+	for _, include := range includes {
+		pending[include.ItemPath] = append(pending[include.ItemPath], include)
+	}
+	for path := range pending {
+		sortIncludes(pending[path])
+	}
+
+	stack := []*includeContainer{}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	writeIncludes := func(path string, position int) error {
+		for len(pending[path]) > 0 && pending[path][0].Position <= position {
+			include := pending[path][0]
+			pending[path] = pending[path][1:]
+
+			element := xml.StartElement{Name: xml.Name{Local: "dxinclude"}, Attr: []xml.Attr{
+				{Name: xml.Name{Local: "schema"}, Value: include.TargetSchema},
+				{Name: xml.Name{Local: "revision"}, Value: strconv.Itoa(include.TargetRevision)},
+			}}
+			if include.Section != "" {
+				element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: "section"}, Value: include.Section})
+			}
+			if err := encoder.EncodeToken(element); err != nil {
+				return err
+			}
+			if err := encoder.EncodeToken(element.End()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			var parent *includeContainer
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			entry := includeContainer{}
+			if parent != nil && parent.isContainer {
+				if err := writeIncludes(parent.path, parent.count); err != nil {
+					return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+				}
+				parent.count++
+			}
+			if element.Name.Local == "dxdoc" {
+				entry.isContainer = true
+			} else if element.Name.Local == "dxsection" && parent != nil {
+				entry.isContainer = true
+				entry.path = joinItemPath(parent.path, getXMLAttr(element, "name"))
+			}
+			stack = append(stack, &entry)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if top.isContainer {
+					if err := writeIncludes(top.path, math.MaxInt32); err != nil {
+						return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+					}
+				}
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
+
+func sortIncludes(includes []Include) {
+	sort.SliceStable(includes, func(i, j int) bool {
+		return includes[i].Position < includes[j].Position
+	})
+}
+
+//GetIncludes get includes declared by schema revision (use -1 for draft), in declaration order
+func GetIncludes(db rdbmstool.DbHandlerProxy, schemaName string, revision int) ([]Include, error) {
+	rows, rowsErr := db.Query(`SELECT a.item_path, a.position, a.target_schema, a.target_revision, a.section
+	FROM doc_schema_include a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.revision = ?
+	ORDER BY a.seq`, schemaName, revision)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []Include{}
+	for rows.Next() {
+		include := Include{}
+		if err := rows.Scan(&include.ItemPath, &include.Position, &include.TargetSchema,
+			&include.TargetRevision, &include.Section); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		results = append(results, include)
+	}
+
+	return results, nil
+}
+
+//SaveIncludes save includes declared by schema revision (use -1 for draft)
+//NOTE: existing includes of the revision will be overwritten
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrInvalidInclude error will return if included schema revision not found in database
+func SaveIncludes(db rdbmstool.DbHandlerProxy, schemaName string, revision int, includes []Include) error {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	if _, err := db.Exec(`DELETE FROM doc_schema_include WHERE schema_id = ? AND revision = ?`,
+		schemaInfo.ID, revision); err != nil {
+		return fmt.Errorf("failed to clear %s includes from database: %s", schemaName, err.Error())
+	}
+
+	for seq, include := range includes {
+		targetInfo, targetErr := GetSchemaInfo(db, include.TargetSchema)
+		if targetErr != nil {
+			return targetErr
+		}
+		if targetInfo == nil || include.TargetRevision < 1 || include.TargetRevision > targetInfo.LatestRevision {
+			return ErrInvalidInclude{msg: fmt.Sprintf("schema %s revision %d included at %s not found in database",
+				include.TargetSchema, include.TargetRevision, describeIncludePath(include.ItemPath))}
+		}
+
+		_, dbErr := db.Exec(`INSERT INTO doc_schema_include
+		(schema_id, revision, seq, item_path, position, target_schema, target_revision, section)
+		VALUES (?,?,?,?,?,?,?,?)`,
+			schemaInfo.ID, revision, seq, include.ItemPath, include.Position,
+			include.TargetSchema, include.TargetRevision, include.Section)
+		if dbErr != nil {
+			return fmt.Errorf("failed to save %s include of %s into database: %s",
+				schemaName, include.TargetSchema, dbErr.Error())
+		}
+	}
+
+	return nil
+}
+
+//GetIncludeDependents get every schema revision (including draft) which depends on base schema,
+//either by including it directly or by including a revision of another dependent
+func GetIncludeDependents(db rdbmstool.DbHandlerProxy, baseSchema string) ([]IncludeDependent, error) {
+	results := []IncludeDependent{}
+
+	//revision 0 means every revision of base schema
+	visited := map[string]bool{}
+	queue := []IncludeDependent{{Schema: baseSchema, Revision: 0}}
+
+	for len(queue) > 0 {
+		base := queue[0]
+		queue = queue[1:]
+
+		dependents, err := getDirectIncludeDependents(db, base.Schema, base.Revision)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dependent := range dependents {
+			results = append(results, dependent)
+
+			//draft can't be included, so nothing depends on it
+			key := fmt.Sprintf("%s revision %d", dependent.Schema, dependent.Revision)
+			if dependent.Revision > 0 && !visited[key] {
+				visited[key] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+//getDirectIncludeDependents get schema revisions which include base schema revision directly,
+//use 0 as baseRevision to match every revision of base schema
+func getDirectIncludeDependents(db rdbmstool.DbHandlerProxy, baseSchema string,
+	baseRevision int) ([]IncludeDependent, error) {
+	rows, rowsErr := db.Query(`SELECT DISTINCT b.name, a.revision, a.target_revision
+	FROM doc_schema_include a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE a.target_schema = ? AND (? = 0 OR a.target_revision = ?)
+	ORDER BY b.name, a.revision, a.target_revision`, baseSchema, baseRevision, baseRevision)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []IncludeDependent{}
+	for rows.Next() {
+		dependent := IncludeDependent{BaseSchema: baseSchema}
+		if err := rows.Scan(&dependent.Schema, &dependent.Revision, &dependent.BaseRevision); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		results = append(results, dependent)
+	}
+
+	return results, nil
+}
+
+//flattenSchema splice items of included schema revisions into schema
+//	stack is list of schema revisions being flattened, to detect cyclic include
+func flattenSchema(db rdbmstool.DbHandlerProxy, schema *gxschema.DxDoc, stack []string) (*gxschema.DxDoc, error) {
+	includes, includeErr := GetIncludes(db, schema.Name, schema.Revision)
+	if includeErr != nil {
+		return nil, includeErr
+	}
+	if len(includes) == 0 {
+		return schema, nil
+	}
+
+	key := fmt.Sprintf("%s revision %d", schema.Name, schema.Revision)
+	for _, visiting := range stack {
+		if visiting == key {
+			return nil, ErrInvalidInclude{msg: "cyclic include: " + strings.Join(append(stack, key), " -> ")}
+		}
+	}
+	stack = append(stack, key)
+
+	pending := map[string][]includedItems{}
+	for _, include := range includes {
+		target, targetErr := GetDeclaredSchemaByRevision(db, include.TargetSchema, include.TargetRevision)
+		if targetErr != nil {
+			return nil, targetErr
+		}
+		if target == nil {
+			return nil, ErrInvalidInclude{msg: fmt.Sprintf("schema %s revision %d included by %s not found",
+				include.TargetSchema, include.TargetRevision, key)}
+		}
+
+		target, targetErr = flattenSchema(db, target, stack)
+		if targetErr != nil {
+			return nil, targetErr
+		}
+
+		items, itemsErr := getIncludedItems(target, include)
+		if itemsErr != nil {
+			return nil, itemsErr
+		}
+		pending[include.ItemPath] = append(pending[include.ItemPath], includedItems{Include: include, items: items})
+	}
+
+	return spliceSchema(schema, pending)
+}
+
+//spliceSchema create copy of schema with included items spliced at their positions
+func spliceSchema(schema *gxschema.DxDoc, pending map[string][]includedItems) (*gxschema.DxDoc, error) {
+	for path := range pending {
+		sort.SliceStable(pending[path], func(i, j int) bool {
+			return pending[path][i].Position < pending[path][j].Position
+		})
+	}
+
+	visited := map[string]bool{}
+	items, itemsErr := spliceItems(schema.Items, "", pending, visited)
+	if itemsErr != nil {
+		return nil, itemsErr
+	}
+
+	for path := range pending {
+		if !visited[path] {
+			return nil, ErrInvalidInclude{msg: fmt.Sprintf("section %s of %s not found to include %s",
+				path, schema.Name, pending[path][0].TargetSchema)}
+		}
+	}
+
+	flatten := *schema
+	flatten.Items = items
+
+	return &flatten, nil
+}
+
+func spliceItems(items []gxschema.DxItem, path string, pending map[string][]includedItems,
+	visited map[string]bool) ([]gxschema.DxItem, error) {
+	visited[path] = true
+	includes := pending[path]
+	results := []gxschema.DxItem{}
+	next := 0
+	appendIncluded := func(position int) {
+		for next < len(includes) && includes[next].Position <= position {
+			results = append(results, includes[next].items...)
+			next++
+		}
+	}
+
+	for index, item := range items {
+		appendIncluded(index)
+
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		if info.Kind == ItemSection {
+			subItems, subErr := spliceItems(info.Items, joinItemPath(path, info.Name), pending, visited)
+			if subErr != nil {
+				return nil, subErr
+			}
+			item = &gxschema.DxSection{Name: info.Name, IsOptional: info.IsOptional, IsArray: info.IsArray,
+				Items: subItems}
+		}
+
+		results = append(results, item)
+	}
+	appendIncluded(math.MaxInt32)
+
+	names := map[string]bool{}
+	for _, item := range results {
+		name := item.GetName()
+		if names[name] {
+			return nil, ErrInvalidInclude{msg: fmt.Sprintf("item %s is declared more than once after include",
+				joinItemPath(path, name))}
+		}
+		names[name] = true
+	}
+
+	return results, nil
+}
+
+//getIncludedItems get items of (flattened) included schema revision selected by include
+func getIncludedItems(target *gxschema.DxDoc, include Include) ([]gxschema.DxItem, error) {
+	if include.Section == "" {
+		return target.Items, nil
+	}
+
+	for _, item := range target.Items {
+		if item.GetName() != include.Section {
+			continue
+		}
+
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		if info.Kind != ItemSection {
+			break
+		}
+
+		return []gxschema.DxItem{item}, nil
+	}
+
+	return nil, ErrInvalidInclude{msg: fmt.Sprintf("schema %s revision %d has no section %s",
+		include.TargetSchema, include.TargetRevision, include.Section)}
+}
+
+//getIncludedReferences get references of included schema revisions, with item path as in flattened schema
+func getIncludedReferences(db rdbmstool.DbHandlerProxy, schemaName string, revision int,
+	stack []string) ([]Reference, error) {
+	includes, includeErr := GetIncludes(db, schemaName, revision)
+	if includeErr != nil {
+		return nil, includeErr
+	}
+
+	key := fmt.Sprintf("%s revision %d", schemaName, revision)
+	for _, visiting := range stack {
+		if visiting == key {
+			return nil, ErrInvalidInclude{msg: "cyclic include: " + strings.Join(append(stack, key), " -> ")}
+		}
+	}
+	stack = append(stack, key)
+
+	results := []Reference{}
+	for _, include := range includes {
+		refs, refErr := getDeclaredReferences(db, include.TargetSchema, include.TargetRevision)
+		if refErr != nil {
+			return nil, refErr
+		}
+		includedRefs, includedErr := getIncludedReferences(db, include.TargetSchema, include.TargetRevision, stack)
+		if includedErr != nil {
+			return nil, includedErr
+		}
+
+		for _, ref := range append(refs, includedRefs...) {
+			if include.Section != "" && !strings.HasPrefix(ref.ItemPath, include.Section+"/") {
+				continue
+			}
+
+			ref.ItemPath = joinItemPath(include.ItemPath, ref.ItemPath)
+			results = append(results, ref)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,313 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+	"github.com/guinso/gxschema"
+)
+
+func TestExtractIncludes(t *testing.T) {
+	xmlStr, includes, err := ExtractIncludes(`<dxdoc name="po" revision="0" extends="header" extendsRevision="2">` +
+		`<dxstr name="poNo"></dxstr>` +
+		`<dxinclude schema="party" revision="1" section="requester"></dxinclude>` +
+		`<dxsection name="items" isArray="true"><dxint name="qty"></dxint><dxinclude schema="price" revision="3"/></dxsection>` +
+		`</dxdoc>`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if strings.Contains(xmlStr, "dxinclude") || strings.Contains(xmlStr, "extends") {
+		t.Errorf("expect includes are removed but get: %s", xmlStr)
+	}
+
+	expects := []Include{
+		{ItemPath: "", Position: 0, TargetSchema: "header", TargetRevision: 2},
+		{ItemPath: "", Position: 1, TargetSchema: "party", TargetRevision: 1, Section: "requester"},
+		{ItemPath: "items", Position: 1, TargetSchema: "price", TargetRevision: 3},
+	}
+	if len(includes) != len(expects) {
+		t.Fatalf("expect %d includes but get %d: %+v", len(expects), len(includes), includes)
+		return
+	}
+	for index, expect := range expects {
+		if includes[index] != expect {
+			t.Errorf("expect include %+v but get %+v", expect, includes[index])
+		}
+	}
+
+	backStr, backErr := ApplyIncludes(xmlStr, includes)
+	if backErr != nil {
+		t.Fatal(backErr)
+		return
+	}
+	if !strings.Contains(backStr, `<dxdoc name="po" revision="0"><dxinclude schema="header" revision="2"></dxinclude>`+
+		`<dxstr name="poNo"></dxstr><dxinclude schema="party" revision="1" section="requester"></dxinclude>`) {
+		t.Errorf("expect top level includes are written back but get: %s", backStr)
+	}
+	if !strings.Contains(backStr, `<dxint name="qty"></dxint><dxinclude schema="price" revision="3"></dxinclude></dxsection>`) {
+		t.Errorf("expect section include is written back but get: %s", backStr)
+	}
+
+	if _, _, err = ExtractIncludes(`<dxdoc name="po"><dxinclude schema="header"></dxinclude></dxdoc>`); err == nil {
+		t.Errorf("expect dxinclude without revision is rejected")
+	}
+	if _, _, err = ExtractIncludes(`<dxdoc name="po"><dxinclude revision="1"></dxinclude></dxdoc>`); err == nil {
+		t.Errorf("expect dxinclude without schema is rejected")
+	}
+}
+
+func TestSpliceSchema(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "po",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "poNo"},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+			}},
+		},
+	}
+
+	flatten, err := spliceSchema(schema, map[string][]includedItems{
+		"": {
+			{Include: Include{Position: 1},
+				items: []gxschema.DxItem{gxschema.DxStr{Name: "requester"}}},
+			{Include: Include{Position: 0},
+				items: []gxschema.DxItem{gxschema.DxStr{Name: "company"}, gxschema.DxStr{Name: "date"}}},
+		},
+		"items": {
+			{Include: Include{ItemPath: "items", Position: 1},
+				items: []gxschema.DxItem{gxschema.DxDecimal{Name: "price", Precision: 2}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	names := []string{}
+	for _, item := range flatten.Items {
+		names = append(names, item.GetName())
+	}
+	if strings.Join(names, ",") != "company,date,poNo,requester,items" {
+		t.Errorf("unexpected top level items after splice: %v", names)
+	}
+
+	info, _ := GetItemInfo(flatten.Items[4])
+	if !info.IsArray || len(info.Items) != 2 || info.Items[1].GetName() != "price" {
+		t.Errorf("expect price is spliced after qty but get: %+v", info)
+	}
+	if len(schema.Items) != 2 {
+		t.Errorf("expect original schema is not modified")
+	}
+
+	_, err = spliceSchema(schema, map[string][]includedItems{
+		"": {{items: []gxschema.DxItem{gxschema.DxStr{Name: "poNo"}}}},
+	})
+	if _, ok := err.(ErrInvalidInclude); !ok {
+		t.Errorf("expect name conflict is rejected but get: %v", err)
+	}
+
+	_, err = spliceSchema(schema, map[string][]includedItems{
+		"lines": {{Include: Include{ItemPath: "lines"}, items: []gxschema.DxItem{gxschema.DxStr{Name: "x"}}}},
+	})
+	if _, ok := err.(ErrInvalidInclude); !ok {
+		t.Errorf("expect include into unknown section is rejected but get: %v", err)
+	}
+}
+
+func TestGetIncludedItems(t *testing.T) {
+	header := &gxschema.DxDoc{
+		Name:     "header",
+		Revision: 2,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "company"},
+			gxschema.DxSection{Name: "requester", Items: []gxschema.DxItem{gxschema.DxStr{Name: "name"}}},
+		},
+	}
+
+	items, err := getIncludedItems(header, Include{TargetSchema: "header", TargetRevision: 2})
+	if err != nil || len(items) != 2 {
+		t.Errorf("expect every item is included but get %v, %v", items, err)
+	}
+
+	items, err = getIncludedItems(header, Include{TargetSchema: "header", TargetRevision: 2, Section: "requester"})
+	if err != nil || len(items) != 1 || items[0].GetName() != "requester" {
+		t.Errorf("expect only requester section is included but get %v, %v", items, err)
+	}
+
+	if _, err = getIncludedItems(header, Include{TargetSchema: "header", TargetRevision: 2, Section: "company"}); err == nil {
+		t.Errorf("expect including non section item is rejected")
+	}
+}
+
+func TestSaveIncludes(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	err := SaveIncludes(trx, "pr", -1, []Include{{TargetSchema: "invoice", TargetRevision: 99}})
+	if _, ok := err.(ErrInvalidInclude); !ok {
+		t.Errorf("expect ErrInvalidInclude for unknown revision but get %v", err)
+	}
+
+	err = SaveIncludes(trx, "pr123", -1, []Include{})
+	if _, ok := err.(ErrSchemaInfoNotFound); !ok {
+		t.Errorf("expect ErrSchemaInfoNotFound for unknown schema but get %v", err)
+	}
+
+	includes := []Include{{TargetSchema: "invoice", TargetRevision: 2}}
+	if err := SaveIncludes(trx, "pr", -1, includes); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	saved, savedErr := GetIncludes(trx, "pr", -1)
+	if savedErr != nil {
+		t.Fatal(savedErr)
+		return
+	}
+	if len(saved) != 1 || saved[0] != includes[0] {
+		t.Errorf("expect draft includes %+v but get %+v", includes, saved)
+	}
+}
+
+func TestFlattenSchema(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	//pr revision 2 includes invoice revision 2 before its own items
+	if err := SaveIncludes(trx, "pr", -1, []Include{{TargetSchema: "invoice", TargetRevision: 2}}); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := SaveDraftToNewRevision(trx, "pr"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	schema, schemaErr := GetSchemaByRevision(trx, "pr", 2)
+	if schemaErr != nil {
+		t.Fatal(schemaErr)
+		return
+	}
+	names := []string{}
+	for _, item := range schema.Items {
+		names = append(names, item.GetName())
+	}
+	if strings.Join(names, ",") != "invNo,totalQty,price,qty,pr number" {
+		t.Errorf("expect invoice items spliced before pr items but get %v", names)
+	}
+
+	declared, declaredErr := GetDeclaredSchemaByRevision(trx, "pr", 2)
+	if declaredErr != nil {
+		t.Fatal(declaredErr)
+		return
+	}
+	if len(declared.Items) != 2 {
+		t.Errorf("expect declared definition keeps own items only but get %d items", len(declared.Items))
+	}
+
+	//invoice revision 2 includes pr revision 2 which includes invoice revision 2
+	if err := SaveIncludes(trx, "invoice", 2, []Include{{TargetSchema: "pr", TargetRevision: 2}}); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if _, err := GetSchemaByRevision(trx, "invoice", 2); err == nil {
+		t.Errorf("expect cyclic include is rejected")
+	} else if _, ok := err.(ErrInvalidInclude); !ok {
+		t.Errorf("expect ErrInvalidInclude for cyclic include but get %v", err)
+	}
+}
+
+func TestGetIncludeDependents(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	//pr revision 2 includes invoice, while pr revision 1 doesn't
+	if err := SaveIncludes(trx, "pr", -1, []Include{{TargetSchema: "invoice", TargetRevision: 2}}); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := SaveDraftToNewRevision(trx, "pr"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	for _, include := range []Include{{TargetSchema: "pr", TargetRevision: 1}, {TargetSchema: "pr", TargetRevision: 2}} {
+		name := fmt.Sprintf("pr-include-%d", include.TargetRevision)
+		if err := AddSchemaInfo(trx, name, ""); err != nil {
+			t.Fatal(err)
+			return
+		}
+		revision, addErr := AddSchema(trx, name, &gxschema.DxDoc{
+			Name:  name,
+			Items: []gxschema.DxItem{gxschema.DxStr{Name: "remark"}},
+		}, "")
+		if addErr != nil {
+			t.Fatal(addErr)
+			return
+		}
+		if err := SaveIncludes(trx, name, revision, []Include{include}); err != nil {
+			t.Fatal(err)
+			return
+		}
+	}
+
+	dependents, err := GetIncludeDependents(trx, "invoice")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//pr-include-1 includes pr revision 1, which doesn't depend on invoice
+	expects := []IncludeDependent{
+		{Schema: "pr", Revision: 2, BaseSchema: "invoice", BaseRevision: 2},
+		{Schema: "pr-include-2", Revision: 1, BaseSchema: "pr", BaseRevision: 2},
+	}
+	if len(dependents) != len(expects) {
+		t.Fatalf("expect dependents %+v but get %+v", expects, dependents)
+		return
+	}
+	for index, expect := range expects {
+		if dependents[index] != expect {
+			t.Errorf("expect dependent %+v but get %+v", expect, dependents[index])
+		}
+	}
+}
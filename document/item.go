@@ -0,0 +1,93 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/guinso/gxschema"
+)
+
+//item kinds
+const (
+	ItemInt     = "int"
+	ItemStr     = "str"
+	ItemBool    = "bool"
+	ItemDecimal = "decimal"
+	ItemFile    = "file"
+	ItemSection = "section"
+)
+
+//ItemInfo common attributes of a schema item regardless of its type
+type ItemInfo struct {
+	Name       string
+	Kind       string
+	IsOptional bool
+	IsArray    bool
+	LenLimit   int //0 means no length limit; only for str
+	Precision  int //only for decimal
+	Items      []gxschema.DxItem
+}
+
+//GetItemInfo get common attributes of schema item
+//NOTE: both value (gxschema.DxStr) and pointer (*gxschema.DxStr) items are accepted,
+//parsed schema hold pointer while manually constructed schema usually hold value
+func GetItemInfo(item gxschema.DxItem) (*ItemInfo, error) {
+	switch x := item.(type) {
+	case *gxschema.DxInt:
+		return getIntInfo(x), nil
+	case gxschema.DxInt:
+		return getIntInfo(&x), nil
+	case *gxschema.DxStr:
+		return getStrInfo(x), nil
+	case gxschema.DxStr:
+		return getStrInfo(&x), nil
+	case *gxschema.DxBool:
+		return getBoolInfo(x), nil
+	case gxschema.DxBool:
+		return getBoolInfo(&x), nil
+	case *gxschema.DxDecimal:
+		return getDecimalInfo(x), nil
+	case gxschema.DxDecimal:
+		return getDecimalInfo(&x), nil
+	case *gxschema.DxFile:
+		return getFileInfo(x), nil
+	case gxschema.DxFile:
+		return getFileInfo(&x), nil
+	case *gxschema.DxSection:
+		return getSectionInfo(x), nil
+	case gxschema.DxSection:
+		return getSectionInfo(&x), nil
+	}
+
+	return nil, fmt.Errorf("unrecognize DxItem: %s", item.GetName())
+}
+
+func getIntInfo(item *gxschema.DxInt) *ItemInfo {
+	return &ItemInfo{Name: item.Name, Kind: ItemInt, IsOptional: item.IsOptional, IsArray: item.IsArray}
+}
+
+func getStrInfo(item *gxschema.DxStr) *ItemInfo {
+	info := ItemInfo{Name: item.Name, Kind: ItemStr, IsOptional: item.IsOptional, IsArray: item.IsArray}
+	if item.EnableLenLimit {
+		info.LenLimit = item.LenLimit
+	}
+
+	return &info
+}
+
+func getBoolInfo(item *gxschema.DxBool) *ItemInfo {
+	return &ItemInfo{Name: item.Name, Kind: ItemBool, IsOptional: item.IsOptional, IsArray: item.IsArray}
+}
+
+func getDecimalInfo(item *gxschema.DxDecimal) *ItemInfo {
+	return &ItemInfo{Name: item.Name, Kind: ItemDecimal, IsOptional: item.IsOptional, IsArray: item.IsArray,
+		Precision: item.Precision}
+}
+
+func getFileInfo(item *gxschema.DxFile) *ItemInfo {
+	return &ItemInfo{Name: item.Name, Kind: ItemFile, IsOptional: item.IsOptional, IsArray: item.IsArray}
+}
+
+func getSectionInfo(item *gxschema.DxSection) *ItemInfo {
+	return &ItemInfo{Name: item.Name, Kind: ItemSection, IsOptional: item.IsOptional, IsArray: item.IsArray,
+		Items: item.Items}
+}
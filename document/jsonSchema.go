@@ -0,0 +1,108 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/guinso/gxschema"
+)
+
+//JSONSchemaDraft JSON Schema dialect produced by ToJSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+//ToJSONSchema convert document schema into JSON Schema (draft 2020-12) document
+//	refs (optional) are references of the schema revision, referencing item is exported as uuid string
+//	computed (optional) are computed fields of the schema revision, exported as readOnly and not required
+func ToJSONSchema(schema *gxschema.DxDoc, refs []Reference, computed []ComputedField) ([]byte, error) {
+	refPaths := map[string]Reference{}
+	for _, ref := range refs {
+		refPaths[ref.ItemPath] = ref
+	}
+
+	root, rootErr := getJSONSchemaObject(schema.Items, "", refPaths, GetComputedPaths(computed))
+	if rootErr != nil {
+		return nil, rootErr
+	}
+
+	root["$schema"] = JSONSchemaDraft
+	root["$id"] = getJSONSchemaURL(schema.Name, schema.Revision)
+	root["title"] = schema.Name
+	root["$comment"] = fmt.Sprintf("generated from gxdoc schema %s revision %d", schema.Name, schema.Revision)
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func getJSONSchemaObject(items []gxschema.DxItem, path string, refPaths map[string]Reference,
+	computedPaths map[string]bool) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+
+		itemPath := info.Name
+		if path != "" {
+			itemPath = path + "/" + info.Name
+		}
+
+		var property map[string]interface{}
+		switch info.Kind {
+		case ItemInt:
+			property = map[string]interface{}{"type": "integer"}
+		case ItemStr:
+			property = map[string]interface{}{"type": "string"}
+			if info.LenLimit > 0 {
+				property["maxLength"] = info.LenLimit
+			}
+			if ref, ok := refPaths[itemPath]; ok {
+				property["format"] = "uuid"
+				property["description"] = "ID of " + ref.TargetSchema + " record"
+			}
+		case ItemBool:
+			property = map[string]interface{}{"type": "boolean"}
+		case ItemDecimal:
+			property = map[string]interface{}{
+				"type":       "number",
+				"multipleOf": json.Number(strconv.FormatFloat(math.Pow10(-info.Precision), 'f', -1, 64)),
+			}
+		case ItemFile:
+			property = map[string]interface{}{"description": "file"}
+		case ItemSection:
+			sectionObj, sectionErr := getJSONSchemaObject(info.Items, itemPath, refPaths, computedPaths)
+			if sectionErr != nil {
+				return nil, sectionErr
+			}
+			property = sectionObj
+		}
+
+		if info.IsArray {
+			property = map[string]interface{}{"type": "array", "items": property}
+		}
+
+		if computedPaths[itemPath] {
+			//filled in by server, client must not supply it
+			property["readOnly"] = true
+		} else if !info.IsOptional {
+			required = append(required, info.Name)
+		}
+		properties[info.Name] = property
+	}
+
+	obj := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+
+	return obj, nil
+}
+
+//getJSONSchemaURL get path of JSON Schema export of schema revision
+func getJSONSchemaURL(name string, revision int) string {
+	return fmt.Sprintf("/api/document/schemas/%s/revisions/%d/export.json-schema", url.PathEscape(name), revision)
+}
@@ -0,0 +1,81 @@
+package document
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func TestToJSONSchema(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 2,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxStr{Name: "customer", IsOptional: true},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+			}},
+			gxschema.DxDecimal{Name: "total", Precision: 2},
+		},
+	}
+
+	jsonRaw, err := ToJSONSchema(schema, []Reference{{ItemPath: "customer", TargetSchema: "customer"}},
+		[]ComputedField{{Path: "total", Expression: "1"}})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var result struct {
+		Schema     string   `json:"$schema"`
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Type      string `json:"type"`
+			MaxLength int    `json:"maxLength"`
+			Format    string `json:"format"`
+			ReadOnly  bool   `json:"readOnly"`
+			Items     struct {
+				Type       string   `json:"type"`
+				Required   []string `json:"required"`
+				Properties map[string]struct {
+					Type       string  `json:"type"`
+					MultipleOf float64 `json:"multipleOf"`
+				} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(jsonRaw, &result); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if result.Schema != JSONSchemaDraft {
+		t.Errorf("expect $schema %s but get %s", JSONSchemaDraft, result.Schema)
+	}
+	if len(result.Required) != 2 || result.Required[0] != "invNo" || result.Required[1] != "items" {
+		t.Errorf("expect invNo and items are required but get %v", result.Required)
+	}
+	if result.Properties["invNo"].Type != "string" || result.Properties["invNo"].MaxLength != 10 {
+		t.Errorf("expect invNo is string with maxLength 10")
+	}
+	if result.Properties["customer"].Format != "uuid" {
+		t.Errorf("expect reference item customer has uuid format")
+	}
+	if !result.Properties["total"].ReadOnly || result.Properties["invNo"].ReadOnly {
+		t.Errorf("expect only computed item total is readOnly")
+	}
+
+	items := result.Properties["items"]
+	if items.Type != "array" || items.Items.Type != "object" || len(items.Items.Required) != 2 {
+		t.Errorf("expect items is array of object with 2 required properties")
+	}
+	if items.Items.Properties["qty"].Type != "integer" {
+		t.Errorf("expect qty is integer but get %s", items.Items.Properties["qty"].Type)
+	}
+	if price := items.Items.Properties["price"]; price.Type != "number" || price.MultipleOf != 0.01 {
+		t.Errorf("expect price is number with multipleOf 0.01 but get %s %v", price.Type, price.MultipleOf)
+	}
+}
@@ -0,0 +1,195 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/guinso/gxschema"
+)
+
+//severity of lint issue
+const (
+	LintError   = "error"   //schema is rejected
+	LintWarning = "warning" //schema is accepted but likely a mistake
+)
+
+//rule of lint issue
+const (
+	LintRuleNoItem        = "noItem"
+	LintRuleDuplicateName = "duplicateName"
+	LintRuleInvalidName   = "invalidName"
+	LintRuleReservedName  = "reservedName"
+	LintRuleLenLimit      = "lenLimit"
+	LintRulePrecision     = "precision"
+	LintRuleNestedArray   = "nestedArray"
+)
+
+//limits checked by LintSchema, follow MySQL datatable generated by SQLBuilder
+const (
+	lintMaxNameLength      = 64 //MySQL identifier length
+	lintMaxCharLength      = 255
+	lintMaxPrecision       = 10 //decimal column is decimal(11, precision)
+	lintSuspiciousDecimal  = 6
+	lintMaxNestedArrayPath = 2
+)
+
+//lintReservedNames column names generated by SQLBuilder for every datatable
+var lintReservedNames = []string{"id", "parent_id"}
+
+//LintIssue mistake found in schema definition
+type LintIssue struct {
+	Path     string `json:"path"` //item path, nested section item is joined by '/'; empty means whole schema
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (issue LintIssue) Error() string {
+	if issue.Path == "" {
+		return issue.Message
+	}
+
+	return issue.Path + ": " + issue.Message
+}
+
+//schemaLinter collect lint issues while walking schema items
+type schemaLinter struct {
+	issues []LintIssue
+}
+
+func (linter *schemaLinter) add(path string, rule string, severity string, format string, args ...interface{}) {
+	linter.issues = append(linter.issues, LintIssue{
+		Path: path, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+//LintSchema check schema definition for obvious mistakes:
+//	duplicate item names within a section, names which break SQLBuilder identifiers, lenLimit of 0,
+//	decimal precision beyond datatable column and deeply nested array sections
+func LintSchema(schema *gxschema.DxDoc) []LintIssue {
+	linter := schemaLinter{issues: []LintIssue{}}
+	if len(schema.Items) == 0 {
+		linter.add("", LintRuleNoItem, LintWarning, "schema has no item")
+	}
+	linter.lintItems(schema.Items, "", 0)
+
+	return linter.issues
+}
+
+//HasLintError check any issue is severe enough to reject schema
+func HasLintError(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (linter *schemaLinter) lintItems(items []gxschema.DxItem, path string, arrayDepth int) {
+	names := map[string]string{} //lower case name to declared name
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			linter.add(joinItemPath(path, item.GetName()), LintRuleInvalidName, LintError, "%s", infoErr.Error())
+			continue
+		}
+		itemPath := joinItemPath(path, info.Name)
+
+		if declared, ok := names[strings.ToLower(info.Name)]; ok {
+			if declared == info.Name {
+				linter.add(itemPath, LintRuleDuplicateName, LintError, "item name %s is declared more than once", info.Name)
+			} else {
+				linter.add(itemPath, LintRuleDuplicateName, LintError,
+					"item name %s differs from %s only by letter case, their SQL columns collide", info.Name, declared)
+			}
+		} else {
+			names[strings.ToLower(info.Name)] = info.Name
+		}
+
+		linter.lintName(itemPath, info.Name)
+
+		switch info.Kind {
+		case ItemStr:
+			if enabled, limit := getStrLenLimit(item); enabled && limit <= 0 {
+				linter.add(itemPath, LintRuleLenLimit, LintError, "lenLimit must be greater than 0 but get %d", limit)
+			} else if enabled && limit > lintMaxCharLength {
+				linter.add(itemPath, LintRuleLenLimit, LintError,
+					"lenLimit %d exceeds %d characters supported by char column, use no length limit instead",
+					limit, lintMaxCharLength)
+			}
+		case ItemDecimal:
+			if info.Precision < 0 || info.Precision > lintMaxPrecision {
+				linter.add(itemPath, LintRulePrecision, LintError,
+					"precision must be between 0 and %d but get %d", lintMaxPrecision, info.Precision)
+			} else if info.Precision > lintSuspiciousDecimal {
+				linter.add(itemPath, LintRulePrecision, LintWarning,
+					"precision %d leaves only %d integer digits", info.Precision, 11-info.Precision)
+			}
+		case ItemSection:
+			subDepth := arrayDepth
+			if info.IsArray {
+				subDepth++
+			}
+			if info.IsArray && subDepth == lintMaxNestedArrayPath+1 {
+				linter.add(itemPath, LintRuleNestedArray, LintWarning,
+					"array section is nested within %d array sections, each level becomes another datatable",
+					arrayDepth)
+			}
+			if len(info.Items) == 0 {
+				linter.add(itemPath, LintRuleNoItem, LintWarning, "section has no item")
+			}
+
+			linter.lintItems(info.Items, itemPath, subDepth)
+		}
+	}
+}
+
+//lintName check item name is usable as SQL identifier generated by SQLBuilder
+func (linter *schemaLinter) lintName(path string, name string) {
+	if name == "" {
+		linter.add(path, LintRuleInvalidName, LintError, "item name is empty")
+		return
+	}
+	if len(name) > lintMaxNameLength {
+		linter.add(path, LintRuleInvalidName, LintError,
+			"item name exceeds %d characters allowed by SQL identifier", lintMaxNameLength)
+	}
+
+	for _, reserved := range lintReservedNames {
+		if strings.EqualFold(name, reserved) {
+			linter.add(path, LintRuleReservedName, LintError, "item name %s is reserved for SQL datatable column", name)
+		}
+	}
+
+	needQuote := false
+	for _, char := range name {
+		switch {
+		case char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_'):
+		case char == ' ' || char == '-':
+			needQuote = true
+		default:
+			linter.add(path, LintRuleInvalidName, LintError,
+				"item name contains character %q which can't be used in SQL identifier", char)
+			return
+		}
+	}
+
+	if needQuote || unicode.IsDigit(rune(name[0])) {
+		linter.add(path, LintRuleInvalidName, LintWarning,
+			"item name %s must be quoted as SQL identifier; letters, digits and underscore are preferred", name)
+	}
+}
+
+//getStrLenLimit get length limit of str item, which GetItemInfo only reports when enabled
+func getStrLenLimit(item gxschema.DxItem) (bool, int) {
+	switch x := item.(type) {
+	case *gxschema.DxStr:
+		return x.EnableLenLimit, x.LenLimit
+	case gxschema.DxStr:
+		return x.EnableLenLimit, x.LenLimit
+	}
+
+	return false, 0
+}
@@ -0,0 +1,84 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func TestLintSchema(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 0},
+			gxschema.DxStr{Name: "invNo"},
+			gxschema.DxStr{Name: "InvNo"},
+			gxschema.DxStr{Name: "memo", EnableLenLimit: true, LenLimit: 256},
+			gxschema.DxInt{Name: "id"},
+			gxschema.DxStr{Name: "remark`s"},
+			gxschema.DxStr{Name: "total qty"},
+			gxschema.DxDecimal{Name: "rate", Precision: 12},
+			gxschema.DxDecimal{Name: "ratio", Precision: 8},
+			gxschema.DxSection{Name: "a", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxSection{Name: "b", IsArray: true, Items: []gxschema.DxItem{
+					gxschema.DxSection{Name: "c", IsArray: true, Items: []gxschema.DxItem{}},
+				}},
+			}},
+		},
+	}
+
+	issues := LintSchema(schema)
+
+	expects := []LintIssue{
+		{Path: "invNo", Rule: LintRuleLenLimit, Severity: LintError},
+		{Path: "invNo", Rule: LintRuleDuplicateName, Severity: LintError},
+		{Path: "InvNo", Rule: LintRuleDuplicateName, Severity: LintError},
+		{Path: "memo", Rule: LintRuleLenLimit, Severity: LintError},
+		{Path: "id", Rule: LintRuleReservedName, Severity: LintError},
+		{Path: "remark`s", Rule: LintRuleInvalidName, Severity: LintError},
+		{Path: "total qty", Rule: LintRuleInvalidName, Severity: LintWarning},
+		{Path: "rate", Rule: LintRulePrecision, Severity: LintError},
+		{Path: "ratio", Rule: LintRulePrecision, Severity: LintWarning},
+		{Path: "a/b/c", Rule: LintRuleNestedArray, Severity: LintWarning},
+		{Path: "a/b/c", Rule: LintRuleNoItem, Severity: LintWarning},
+	}
+	for _, expect := range expects {
+		found := false
+		for _, issue := range issues {
+			if issue.Path == expect.Path && issue.Rule == expect.Rule && issue.Severity == expect.Severity {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expect %s %s on %s but get: %+v", expect.Severity, expect.Rule, expect.Path, issues)
+		}
+	}
+
+	if !HasLintError(issues) {
+		t.Errorf("expect lint error is reported")
+	}
+}
+
+func TestLintSchemaClean(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 20},
+			gxschema.DxDecimal{Name: "price", Precision: 2},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+			}},
+		},
+	}
+
+	if issues := LintSchema(schema); len(issues) != 0 {
+		t.Errorf("expect no lint issue but get: %+v", issues)
+	}
+
+	if issues := LintSchema(&gxschema.DxDoc{Name: "empty"}); len(issues) != 1 || issues[0].Rule != LintRuleNoItem {
+		t.Errorf("expect empty schema is warned but get: %+v", issues)
+	}
+}
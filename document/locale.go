@@ -0,0 +1,395 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+)
+
+var localeTagPattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+//localizableRules validation rules which message can be localized
+var localizableRules = []string{RuleRequired, RuleType, RuleLenLimit, RulePrecision, RuleArray, RuleReference,
+	RuleComputed}
+
+//ItemLocale display metadata of schema item in one language
+type ItemLocale struct {
+	Path        string            `json:"item"` //item path, nested section item is joined by '/'; empty means schema itself
+	Label       string            `json:"label,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Messages    map[string]string `json:"messages,omitempty"` //validation rule to message, {label} is replaced by label
+}
+
+//SchemaLocale display metadata of schema revision in one language
+type SchemaLocale struct {
+	Locale string       `json:"locale"` //language tag; e.g. ms, zh-CN
+	Items  []ItemLocale `json:"items"`
+}
+
+//GetItem get metadata of item path, nil if not declared
+func (locale *SchemaLocale) GetItem(path string) *ItemLocale {
+	for index := range locale.Items {
+		if locale.Items[index].Path == path {
+			return &locale.Items[index]
+		}
+	}
+
+	return nil
+}
+
+//ParseSchemaLocalesFromJSON convert JSON array into list of SchemaLocale
+func ParseSchemaLocalesFromJSON(jsonStr string) ([]SchemaLocale, error) {
+	locales := []SchemaLocale{}
+	if err := json.Unmarshal([]byte(jsonStr), &locales); err != nil {
+		return nil, fmt.Errorf("invalid locales JSON: %s", err.Error())
+	}
+
+	tags := map[string]bool{}
+	for _, locale := range locales {
+		if !localeTagPattern.MatchString(locale.Locale) {
+			return nil, fmt.Errorf("invalid locale tag: '%s'", locale.Locale)
+		}
+		if tags[strings.ToLower(locale.Locale)] {
+			return nil, fmt.Errorf("locale %s is declared more than once", locale.Locale)
+		}
+		tags[strings.ToLower(locale.Locale)] = true
+
+		paths := map[string]bool{}
+		for _, item := range locale.Items {
+			if paths[item.Path] {
+				return nil, fmt.Errorf("item %s is declared more than once in locale %s", item.Path, locale.Locale)
+			}
+			paths[item.Path] = true
+		}
+	}
+
+	return locales, nil
+}
+
+//ValidateSchemaLocales check every localized item exists in schema and every message is of known rule
+func ValidateSchemaLocales(schema *gxschema.DxDoc, locales []SchemaLocale) error {
+	for _, locale := range locales {
+		for _, item := range locale.Items {
+			if item.Path != "" {
+				info, infoErr := findItemInfo(schema.Items, strings.Split(item.Path, "/"))
+				if infoErr != nil {
+					return infoErr
+				}
+				if info == nil {
+					return ErrInvalidLocale{msg: fmt.Sprintf("item %s of locale %s not found in %s",
+						item.Path, locale.Locale, schema.Name)}
+				}
+			}
+
+			for rule := range item.Messages {
+				if !isLocalizableRule(rule) {
+					return ErrInvalidLocale{msg: fmt.Sprintf("message of unknown rule %s at item %s of locale %s",
+						rule, item.Path, locale.Locale)}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func isLocalizableRule(rule string) bool {
+	for _, localizable := range localizableRules {
+		if rule == localizable {
+			return true
+		}
+	}
+
+	return false
+}
+
+//GetSchemaLocales get localized metadata of schema revision (use -1 for draft), ordered by locale tag
+func GetSchemaLocales(db rdbmstool.DbHandlerProxy, schemaName string, revision int) ([]SchemaLocale, error) {
+	rows, rowsErr := db.Query(`SELECT a.locale, a.metadata FROM doc_schema_locale a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.revision = ?
+	ORDER BY a.locale`, schemaName, revision)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	locales := []SchemaLocale{}
+	for rows.Next() {
+		locale := SchemaLocale{}
+		var metadata string
+		if err := rows.Scan(&locale.Locale, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+		if err := json.Unmarshal([]byte(metadata), &locale.Items); err != nil {
+			return nil, fmt.Errorf("invalid %s locale %s in database: %s", schemaName, locale.Locale, err.Error())
+		}
+
+		locales = append(locales, locale)
+	}
+
+	return locales, nil
+}
+
+//GetSchemaLocale get localized metadata of schema revision (use -1 for draft) best matching Accept-Language header
+//	return nil if no locale declared matches
+func GetSchemaLocale(db rdbmstool.DbHandlerProxy, schemaName string, revision int,
+	acceptLanguage string) (*SchemaLocale, error) {
+	if strings.TrimSpace(acceptLanguage) == "" {
+		return nil, nil
+	}
+
+	locales, localeErr := GetSchemaLocales(db, schemaName, revision)
+	if localeErr != nil {
+		return nil, localeErr
+	}
+
+	tags := []string{}
+	for _, locale := range locales {
+		tags = append(tags, locale.Locale)
+	}
+
+	matched := MatchLocale(acceptLanguage, tags)
+	for index := range locales {
+		if locales[index].Locale == matched {
+			return &locales[index], nil
+		}
+	}
+
+	return nil, nil
+}
+
+//SaveDraftSchemaLocales declare or overwrite localized metadata of schema draft
+//locales are released together with draft, see SaveDraftToNewRevision
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrDraftNotFound error will return if schema has no draft
+//NOTE: ErrInvalidLocale error will return if any localized item not match with draft schema
+func SaveDraftSchemaLocales(db rdbmstool.DbHandlerProxy, schemaName string, locales []SchemaLocale) error {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	draft, draftErr := GetDraftSchema(db, schemaName)
+	if draftErr != nil {
+		return draftErr
+	}
+	if draft == nil {
+		return ErrDraftNotFound{msg: fmt.Sprintf("no draft found for %s", schemaName)}
+	}
+
+	if err := ValidateSchemaLocales(draft, locales); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM doc_schema_locale WHERE schema_id = ? AND revision = -1`,
+		schemaInfo.ID); err != nil {
+		return fmt.Errorf("failed to clear %s locales from database: %s", schemaName, err.Error())
+	}
+
+	for _, locale := range locales {
+		metadata, jsonErr := json.Marshal(locale.Items)
+		if jsonErr != nil {
+			return jsonErr
+		}
+
+		_, dbErr := db.Exec(`INSERT INTO doc_schema_locale (schema_id, revision, locale, metadata)
+		VALUES (?,-1,?,?)`, schemaInfo.ID, locale.Locale, string(metadata))
+		if dbErr != nil {
+			return fmt.Errorf("failed to save %s locale %s into database: %s",
+				schemaName, locale.Locale, dbErr.Error())
+		}
+	}
+
+	return nil
+}
+
+//acceptLanguageRange language range of Accept-Language header
+type acceptLanguageRange struct {
+	tag     string
+	quality float64
+}
+
+//MatchLocale choose locale tag best matching Accept-Language header; e.g. "ms-MY,ms;q=0.9,en;q=0.8"
+//	language range matches same tag, then tag of same primary language; return empty string if none matches
+func MatchLocale(acceptLanguage string, tags []string) string {
+	ranges := []acceptLanguageRange{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		languageRange := acceptLanguageRange{tag: strings.ToLower(strings.TrimSpace(fields[0])), quality: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if quality, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					languageRange.quality = quality
+				}
+			}
+		}
+		if languageRange.tag != "" && languageRange.tag != "*" && languageRange.quality > 0 {
+			ranges = append(ranges, languageRange)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, languageRange := range ranges {
+		for _, tag := range tags {
+			if strings.ToLower(tag) == languageRange.tag {
+				return tag
+			}
+		}
+
+		primary := strings.Split(languageRange.tag, "-")[0]
+		for _, tag := range tags {
+			if strings.Split(strings.ToLower(tag), "-")[0] == primary {
+				return tag
+			}
+		}
+	}
+
+	return ""
+}
+
+//LocalizeViolations replace violation message with localized message of offending item, and fill in its label
+//	violations are returned as is if locale is nil
+func LocalizeViolations(schema *gxschema.DxDoc, locale *SchemaLocale, violations []Violation) []Violation {
+	if locale == nil {
+		return violations
+	}
+
+	results := make([]Violation, 0, len(violations))
+	for _, violation := range violations {
+		itemPath, found := getItemPathOfPointer(schema.Items, violation.Path)
+		item := locale.GetItem(itemPath)
+		if !found || item == nil {
+			results = append(results, violation)
+			continue
+		}
+
+		violation.Label = item.Label
+		if message, ok := item.Messages[violation.Rule]; ok && message != "" {
+			violation.Message = strings.Replace(message, "{label}", item.Label, -1)
+		}
+		results = append(results, violation)
+	}
+
+	return results
+}
+
+//getItemPathOfPointer convert JSON pointer of data value into schema item path; e.g. /items/0/qty become items/qty
+func getItemPathOfPointer(items []gxschema.DxItem, pointer string) (string, bool) {
+	if pointer == "" || !strings.HasPrefix(pointer, "/") {
+		return "", false
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	names := []string{}
+	for len(tokens) > 0 {
+		name := strings.Replace(strings.Replace(tokens[0], "~1", "/", -1), "~0", "~", -1)
+		tokens = tokens[1:]
+
+		var matched *ItemInfo
+		for _, item := range items {
+			info, infoErr := GetItemInfo(item)
+			if infoErr == nil && info.Name == name {
+				matched = info
+				break
+			}
+		}
+		if matched == nil {
+			return "", false
+		}
+		names = append(names, name)
+
+		if matched.IsArray && len(tokens) > 0 {
+			tokens = tokens[1:] //array index
+		}
+		items = matched.Items
+	}
+
+	return strings.Join(names, "/"), true
+}
+
+//ApplyLocaleToXML write label and description of locale into XML definition as item attributes,
+//and locale tag as locale attribute of dxdoc
+func ApplyLocaleToXML(xmlStr string, locale *SchemaLocale) (string, error) {
+	if locale == nil {
+		return xmlStr, nil
+	}
+
+	path := []string{}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			var item *ItemLocale
+			if element.Name.Local == "dxdoc" {
+				item = locale.GetItem("")
+				element.Attr = append(removeXMLAttr(element.Attr, "locale"),
+					xml.Attr{Name: xml.Name{Local: "locale"}, Value: locale.Locale})
+			} else if strings.HasPrefix(element.Name.Local, "dx") {
+				itemPath := strings.Join(append(path, getXMLAttr(element, "name")), "/")
+				item = locale.GetItem(itemPath)
+				if element.Name.Local == "dxsection" {
+					path = append(path, getXMLAttr(element, "name"))
+				}
+			}
+
+			if item != nil && item.Label != "" {
+				element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: "label"}, Value: item.Label})
+			}
+			if item != nil && item.Description != "" {
+				element.Attr = append(element.Attr,
+					xml.Attr{Name: xml.Name{Local: "description"}, Value: item.Description})
+			}
+			token = element
+		case xml.EndElement:
+			if element.Name.Local == "dxsection" && len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
+
+//removeLocaleXMLAttr remove attributes written by ApplyLocaleToXML, which are informative only
+func removeLocaleXMLAttr(element xml.StartElement) xml.StartElement {
+	if element.Name.Local == "dxdoc" {
+		element.Attr = removeXMLAttr(element.Attr, "locale")
+	}
+	element.Attr = removeXMLAttr(removeXMLAttr(element.Attr, "label"), "description")
+
+	return element
+}
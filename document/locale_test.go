@@ -0,0 +1,195 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+	"github.com/guinso/gxschema"
+)
+
+func getLocaleTestSchema() *gxschema.DxDoc {
+	return &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo"},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+			}},
+		},
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	tags := []string{"en", "ms-MY", "zh-CN"}
+
+	cases := map[string]string{
+		"":                         "",
+		"fr":                       "",
+		"ms-MY":                    "ms-MY",
+		"ms":                       "ms-MY",
+		"zh-cn,en;q=0.5":           "zh-CN",
+		"en;q=0.5,zh-TW;q=0.8":     "zh-CN",
+		"fr,en;q=0.1":              "en",
+		"en;q=0,ms":                "ms-MY",
+		"de, *;q=0.1":              "",
+		"ms-SG;q=0.9, en-GB;q=0.8": "ms-MY",
+	}
+	for acceptLanguage, expect := range cases {
+		if result := MatchLocale(acceptLanguage, tags); result != expect {
+			t.Errorf("expect '%s' match %s but get %s", acceptLanguage, expect, result)
+		}
+	}
+}
+
+func TestParseSchemaLocalesFromJSON(t *testing.T) {
+	locales, err := ParseSchemaLocalesFromJSON(`[{"locale":"ms","items":[
+		{"item":"","label":"Invois"},
+		{"item":"items/qty","label":"Kuantiti","messages":{"required":"{label} diperlukan"}}]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locales) != 1 || len(locales[0].Items) != 2 || locales[0].GetItem("items/qty").Label != "Kuantiti" {
+		t.Errorf("unexpected locales: %v", locales)
+	}
+	if err := ValidateSchemaLocales(getLocaleTestSchema(), locales); err != nil {
+		t.Error(err)
+	}
+
+	invalids := []string{
+		`{"locale":"ms"}`,
+		`[{"locale":"ms_MY","items":[]}]`,
+		`[{"locale":"ms","items":[]},{"locale":"MS","items":[]}]`,
+		`[{"locale":"ms","items":[{"item":"invNo"},{"item":"invNo"}]}]`,
+	}
+	for _, invalid := range invalids {
+		if _, err := ParseSchemaLocalesFromJSON(invalid); err == nil {
+			t.Errorf("expect error on %s", invalid)
+		}
+	}
+
+	unknowns := []string{
+		`[{"locale":"ms","items":[{"item":"items/price","label":"Harga"}]}]`,
+		`[{"locale":"ms","items":[{"item":"invNo","messages":{"custom":"salah"}}]}]`,
+	}
+	for _, unknown := range unknowns {
+		locales, _ := ParseSchemaLocalesFromJSON(unknown)
+		if err := ValidateSchemaLocales(getLocaleTestSchema(), locales); err == nil {
+			t.Errorf("expect error on %s", unknown)
+		} else if _, ok := err.(ErrInvalidLocale); !ok {
+			t.Errorf("expect ErrInvalidLocale but get %T", err)
+		}
+	}
+}
+
+func TestLocalizeViolations(t *testing.T) {
+	locale := &SchemaLocale{Locale: "ms", Items: []ItemLocale{
+		{Path: "invNo", Label: "No. Invois"},
+		{Path: "items/qty", Label: "Kuantiti", Messages: map[string]string{RuleType: "{label} mesti nombor bulat"}},
+	}}
+	violations := []Violation{
+		{Path: "/invNo", Rule: RuleRequired, Message: "invNo is required"},
+		{Path: "/items/1/qty", Rule: RuleType, Message: "qty must be int"},
+		{Path: "/items/1/price", Rule: RuleSchema, Message: "price is not declared"},
+		{Path: "", Rule: RuleFormat, Message: "invalid JSON"},
+	}
+
+	results := LocalizeViolations(getLocaleTestSchema(), locale, violations)
+	if len(results) != len(violations) {
+		t.Fatalf("expect %d violations but get %d", len(violations), len(results))
+	}
+	if results[0].Label != "No. Invois" || results[0].Message != "invNo is required" {
+		t.Errorf("unexpected localized violation: %v", results[0])
+	}
+	if results[1].Label != "Kuantiti" || results[1].Message != "Kuantiti mesti nombor bulat" {
+		t.Errorf("unexpected localized violation: %v", results[1])
+	}
+	for _, result := range results[2:] {
+		if result.Label != "" {
+			t.Errorf("expect violation not localized: %v", result)
+		}
+	}
+
+	if results := LocalizeViolations(getLocaleTestSchema(), nil, violations); results[1].Message != "qty must be int" {
+		t.Errorf("expect violation unchanged without locale: %v", results[1])
+	}
+}
+
+func TestApplyLocaleToXML(t *testing.T) {
+	locale := &SchemaLocale{Locale: "ms", Items: []ItemLocale{
+		{Path: "", Label: "Invois"},
+		{Path: "items/qty", Label: "Kuantiti", Description: "bilangan unit"},
+	}}
+
+	xmlStr, err := ApplyLocaleToXML(`<dxdoc name="invoice" revision="1">`+
+		`<dxstr name="invNo"></dxstr>`+
+		`<dxsection name="items" isArray="true"><dxint name="qty"></dxint></dxsection>`+
+		`</dxdoc>`, locale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []string{
+		`<dxdoc name="invoice" revision="1" locale="ms" label="Invois">`,
+		`<dxstr name="invNo"></dxstr>`,
+		`<dxint name="qty" label="Kuantiti" description="bilangan unit">`,
+	}
+	for _, expect := range expects {
+		if !strings.Contains(xmlStr, expect) {
+			t.Errorf("expect %s in %s", expect, xmlStr)
+		}
+	}
+}
+
+func TestReleasedSchemaLocales(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	locales := []SchemaLocale{
+		{Locale: "ms", Items: []ItemLocale{{Path: "qty", Label: "Kuantiti"}}},
+	}
+	if err := SaveDraftSchemaLocales(trx, "pr", locales); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if err := SaveDraftToNewRevision(trx, "pr"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	released, releasedErr := GetSchemaLocales(trx, "pr", 2)
+	if releasedErr != nil {
+		t.Fatal(releasedErr)
+		return
+	}
+	if len(released) != 1 || released[0].Locale != "ms" || released[0].GetItem("qty") == nil ||
+		released[0].GetItem("qty").Label != "Kuantiti" {
+		t.Fatalf("expect released revision 2 carry draft locales but get %+v", released)
+	}
+
+	if draft, _ := GetSchemaLocales(trx, "pr", -1); len(draft) != 0 {
+		t.Errorf("expect draft locales are moved into released revision")
+	}
+
+	locale, localeErr := GetSchemaLocale(trx, "pr", 2, "ms-MY,en;q=0.8")
+	if localeErr != nil {
+		t.Fatal(localeErr)
+		return
+	}
+	if locale == nil || locale.Locale != "ms" {
+		t.Errorf("expect ms locale of revision 2 match Accept-Language but get %+v", locale)
+	}
+}
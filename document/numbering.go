@@ -0,0 +1,350 @@
+package document
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guinso/rdbmstool"
+)
+
+//numbering sequence reset period
+const (
+	ResetNever   = "never"
+	ResetYearly  = "yearly"
+	ResetMonthly = "monthly"
+	ResetDaily   = "daily"
+)
+
+var numberingTokenPattern = regexp.MustCompile(`\{(YYYY|YY|MM|DD|SEQ(:[1-9][0-9]?)?)\}`)
+
+//NumberingSequence human readable document number format of a schema
+//
+//	Pattern accept literal text with tokens; e.g. INV-{YYYY}-{SEQ:6} produce INV-2026-000123
+//	{YYYY} 4 digits year, {YY} 2 digits year, {MM} month, {DD} day,
+//	{SEQ} running number, {SEQ:n} running number zero-padded into n digits
+type NumberingSequence struct {
+	Pattern string `json:"pattern"`
+	Reset   string `json:"reset"` //never, yearly, monthly or daily
+}
+
+//NumberingGapReport result of auditing allocated numbers of a period
+type NumberingGapReport struct {
+	Period         string `json:"period"`
+	LastValue      int    `json:"lastValue"`
+	AllocatedCount int    `json:"allocated"`
+	Missing        []int  `json:"missing"`
+}
+
+//ParseNumberingFromJSON convert JSON string into NumberingSequence and check it is well defined
+func ParseNumberingFromJSON(jsonStr string) (*NumberingSequence, error) {
+	sequence := NumberingSequence{}
+	if err := json.Unmarshal([]byte(jsonStr), &sequence); err != nil {
+		return nil, fmt.Errorf("invalid numbering JSON: %s", err.Error())
+	}
+
+	if sequence.Reset == "" {
+		sequence.Reset = ResetNever
+	}
+
+	if err := sequence.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &sequence, nil
+}
+
+//Validate check numbering pattern and reset period
+func (sequence *NumberingSequence) Validate() error {
+	switch sequence.Reset {
+	case ResetNever, ResetYearly, ResetMonthly, ResetDaily:
+	default:
+		return fmt.Errorf("invalid numbering reset period '%s'", sequence.Reset)
+	}
+
+	seqCount := 0
+	for _, token := range numberingTokenPattern.FindAllString(sequence.Pattern, -1) {
+		if strings.HasPrefix(token, "{SEQ") {
+			seqCount++
+		}
+	}
+	if seqCount != 1 {
+		return fmt.Errorf("numbering pattern must contain exactly one {SEQ} token")
+	}
+
+	if strings.Count(sequence.Pattern, "{") != len(numberingTokenPattern.FindAllString(sequence.Pattern, -1)) {
+		return fmt.Errorf("numbering pattern contains unknown token: %s", sequence.Pattern)
+	}
+
+	//number of every period must be distinguishable, otherwise reset counter repeat numbers
+	hasYear := strings.Contains(sequence.Pattern, "{YYYY}") || strings.Contains(sequence.Pattern, "{YY}")
+	hasMonth := strings.Contains(sequence.Pattern, "{MM}")
+	hasDay := strings.Contains(sequence.Pattern, "{DD}")
+	switch {
+	case sequence.Reset == ResetYearly && !hasYear:
+		return fmt.Errorf("numbering pattern reset yearly must contain {YYYY} or {YY} token")
+	case sequence.Reset == ResetMonthly && !(hasYear && hasMonth):
+		return fmt.Errorf("numbering pattern reset monthly must contain {YYYY} or {YY} and {MM} tokens")
+	case sequence.Reset == ResetDaily && !(hasYear && hasMonth && hasDay):
+		return fmt.Errorf("numbering pattern reset daily must contain {YYYY} or {YY}, {MM} and {DD} tokens")
+	}
+
+	return nil
+}
+
+//matchPattern build regular expression which match any number produced by numbering pattern
+func (sequence *NumberingSequence) matchPattern() *regexp.Regexp {
+	expr := "^"
+	last := 0
+	for _, loc := range numberingTokenPattern.FindAllStringIndex(sequence.Pattern, -1) {
+		expr += regexp.QuoteMeta(sequence.Pattern[last:loc[0]])
+
+		switch token := sequence.Pattern[loc[0]:loc[1]]; token {
+		case "{YYYY}":
+			expr += `[0-9]{4}`
+		case "{YY}", "{MM}", "{DD}":
+			expr += `[0-9]{2}`
+		case "{SEQ}":
+			expr += `[0-9]+`
+		default:
+			expr += `[0-9]{` + token[5:len(token)-1] + `,}` //{SEQ:n}
+		}
+
+		last = loc[1]
+	}
+	expr += regexp.QuoteMeta(sequence.Pattern[last:]) + "$"
+
+	return regexp.MustCompile(expr)
+}
+
+//PeriodKey get counter period of specified time based on reset period
+func (sequence *NumberingSequence) PeriodKey(now time.Time) string {
+	switch sequence.Reset {
+	case ResetYearly:
+		return now.Format("2006")
+	case ResetMonthly:
+		return now.Format("2006-01")
+	case ResetDaily:
+		return now.Format("2006-01-02")
+	}
+
+	return ""
+}
+
+//Format produce document number of specified running number and time
+func (sequence *NumberingSequence) Format(seq int, now time.Time) string {
+	return numberingTokenPattern.ReplaceAllStringFunc(sequence.Pattern, func(token string) string {
+		switch token {
+		case "{YYYY}":
+			return now.Format("2006")
+		case "{YY}":
+			return now.Format("06")
+		case "{MM}":
+			return now.Format("01")
+		case "{DD}":
+			return now.Format("02")
+		case "{SEQ}":
+			return strconv.Itoa(seq)
+		}
+
+		width, _ := strconv.Atoi(token[5 : len(token)-1]) //{SEQ:n}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+//JSON export to JSON string
+func (sequence *NumberingSequence) JSON() string {
+	jsonRaw, _ := json.Marshal(sequence)
+
+	return string(jsonRaw)
+}
+
+//GetNumbering get numbering sequence of document schema
+//return nil if schema has no numbering sequence
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func GetNumbering(db rdbmstool.DbHandlerProxy, schemaName string) (*NumberingSequence, error) {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	if schemaInfo == nil {
+		return nil, ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	return getNumberingByID(db, schemaInfo.ID)
+}
+
+func getNumberingByID(db rdbmstool.DbHandlerProxy, schemaID string) (*NumberingSequence, error) {
+	row := db.QueryRow(`SELECT pattern, reset_period FROM doc_schema_numbering WHERE schema_id = ?`, schemaID)
+
+	sequence := NumberingSequence{}
+	if err := row.Scan(&sequence.Pattern, &sequence.Reset); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch numbering sequence from database: %s", err.Error())
+	}
+
+	return &sequence, nil
+}
+
+//SaveNumbering declare or overwrite numbering sequence of document schema
+//NOTE: running counter is kept; changing reset period start counting on new period key,
+//so new sequence is rejected if any allocated number can be produced by it again
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrNumberingConflict error will return if new sequence may repeat allocated number
+func SaveNumbering(db rdbmstool.DbHandlerProxy, schemaName string, sequence *NumberingSequence) error {
+	if err := sequence.Validate(); err != nil {
+		return err
+	}
+
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	oldSequence, oldErr := getNumberingByID(db, schemaInfo.ID)
+	if oldErr != nil {
+		return oldErr
+	}
+	if oldSequence != nil && *oldSequence != *sequence {
+		if err := checkNumberingConflict(db, schemaInfo.ID, sequence); err != nil {
+			return err
+		}
+	}
+
+	_, dbErr := db.Exec(`INSERT INTO doc_schema_numbering (schema_id, pattern, reset_period) VALUES (?,?,?)
+	ON DUPLICATE KEY UPDATE pattern = VALUES(pattern), reset_period = VALUES(reset_period)`,
+		schemaInfo.ID, sequence.Pattern, sequence.Reset)
+	if dbErr != nil {
+		return fmt.Errorf("failed to save numbering sequence of %s into database: %s", schemaName, dbErr.Error())
+	}
+
+	return nil
+}
+
+//checkNumberingConflict make sure none of allocated numbers of schema match new numbering sequence
+func checkNumberingConflict(db rdbmstool.DbHandlerProxy, schemaID string, sequence *NumberingSequence) error {
+	rows, rowsErr := db.Query(`SELECT number FROM doc_numbering_allocation WHERE schema_id = ?`, schemaID)
+	if rowsErr != nil {
+		return fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	matcher := sequence.matchPattern()
+	for rows.Next() {
+		var number string
+		if err := rows.Scan(&number); err != nil {
+			return fmt.Errorf("failed to fetch allocated document number from database: %s", err.Error())
+		}
+
+		if matcher.MatchString(number) {
+			return ErrNumberingConflict{msg: fmt.Sprintf(
+				"numbering pattern %s may repeat allocated number %s", sequence.Pattern, number)}
+		}
+	}
+
+	return rows.Err()
+}
+
+//allocateNumber reserve next running number of schema for record
+//return empty string if schema has no numbering sequence
+//NOTE: must run within same transaction which create the record,
+//so rolled back record will release its number and no gap is left behind
+func allocateNumber(db rdbmstool.DbHandlerProxy, schemaID string, recordID string, now time.Time) (string, error) {
+	sequence, seqErr := getNumberingByID(db, schemaID)
+	if seqErr != nil {
+		return "", seqErr
+	}
+	if sequence == nil {
+		return "", nil
+	}
+
+	periodKey := sequence.PeriodKey(now)
+
+	//upsert lock the counter row until transaction end
+	_, upsertErr := db.Exec(`INSERT INTO doc_numbering_counter (schema_id, period_key, last_value) VALUES (?,?,1)
+	ON DUPLICATE KEY UPDATE last_value = last_value + 1`, schemaID, periodKey)
+	if upsertErr != nil {
+		return "", fmt.Errorf("failed to allocate document number: %s", upsertErr.Error())
+	}
+
+	row := db.QueryRow(`SELECT last_value FROM doc_numbering_counter WHERE schema_id = ? AND period_key = ?`,
+		schemaID, periodKey)
+	var seq int
+	if err := row.Scan(&seq); err != nil {
+		return "", fmt.Errorf("failed to fetch allocated document number: %s", err.Error())
+	}
+
+	number := sequence.Format(seq, now)
+
+	_, insertErr := db.Exec(`INSERT INTO doc_numbering_allocation (schema_id, period_key, seq, number, record_id, created)
+	VALUES (?,?,?,?,?,?)`, schemaID, periodKey, seq, number, recordID, now.Format(timeFormat))
+	if insertErr != nil {
+		return "", fmt.Errorf("failed to register allocated document number %s: %s", number, insertErr.Error())
+	}
+
+	return number, nil
+}
+
+//GetNumberingGaps audit allocated numbers of every period and report missing running numbers
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func GetNumberingGaps(db rdbmstool.DbHandlerProxy, schemaName string) ([]NumberingGapReport, error) {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	if schemaInfo == nil {
+		return nil, ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	rows, rowsErr := db.Query(`SELECT a.period_key, a.last_value, b.seq
+	FROM doc_numbering_counter a
+	LEFT JOIN doc_numbering_allocation b ON a.schema_id = b.schema_id AND a.period_key = b.period_key
+	WHERE a.schema_id = ?
+	ORDER BY a.period_key, b.seq`, schemaInfo.ID)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	reports := []NumberingGapReport{}
+	allocated := map[string]map[int]bool{}
+	for rows.Next() {
+		var tmpPeriod string
+		var tmpLast int
+		var tmpSeq sql.NullInt64
+		if err := rows.Scan(&tmpPeriod, &tmpLast, &tmpSeq); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		if _, ok := allocated[tmpPeriod]; !ok {
+			allocated[tmpPeriod] = map[int]bool{}
+			reports = append(reports, NumberingGapReport{Period: tmpPeriod, LastValue: tmpLast})
+		}
+		if tmpSeq.Valid {
+			allocated[tmpPeriod][int(tmpSeq.Int64)] = true
+		}
+	}
+
+	for index := range reports {
+		report := &reports[index]
+		report.AllocatedCount = len(allocated[report.Period])
+		report.Missing = []int{}
+		for seq := 1; seq <= report.LastValue; seq++ {
+			if !allocated[report.Period][seq] {
+				report.Missing = append(report.Missing, seq)
+			}
+		}
+	}
+
+	return reports, nil
+}
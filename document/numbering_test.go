@@ -0,0 +1,166 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+func TestNumberingSequenceFormat(t *testing.T) {
+	sequence, err := ParseNumberingFromJSON(`{"pattern": "INV-{YYYY}-{SEQ:6}", "reset": "yearly"}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	now := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	if number := sequence.Format(123, now); strings.Compare(number, "INV-2026-000123") != 0 {
+		t.Errorf("expect INV-2026-000123 but get %s", number)
+	}
+
+	if key := sequence.PeriodKey(now); strings.Compare(key, "2026") != 0 {
+		t.Errorf("expect period key 2026 but get %s", key)
+	}
+
+	sequence = &NumberingSequence{Pattern: "PO{YY}{MM}{DD}/{SEQ}", Reset: ResetDaily}
+	if number := sequence.Format(7, now); strings.Compare(number, "PO260307/7") != 0 {
+		t.Errorf("expect PO260307/7 but get %s", number)
+	}
+	if key := sequence.PeriodKey(now); strings.Compare(key, "2026-03-07") != 0 {
+		t.Errorf("expect period key 2026-03-07 but get %s", key)
+	}
+}
+
+func TestNumberingSequenceValidate(t *testing.T) {
+	if _, err := ParseNumberingFromJSON(`{"pattern": "INV-{YYYY}"}`); err == nil {
+		t.Errorf("expect pattern without {SEQ} is rejected")
+	}
+
+	if _, err := ParseNumberingFromJSON(`{"pattern": "{SEQ}-{SEQ}"}`); err == nil {
+		t.Errorf("expect pattern with two {SEQ} is rejected")
+	}
+
+	if _, err := ParseNumberingFromJSON(`{"pattern": "INV-{YEAR}-{SEQ}"}`); err == nil {
+		t.Errorf("expect unknown token is rejected")
+	}
+
+	if _, err := ParseNumberingFromJSON(`{"pattern": "INV-{SEQ}", "reset": "weekly"}`); err == nil {
+		t.Errorf("expect unknown reset period is rejected")
+	}
+
+	sequence, err := ParseNumberingFromJSON(`{"pattern": "INV-{SEQ}"}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if sequence.Reset != ResetNever {
+		t.Errorf("expect default reset period is never but get %s", sequence.Reset)
+	}
+
+	cases := map[string]string{
+		`{"pattern": "INV-{SEQ}", "reset": "yearly"}`:         "yearly pattern without year",
+		`{"pattern": "INV-{YYYY}-{SEQ}", "reset": "monthly"}`: "monthly pattern without month",
+		`{"pattern": "INV-{MM}-{SEQ}", "reset": "monthly"}`:   "monthly pattern without year",
+		`{"pattern": "INV-{YY}{MM}-{SEQ}", "reset": "daily"}`: "daily pattern without day",
+	}
+	for jsonStr, desc := range cases {
+		if _, err := ParseNumberingFromJSON(jsonStr); err == nil {
+			t.Errorf("expect %s is rejected", desc)
+		}
+	}
+
+	if _, err := ParseNumberingFromJSON(`{"pattern": "INV-{YY}{MM}-{SEQ}", "reset": "monthly"}`); err != nil {
+		t.Errorf("expect monthly pattern with year and month is accepted but get %s", err.Error())
+	}
+}
+
+func TestNumberingSequenceMatchPattern(t *testing.T) {
+	sequence := &NumberingSequence{Pattern: "INV.{YYYY}-{SEQ:4}", Reset: ResetYearly}
+	matcher := sequence.matchPattern()
+
+	for _, number := range []string{"INV.2026-0001", "INV.2026-12345"} {
+		if !matcher.MatchString(number) {
+			t.Errorf("expect %s match pattern %s", number, sequence.Pattern)
+		}
+	}
+	for _, number := range []string{"INVx2026-0001", "INV.2026-001", "INV.26-0001", "XINV.2026-0001"} {
+		if matcher.MatchString(number) {
+			t.Errorf("expect %s not match pattern %s", number, sequence.Pattern)
+		}
+	}
+}
+
+func TestAllocateNumberPeriodChange(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	sequence := &NumberingSequence{Pattern: "INV-{YYYY}-{SEQ:3}", Reset: ResetYearly}
+	if err := SaveNumbering(trx, "invoice", sequence); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	invoiceID := "733bee1b-f79a-4cb7-b675-842317b994b5"
+	dates := []time.Time{
+		time.Date(2026, 12, 30, 10, 0, 0, 0, time.UTC),
+		time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2027, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+	expected := []string{"INV-2026-001", "INV-2026-002", "INV-2027-001"}
+	for index, now := range dates {
+		number, err := allocateNumber(trx, invoiceID, fmt.Sprintf("numbering-test-%d", index), now)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if strings.Compare(number, expected[index]) != 0 {
+			t.Errorf("expect allocation %d is %s but get %s", index, expected[index], number)
+		}
+	}
+
+	//same pattern with other reset period restart counter on new period key and repeat numbers
+	err := SaveNumbering(trx, "invoice", &NumberingSequence{Pattern: "INV-{YYYY}-{SEQ:3}", Reset: ResetNever})
+	if _, ok := err.(ErrNumberingConflict); !ok {
+		t.Errorf("expect ErrNumberingConflict when reset period change may repeat numbers but get %v", err)
+	}
+
+	//pattern which never produce allocated numbers is accepted and keep numbers unique
+	sequence = &NumberingSequence{Pattern: "INV/{YYYY}{MM}/{SEQ:3}", Reset: ResetMonthly}
+	if err = SaveNumbering(trx, "invoice", sequence); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	number, allocErr := allocateNumber(trx, invoiceID, "numbering-test-3", dates[2])
+	if allocErr != nil {
+		t.Fatal(allocErr)
+		return
+	}
+	if strings.Compare(number, "INV/202701/001") != 0 {
+		t.Errorf("expect INV/202701/001 but get %s", number)
+	}
+
+	reports, reportErr := GetNumberingGaps(trx, "invoice")
+	if reportErr != nil {
+		t.Fatal(reportErr)
+		return
+	}
+	if len(reports) != 3 {
+		t.Errorf("expect counters of periods 2026, 2027 and 2027-01 but get %v", reports)
+	}
+}
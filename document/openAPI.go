@@ -0,0 +1,370 @@
+package document
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+)
+
+//OpenAPIVersion OpenAPI specification version produced by ToOpenAPI
+const OpenAPIVersion = "3.1.0"
+
+//OpenAPISchema released schema revision described by OpenAPI document
+type OpenAPISchema struct {
+	Schema   *gxschema.DxDoc
+	Refs     []Reference
+	Computed []ComputedField
+}
+
+//openAPIOperation fixed gxdoc endpoint, path is relative to /api
+type openAPIOperation struct {
+	method  string
+	path    string
+	summary string
+	tag     string
+}
+
+//openAPIFixedOperations endpoints which don't depend on registered schemas
+//NOTE: record submission, validation and record retrieval are generated per schema, see ToOpenAPI
+var openAPIFixedOperations = []openAPIOperation{
+	{"get", "/document/schema-infos", "get all schema summary", "schema"},
+	{"post", "/document/schema-infos", "register a new schema", "schema"},
+	{"get", "/document/schema-infos/{schema-name}", "get specific schema summary", "schema"},
+	{"post", "/document/schema-infos/{schema-name}", "update schema summary", "schema"},
+	{"get", "/document/schemas/{schema-name}", "get latest schema definition", "schema"},
+	{"post", "/document/schemas/{schema-name}", "update schema definition", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}", "get specific schema definition by revision number", "schema"},
+	{"get", "/document/schemas/{schema-name}/draft", "get draft version of schema definition", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft", "update draft version of schema definition", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft/import", "import JSON Schema or XSD as draft version of schema definition", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft/release", "release draft as new schema revision", "schema"},
+	{"get", "/document/schemas.zip", "export schema infos with all revisions and drafts as bundle", "schema"},
+	{"post", "/document/schemas.zip", "import schema bundle exported from another server", "schema"},
+	{"get", "/document/schemas/{schema-name}/draft/rules", "get Lua rule script of schema draft", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft/rules", "update Lua rule script of schema draft", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/rules", "get Lua rule script of schema revision", "schema"},
+	{"get", "/document/schemas/{schema-name}/draft/computed", "get computed fields of schema draft", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft/computed", "update computed fields of schema draft", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/computed", "get computed fields of schema revision", "schema"},
+	{"get", "/document/schemas/{schema-name}/draft/locales", "get localized labels and messages of schema draft", "schema"},
+	{"post", "/document/schemas/{schema-name}/draft/locales", "update localized labels and messages of schema draft", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/locales", "get localized labels and messages of schema revision", "schema"},
+	{"get", "/document/schemas/{schema-name}/export.json-schema", "export latest schema as JSON Schema", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/export.json-schema", "export schema revision as JSON Schema", "schema"},
+	{"get", "/document/schemas/{schema-name}/export.xsd", "export latest schema as XSD", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/export.xsd", "export schema revision as XSD", "schema"},
+	{"get", "/document/schemas/{schema-name}/export.d.ts", "export latest schema as TypeScript declaration", "schema"},
+	{"get", "/document/schemas/{schema-name}/revisions/{revision-number}/export.d.ts", "export schema revision as TypeScript declaration", "schema"},
+	{"get", "/document/schemas/{schema-name}/verify", "verify schema revisions hash chain is intact", "schema"},
+	{"get", "/document/schemas/{schema-name}/dependents", "list schema revisions which include the schema", "schema"},
+	{"post", "/document/lint", "check schema definition for mistakes without saving it", "schema"},
+	{"get", "/document/schema-infos/{schema-name}/workflow", "get workflow (state machine) of schema", "workflow"},
+	{"post", "/document/schema-infos/{schema-name}/workflow", "declare or update workflow of schema", "workflow"},
+	{"get", "/document/schema-infos/{schema-name}/numbering", "get document numbering sequence of schema", "numbering"},
+	{"post", "/document/schema-infos/{schema-name}/numbering", "declare or update document numbering sequence of schema", "numbering"},
+	{"get", "/document/schema-infos/{schema-name}/numbering/gaps", "audit allocated document numbers for gaps", "numbering"},
+	{"post", "/document/{schema-name}/validate/batch", "validate many documents at once, results streamed as NDJSON", "validation"},
+	{"post", "/document/{schema-name}/records/import", "import document records from CSV (all rows or none)", "record"},
+	{"get", "/document/{schema-name}/records/{record-id}/history", "get all versions of document record", "record"},
+	{"get", "/document/{schema-name}/records/{record-id}/verify", "verify document record versions hash chain is intact", "record"},
+	{"post", "/document/{schema-name}/records/{record-id}/transitions/{transition-name}", "move document record to next workflow state", "record"},
+	{"get", "/document/{schema-name}/records/{record-id}/referenced-by", "list records which reference to this record", "record"},
+	{"get", "/audit", "query audit log of mutating API calls", "audit"},
+	{"get", "/openapi.json", "OpenAPI 3 description of REST API, including every active schema", "openapi"},
+}
+
+var openAPIPathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+//openAPIComponentNamePattern characters not allowed in OpenAPI component name
+var openAPIComponentNamePattern = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+//ToOpenAPI generate OpenAPI document of gxdoc REST API
+//	fixed endpoints are described generically, while record submission, validation and record retrieval
+//	are described per schema with request and record data derived from its released revision
+//	version is version of API description, e.g. from GetOpenAPIVersion
+func ToOpenAPI(schemas []OpenAPISchema, version string) ([]byte, error) {
+	paths := map[string]map[string]interface{}{}
+	components := map[string]interface{}{
+		"Violation": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path":    map[string]interface{}{"type": "string", "description": "JSON pointer of offending value"},
+				"rule":    map[string]interface{}{"type": "string"},
+				"value":   map[string]interface{}{},
+				"message": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"path", "rule", "message"},
+		},
+		"Error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"errorCode":    map[string]interface{}{"type": "integer"},
+				"errorMessage": map[string]interface{}{"type": "string"},
+				"errors": map[string]interface{}{
+					"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/Violation"}},
+			},
+			"required": []string{"errorCode", "errorMessage"},
+		},
+		"ValidationResult": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"isValid":  map[string]interface{}{"type": "boolean"},
+				"message":  map[string]interface{}{"type": "string"},
+				"revision": map[string]interface{}{"type": "integer", "description": "schema revision used, -1 is draft"},
+				"isDraft":  map[string]interface{}{"type": "boolean"},
+				"errors": map[string]interface{}{
+					"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/Violation"}},
+			},
+		},
+	}
+
+	for _, operation := range openAPIFixedOperations {
+		addOpenAPIOperation(paths, operation.path, operation.method, map[string]interface{}{
+			"summary":    operation.summary,
+			"tags":       []string{operation.tag},
+			"parameters": getOpenAPIPathParams(operation.path),
+			"responses": map[string]interface{}{
+				"200":     getOpenAPIResponse("successful response", map[string]interface{}{}),
+				"default": getOpenAPIErrorResponse(),
+			},
+		})
+	}
+
+	for _, item := range schemas {
+		if err := addOpenAPISchema(paths, components, item); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "gxdoc",
+			"version": version,
+		},
+		"servers":    []interface{}{map[string]interface{}{"url": "/api"}},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": components},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func addOpenAPISchema(paths map[string]map[string]interface{}, components map[string]interface{},
+	item OpenAPISchema) error {
+	refPaths := map[string]Reference{}
+	for _, ref := range item.Refs {
+		refPaths[ref.ItemPath] = ref
+	}
+
+	dataSchema, dataErr := getJSONSchemaObject(item.Schema.Items, "", refPaths, GetComputedPaths(item.Computed))
+	if dataErr != nil {
+		return fmt.Errorf("failed to describe schema %s: %s", item.Schema.Name, dataErr.Error())
+	}
+	dataSchema["title"] = item.Schema.Name
+	dataSchema["$comment"] = fmt.Sprintf("generated from gxdoc schema %s revision %d",
+		item.Schema.Name, item.Schema.Revision)
+
+	componentName := openAPIComponentNamePattern.ReplaceAllString(item.Schema.Name, "_")
+	components[componentName] = dataSchema
+	components[componentName+".record"] = getOpenAPIRecordSchema(componentName)
+
+	dataRef := map[string]interface{}{"$ref": "#/components/schemas/" + componentName}
+	recordRef := map[string]interface{}{"$ref": "#/components/schemas/" + componentName + ".record"}
+	basePath := "/document/" + url.PathEscape(item.Schema.Name)
+	tags := []string{item.Schema.Name}
+
+	addOpenAPIOperation(paths, basePath+"/records", "post", map[string]interface{}{
+		"summary": "submit a new " + item.Schema.Name + " record",
+		"tags":    tags,
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": dataRef}},
+		},
+		"responses": map[string]interface{}{
+			"200":     getOpenAPIResponse("record is created", recordRef),
+			"400":     getOpenAPIErrorResponse(),
+			"default": getOpenAPIErrorResponse(),
+		},
+	})
+
+	addOpenAPIOperation(paths, basePath+"/validate", "post", map[string]interface{}{
+		"summary": "validate " + item.Schema.Name + " data with XML or JSON format",
+		"tags":    tags,
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "revision", "in": "query", "schema": map[string]interface{}{"type": "integer"},
+				"description": "validate against specific revision instead of latest revision"},
+			map[string]interface{}{"name": "draft", "in": "query", "schema": map[string]interface{}{"type": "boolean"},
+				"description": "validate against draft instead of latest revision"},
+		},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": dataRef},
+				"text/xml":         map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": getOpenAPIResponse("validation result",
+				map[string]interface{}{"$ref": "#/components/schemas/ValidationResult"}),
+			"404":     getOpenAPIErrorResponse(),
+			"413":     getOpenAPIErrorResponse(),
+			"415":     getOpenAPIErrorResponse(),
+			"default": getOpenAPIErrorResponse(),
+		},
+	})
+
+	recordPath := basePath + "/records/{record-id}"
+	addOpenAPIOperation(paths, recordPath, "get", map[string]interface{}{
+		"summary":    "get " + item.Schema.Name + " record",
+		"tags":       tags,
+		"parameters": getOpenAPIPathParams(recordPath),
+		"responses": map[string]interface{}{
+			"200":     getOpenAPIResponse("latest version of record", recordRef),
+			"404":     getOpenAPIErrorResponse(),
+			"default": getOpenAPIErrorResponse(),
+		},
+	})
+
+	return nil
+}
+
+func addOpenAPIOperation(paths map[string]map[string]interface{}, path string, method string,
+	operation map[string]interface{}) {
+	if paths[path] == nil {
+		paths[path] = map[string]interface{}{}
+	}
+
+	paths[path][method] = operation
+}
+
+//getOpenAPIPathParams declare every {param} in path as required string parameter
+func getOpenAPIPathParams(path string) []interface{} {
+	params := []interface{}{}
+	for _, match := range openAPIPathParamPattern.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	return params
+}
+
+//getOpenAPIResponse describe successful JSON response, result is wrapped in "response"
+func getOpenAPIResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"response": schema},
+				},
+			},
+		},
+	}
+}
+
+func getOpenAPIErrorResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"description": "request is rejected",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+			},
+		},
+	}
+}
+
+func getOpenAPIRecordSchema(componentName string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":       map[string]interface{}{"type": "string", "format": "uuid"},
+			"number":   map[string]interface{}{"type": "string"},
+			"schema":   map[string]interface{}{"type": "string"},
+			"revision": map[string]interface{}{"type": "integer"},
+			"state":    map[string]interface{}{"type": "string"},
+			"version":  map[string]interface{}{"type": "integer"},
+			"data":     map[string]interface{}{"$ref": "#/components/schemas/" + componentName},
+			"created":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"updated":  map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+	}
+}
+
+//GetOpenAPIVersion get version of OpenAPI document,
+//it changes whenever a schema revision is released or a schema is activated or deactivated
+func GetOpenAPIVersion(db rdbmstool.DbHandlerProxy) (string, error) {
+	rows, rowsErr := db.Query(`SELECT a.name, MAX(b.revision) FROM doc_schema a
+	JOIN doc_schema_revision b ON a.id = b.schema_id
+	WHERE a.is_active = 1 AND b.revision > 0
+	GROUP BY a.name
+	ORDER BY a.name`)
+	if rowsErr != nil {
+		return "", fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	releases := []string{}
+	for rows.Next() {
+		var name string
+		var revision int
+		if err := rows.Scan(&name, &revision); err != nil {
+			return "", fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		releases = append(releases, fmt.Sprintf("%s:%d", name, revision))
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(releases, "\n")))
+
+	return hex.EncodeToString(hash[:])[:12], nil
+}
+
+//GetOpenAPISchemas get latest revision and references of every active schema
+func GetOpenAPISchemas(db rdbmstool.DbHandlerProxy) ([]OpenAPISchema, error) {
+	infos, infoErr := GetAllSchemaInfo(db)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+
+	schemas := []OpenAPISchema{}
+	for _, info := range infos {
+		if !info.IsActive {
+			continue
+		}
+
+		schema, schemaErr := GetSchema(db, info.Name)
+		if schemaErr != nil {
+			return nil, schemaErr
+		}
+		if schema == nil {
+			continue //no revision released yet
+		}
+
+		refs, refErr := GetReferences(db, info.Name, schema.Revision)
+		if refErr != nil {
+			return nil, refErr
+		}
+
+		computed, computedErr := GetComputedFields(db, info.Name, schema.Revision)
+		if computedErr != nil {
+			return nil, computedErr
+		}
+
+		schemas = append(schemas, OpenAPISchema{Schema: schema, Refs: refs, Computed: computed})
+	}
+
+	return schemas, nil
+}
@@ -0,0 +1,109 @@
+package document
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func TestToOpenAPI(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 2,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxDecimal{Name: "price", Precision: 2},
+		},
+	}
+
+	docRaw, err := ToOpenAPI([]OpenAPISchema{{Schema: schema}}, "abc")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(docRaw, &doc); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if doc.OpenAPI != OpenAPIVersion || doc.Info.Version != "abc" {
+		t.Errorf("expect openapi %s version abc but get %s version %s", OpenAPIVersion, doc.OpenAPI, doc.Info.Version)
+	}
+
+	for _, path := range []string{"/document/invoice/records", "/document/invoice/validate"} {
+		if _, ok := doc.Paths[path]["post"]; !ok {
+			t.Errorf("expect POST %s is described", path)
+		}
+	}
+	if _, ok := doc.Paths["/document/invoice/records/{record-id}"]["get"]; !ok {
+		t.Errorf("expect GET record of invoice is described")
+	}
+	if _, ok := doc.Paths["/document/schema-infos"]["get"]; !ok {
+		t.Errorf("expect fixed endpoints are described")
+	}
+
+	invoice, ok := doc.Components.Schemas["invoice"]
+	if !ok || len(invoice.Properties) != 2 {
+		t.Errorf("expect invoice component with 2 properties but get %v", doc.Components.Schemas["invoice"])
+	}
+	if _, ok := doc.Components.Schemas["invoice.record"]; !ok {
+		t.Errorf("expect invoice.record component")
+	}
+}
+
+func TestToOpenAPIDescribesREADMEEndpoints(t *testing.T) {
+	readme, readErr := ioutil.ReadFile("../README.md")
+	if readErr != nil {
+		t.Fatal(readErr)
+		return
+	}
+
+	schema := &gxschema.DxDoc{Name: "invoice", Revision: 1, Items: []gxschema.DxItem{gxschema.DxStr{Name: "invNo"}}}
+	docRaw, err := ToOpenAPI([]OpenAPISchema{{Schema: schema}}, "abc")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(docRaw, &doc); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//API summary row; e.g. "| GET | /api/document/schema-infos | get all schema summary |"
+	rowPattern := regexp.MustCompile(`(?m)^\| (GET|POST) \| /api(/\S+) \|`)
+	rows := rowPattern.FindAllStringSubmatch(string(readme), -1)
+	if len(rows) == 0 {
+		t.Fatal("expect API summary rows in README.md")
+		return
+	}
+
+	for _, row := range rows {
+		method := strings.ToLower(row[1])
+		_, fixed := doc.Paths[row[2]][method]
+		_, perSchema := doc.Paths[strings.Replace(row[2], "{schema-name}", "invoice", 1)][method]
+		if !fixed && !perSchema {
+			t.Errorf("expect %s %s is described", row[1], row[2])
+		}
+	}
+}
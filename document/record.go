@@ -0,0 +1,315 @@
+package document
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/guinso/rdbmstool"
+	"github.com/guinso/stringtool"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+//record version actions
+const (
+	RecordActionCreate = "create"
+)
+
+//Record document data submitted against a schema revision
+type Record struct {
+	ID         string          `json:"id"`
+	Number     string          `json:"number,omitempty"` //human readable number, see NumberingSequence
+	SchemaName string          `json:"schema"`
+	Revision   int             `json:"revision"`
+	State      string          `json:"state"`
+	Version    int             `json:"version"`
+	Data       json.RawMessage `json:"data"`
+	Created    time.Time       `json:"created"`
+	Updated    time.Time       `json:"updated"`
+}
+
+//RecordVersion historical snapshot of a document record
+type RecordVersion struct {
+	RecordID    string          `json:"id"`
+	Version     int             `json:"version"`
+	Action      string          `json:"action"`
+	State       string          `json:"state"`
+	Data        json.RawMessage `json:"data"`
+	Actor       string          `json:"actor"`
+	Created     time.Time       `json:"created"`
+	ContentHash string          `json:"hash"`
+	PrevHash    string          `json:"prevHash"`
+}
+
+//JSON export to JSON string
+func (record *Record) JSON() string {
+	jsonRaw, _ := json.Marshal(record)
+
+	return string(jsonRaw)
+}
+
+//computeRecordVersionHash calculate hash of a document record version
+func computeRecordVersionHash(prevHash string, recordID string, version int,
+	action string, state string, data string, actor string, created string) string {
+	return ComputeChainHash(prevHash, recordID, strconv.Itoa(version), action, state, data, actor, created)
+}
+
+//AddRecord register new document record against schema revision
+//	data is JSON string which already validated by caller
+//	record start with workflow initial state if schema has workflow declared
+//	record is given next document number if schema has numbering sequence declared
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func AddRecord(db rdbmstool.DbHandlerProxy, schemaName string, revision int, data string, actor string) (*Record, error) {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	if schemaInfo == nil {
+		return nil, ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	workflow, workflowErr := GetWorkflow(db, schemaName)
+	if workflowErr != nil {
+		return nil, workflowErr
+	}
+
+	newID, idErr := stringtool.GenerateRandomUUID()
+	if idErr != nil {
+		return nil, fmt.Errorf("failed to generate ID for new %s record", schemaName)
+	}
+
+	now := time.Now().UTC()
+	record := Record{
+		ID:         newID,
+		SchemaName: schemaInfo.Name,
+		Revision:   revision,
+		Version:    1,
+		Data:       json.RawMessage(data),
+		Created:    now,
+		Updated:    now,
+	}
+	if workflow != nil {
+		record.State = workflow.InitialState
+	}
+
+	number, numberErr := allocateNumber(db, schemaInfo.ID, record.ID, now)
+	if numberErr != nil {
+		return nil, numberErr
+	}
+	record.Number = number
+
+	_, dbErr := db.Exec(`INSERT INTO doc_record (id, schema_id, number, revision, state, version, data, created, updated)
+	VALUES (?,?,?,?,?,?,?,?,?)`,
+		record.ID, schemaInfo.ID, sql.NullString{String: record.Number, Valid: record.Number != ""}, record.Revision, record.State, record.Version, data,
+		now.Format(timeFormat), now.Format(timeFormat))
+	if dbErr != nil {
+		return nil, fmt.Errorf("failed to create %s record into database: %s", schemaName, dbErr.Error())
+	}
+
+	if err := addRecordVersion(db, &record, RecordActionCreate, actor); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+//GetRecord get document record by ID, return nil if not found
+func GetRecord(db rdbmstool.DbHandlerProxy, schemaName string, id string) (*Record, error) {
+	return getRecord(db, schemaName, id, false)
+}
+
+func getRecord(db rdbmstool.DbHandlerProxy, schemaName string, id string, forUpdate bool) (*Record, error) {
+	sqlStr := `SELECT a.id, a.number, b.name, a.revision, a.state, a.version, a.data, a.created, a.updated
+	FROM doc_record a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.id = ?`
+	if forUpdate {
+		sqlStr += " FOR UPDATE"
+	}
+
+	row := db.QueryRow(sqlStr, schemaName, id)
+
+	record := Record{}
+	var tmpNumber sql.NullString
+	var tmpData, tmpCreated, tmpUpdated string
+	scanErr := row.Scan(&record.ID, &tmpNumber, &record.SchemaName, &record.Revision, &record.State,
+		&record.Version, &tmpData, &tmpCreated, &tmpUpdated)
+	if scanErr != nil {
+		if scanErr == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch %s record %s from database: %s", schemaName, id, scanErr.Error())
+	}
+
+	record.Number = tmpNumber.String
+	record.Data = json.RawMessage(tmpData)
+	record.Created, _ = time.Parse(timeFormat, tmpCreated)
+	record.Updated, _ = time.Parse(timeFormat, tmpUpdated)
+
+	return &record, nil
+}
+
+//GetRecordHistory get all versions of document record, oldest version come first
+func GetRecordHistory(db rdbmstool.DbHandlerProxy, id string) ([]RecordVersion, error) {
+	rows, rowsErr := db.Query(`SELECT record_id, version, action, state, data, actor, created, content_hash, prev_hash
+	FROM doc_record_version WHERE record_id = ? ORDER BY version`, id)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []RecordVersion{}
+	for rows.Next() {
+		item := RecordVersion{}
+		var tmpData, tmpCreated string
+		scanErr := rows.Scan(&item.RecordID, &item.Version, &item.Action, &item.State, &tmpData,
+			&item.Actor, &tmpCreated, &item.ContentHash, &item.PrevHash)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", scanErr.Error())
+		}
+
+		item.Data = json.RawMessage(tmpData)
+		item.Created, _ = time.Parse(timeFormat, tmpCreated)
+
+		results = append(results, item)
+	}
+
+	return results, nil
+}
+
+//TransitRecord move document record into next state by workflow transition
+//and write the transition into record history
+//NOTE: ErrRecordNotFound, ErrWorkflowNotFound, ErrTransitionNotAllowed and
+//ErrTransitionNotAuthorize error will return if rules are violated
+func TransitRecord(db rdbmstool.DbHandlerProxy, schemaName string, id string,
+	transitionName string, actor string, roles []string) (*Record, error) {
+	workflow, workflowErr := GetWorkflow(db, schemaName)
+	if workflowErr != nil {
+		return nil, workflowErr
+	}
+	if workflow == nil {
+		return nil, ErrWorkflowNotFound{msg: schemaName + " has no workflow declared"}
+	}
+
+	record, recordErr := getRecord(db, schemaName, id, true)
+	if recordErr != nil {
+		return nil, recordErr
+	}
+	if record == nil {
+		return nil, ErrRecordNotFound{msg: fmt.Sprintf("%s record %s not found", schemaName, id)}
+	}
+
+	transition := workflow.GetTransition(transitionName)
+	if transition == nil {
+		return nil, ErrTransitionNotAllowed{
+			msg: fmt.Sprintf("transition '%s' is not declared in %s workflow", transitionName, schemaName)}
+	}
+
+	if err := transition.Check(workflow.GetCurrentState(record.State), roles); err != nil {
+		return nil, err
+	}
+
+	record.State = transition.To
+	record.Version++
+	record.Updated = time.Now().UTC()
+
+	_, dbErr := db.Exec(`UPDATE doc_record SET state = ?, version = ?, updated = ? WHERE id = ?`,
+		record.State, record.Version, record.Updated.Format(timeFormat), record.ID)
+	if dbErr != nil {
+		return nil, fmt.Errorf("failed to update %s record %s state: %s", schemaName, id, dbErr.Error())
+	}
+
+	if err := addRecordVersion(db, record, transition.Name, actor); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+//addRecordVersion append current record content into history, chained to previous version's hash
+func addRecordVersion(db rdbmstool.DbHandlerProxy, record *Record, action string, actor string) error {
+	prevHash := ""
+	if record.Version > 1 {
+		row := db.QueryRow(`SELECT content_hash FROM doc_record_version WHERE record_id = ? AND version = ?`,
+			record.ID, record.Version-1)
+		if err := row.Scan(&prevHash); err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to fetch record version hash from database: %s", err.Error())
+		}
+	}
+
+	data := string(record.Data)
+	created := record.Updated.Format(timeFormat)
+	contentHash := computeRecordVersionHash(prevHash, record.ID, record.Version, action, record.State, data,
+		actor, created)
+
+	_, dbErr := db.Exec(`INSERT INTO doc_record_version
+	(record_id, version, action, state, data, actor, created, content_hash, prev_hash)
+	VALUES (?,?,?,?,?,?,?,?,?)`,
+		record.ID, record.Version, action, record.State, data, actor,
+		created, contentHash, prevHash)
+	if dbErr != nil {
+		return fmt.Errorf("failed to write record %s version %d into database: %s",
+			record.ID, record.Version, dbErr.Error())
+	}
+
+	return nil
+}
+
+//VerifyRecordChain walk through all versions of document record and report first broken link found (if any)
+//NOTE: ErrRecordNotFound error will return if record not found
+func VerifyRecordChain(db rdbmstool.DbHandlerProxy, schemaName string, id string) (*ChainReport, error) {
+	record, recordErr := GetRecord(db, schemaName, id)
+	if recordErr != nil {
+		return nil, recordErr
+	}
+	if record == nil {
+		return nil, ErrRecordNotFound{msg: fmt.Sprintf("%s record %s not found", schemaName, id)}
+	}
+
+	versions, versionsErr := GetRecordHistory(db, id)
+	if versionsErr != nil {
+		return nil, versionsErr
+	}
+
+	report := ChainReport{Name: id, IsValid: true}
+	expectedPrevHash := ""
+	for index, item := range versions {
+		report.CheckedCount++
+
+		if item.Version != index+1 {
+			report.IsValid = false
+			report.BrokenRevision = index + 1
+			report.Reason = fmt.Sprintf("version %d is missing from chain", index+1)
+			return &report, nil
+		}
+
+		if item.PrevHash != expectedPrevHash {
+			report.IsValid = false
+			report.BrokenRevision = item.Version
+			report.Reason = fmt.Sprintf("version %d does not link to hash of version %d", item.Version, item.Version-1)
+			return &report, nil
+		}
+
+		if computeRecordVersionHash(item.PrevHash, item.RecordID, item.Version,
+			item.Action, item.State, string(item.Data), item.Actor, item.Created.Format(timeFormat)) != item.ContentHash {
+			report.IsValid = false
+			report.BrokenRevision = item.Version
+			report.Reason = fmt.Sprintf("version %d content does not match its hash", item.Version)
+			return &report, nil
+		}
+
+		expectedPrevHash = item.ContentHash
+	}
+
+	if len(versions) != record.Version {
+		report.IsValid = false
+		report.BrokenRevision = record.Version
+		report.Reason = fmt.Sprintf("record is at version %d but history has %d versions", record.Version, len(versions))
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,418 @@
+package document
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/rdbmstool"
+)
+
+//referenceIDLength length of referenced record ID (UUID)
+const referenceIDLength = 36
+
+//Reference declare schema item which hold ID of a record from another schema
+//
+//	in XML definition it is written as <dxref name="pr" schema="pr" revision="2"></dxref>
+//	and stored as <dxstr> so gxschema able to validate it as a string
+type Reference struct {
+	ItemPath       string `json:"item"`               //item name, nested section item is joined by '/'; e.g. header/prNo
+	TargetSchema   string `json:"schema"`             //referenced schema name
+	TargetRevision int    `json:"revision,omitempty"` //referenced schema revision, 0 means any revision
+	IsOptional     bool   `json:"isOptional,omitempty"`
+	IsArray        bool   `json:"isArray,omitempty"`
+}
+
+//RecordReference link between two document records
+type RecordReference struct {
+	RecordID       string `json:"id"`
+	SchemaName     string `json:"schema"`
+	ItemPath       string `json:"item"`
+	TargetRecordID string `json:"targetId"`
+}
+
+//ExtractReferences convert <dxref> items of XML definition into <dxstr>
+//so it is able to parse by gxschema.ParseSchemaFromXML
+//RETURN:
+//	string: XML definition without <dxref>
+//	[]Reference: references found in XML definition
+func ExtractReferences(xmlStr string) (string, []Reference, error) {
+	refs := []Reference{}
+	path := []string{}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", nil, fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			element = removeLocaleXMLAttr(element) //informative only
+			token = element
+			if element.Name.Local == "dxdoc" {
+				element.Attr = removeXMLAttr(element.Attr, DataSchemaLocationAttr) //informative only
+				token = element
+			} else if element.Name.Local == "dxsection" {
+				path = append(path, getXMLAttr(element, "name"))
+			} else if element.Name.Local == "dxref" {
+				ref := Reference{
+					ItemPath:     strings.Join(append(path, getXMLAttr(element, "name")), "/"),
+					TargetSchema: getXMLAttr(element, "schema"),
+					IsOptional:   getXMLAttr(element, "isOptional") == "true",
+					IsArray:      getXMLAttr(element, "isArray") == "true",
+				}
+				if ref.TargetSchema == "" {
+					return "", nil, fmt.Errorf("dxref %s must specify target schema", ref.ItemPath)
+				}
+				if rawRev := getXMLAttr(element, "revision"); rawRev != "" {
+					rev, revErr := strconv.Atoi(rawRev)
+					if revErr != nil || rev < 1 {
+						return "", nil, fmt.Errorf("dxref %s has invalid revision: %s", ref.ItemPath, rawRev)
+					}
+					ref.TargetRevision = rev
+				}
+				refs = append(refs, ref)
+
+				//length limit is always the referenced record ID length, declared one is dropped
+				strElement := xml.StartElement{Name: xml.Name{Local: "dxstr"}}
+				for _, attr := range element.Attr {
+					switch attr.Name.Local {
+					case "schema", "revision", "lenLimit", "enableLenLimit":
+					default:
+						strElement.Attr = append(strElement.Attr, attr)
+					}
+				}
+				strElement.Attr = append(strElement.Attr, xml.Attr{
+					Name: xml.Name{Local: "lenLimit"}, Value: strconv.Itoa(referenceIDLength)})
+				token = strElement
+			}
+		case xml.EndElement:
+			if element.Name.Local == "dxsection" && len(path) > 0 {
+				path = path[:len(path)-1]
+			} else if element.Name.Local == "dxref" {
+				token = xml.EndElement{Name: xml.Name{Local: "dxstr"}}
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", nil, fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", nil, fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), refs, nil
+}
+
+//ApplyReferences convert <dxstr> items which declared as reference back into <dxref>
+func ApplyReferences(xmlStr string, refs []Reference) (string, error) {
+	if len(refs) == 0 {
+		return xmlStr, nil
+	}
+
+	refMap := map[string]Reference{}
+	for _, ref := range refs {
+		refMap[ref.ItemPath] = ref
+	}
+
+	path := []string{}
+	renamed := []bool{}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			isRef := false
+			if element.Name.Local == "dxsection" {
+				path = append(path, getXMLAttr(element, "name"))
+			} else if element.Name.Local == "dxstr" {
+				itemPath := strings.Join(append(path, getXMLAttr(element, "name")), "/")
+				if ref, ok := refMap[itemPath]; ok {
+					isRef = true
+
+					refElement := xml.StartElement{Name: xml.Name{Local: "dxref"}}
+					for _, attr := range element.Attr {
+						if attr.Name.Local != "lenLimit" {
+							refElement.Attr = append(refElement.Attr, attr)
+						}
+					}
+					refElement.Attr = append(refElement.Attr, xml.Attr{
+						Name: xml.Name{Local: "schema"}, Value: ref.TargetSchema})
+					if ref.TargetRevision > 0 {
+						refElement.Attr = append(refElement.Attr, xml.Attr{
+							Name: xml.Name{Local: "revision"}, Value: strconv.Itoa(ref.TargetRevision)})
+					}
+					token = refElement
+				}
+			}
+			renamed = append(renamed, isRef)
+		case xml.EndElement:
+			if element.Name.Local == "dxsection" && len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			if len(renamed) > 0 {
+				if renamed[len(renamed)-1] {
+					token = xml.EndElement{Name: xml.Name{Local: "dxref"}}
+				}
+				renamed = renamed[:len(renamed)-1]
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
+
+func getXMLAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+//GetReferences get references of schema revision (use -1 for draft),
+//including references of included schema revisions
+func GetReferences(db rdbmstool.DbHandlerProxy, schemaName string, revision int) ([]Reference, error) {
+	refs, refErr := getDeclaredReferences(db, schemaName, revision)
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	includedRefs, includedErr := getIncludedReferences(db, schemaName, revision, []string{})
+	if includedErr != nil {
+		return nil, includedErr
+	}
+
+	return append(refs, includedRefs...), nil
+}
+
+//getDeclaredReferences get references declared by schema revision itself (use -1 for draft)
+func getDeclaredReferences(db rdbmstool.DbHandlerProxy, schemaName string, revision int) ([]Reference, error) {
+	rows, rowsErr := db.Query(`SELECT a.item_path, a.target_schema, a.target_revision, a.is_optional, a.is_array
+	FROM doc_schema_reference a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.revision = ?
+	ORDER BY a.item_path`, schemaName, revision)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []Reference{}
+	for rows.Next() {
+		ref := Reference{}
+		if err := rows.Scan(&ref.ItemPath, &ref.TargetSchema, &ref.TargetRevision,
+			&ref.IsOptional, &ref.IsArray); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		results = append(results, ref)
+	}
+
+	return results, nil
+}
+
+//SaveReferences save references declared by schema revision (use -1 for draft)
+//NOTE: existing references of the revision will be overwritten
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrReferenceNotFound error will return if referenced document not register in doc_schema datatable
+func SaveReferences(db rdbmstool.DbHandlerProxy, schemaName string, revision int, refs []Reference) error {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	if _, err := db.Exec(`DELETE FROM doc_schema_reference WHERE schema_id = ? AND revision = ?`,
+		schemaInfo.ID, revision); err != nil {
+		return fmt.Errorf("failed to clear %s references from database: %s", schemaName, err.Error())
+	}
+
+	for _, ref := range refs {
+		targetInfo, targetErr := GetSchemaInfo(db, ref.TargetSchema)
+		if targetErr != nil {
+			return targetErr
+		}
+		if targetInfo == nil {
+			return ErrReferenceNotFound{
+				msg: fmt.Sprintf("schema %s referenced by %s not found in database", ref.TargetSchema, ref.ItemPath)}
+		}
+
+		_, dbErr := db.Exec(`INSERT INTO doc_schema_reference
+		(schema_id, revision, item_path, target_schema, target_revision, is_optional, is_array)
+		VALUES (?,?,?,?,?,?,?)`,
+			schemaInfo.ID, revision, ref.ItemPath, ref.TargetSchema, ref.TargetRevision, ref.IsOptional, ref.IsArray)
+		if dbErr != nil {
+			return fmt.Errorf("failed to save %s reference %s into database: %s",
+				schemaName, ref.ItemPath, dbErr.Error())
+		}
+	}
+
+	return nil
+}
+
+//ValidateRecordReferences check every referenced record exists in database
+//	data is JSON string of document record
+//	return list of record references to be saved once record is created
+//NOTE: ErrReferenceNotFound error will return if referenced record not found
+func ValidateRecordReferences(db rdbmstool.DbHandlerProxy, schemaName string, revision int,
+	data string) ([]RecordReference, error) {
+	refs, refsErr := GetReferences(db, schemaName, revision)
+	if refsErr != nil {
+		return nil, refsErr
+	}
+
+	if len(refs) == 0 {
+		return []RecordReference{}, nil
+	}
+
+	var dataObj interface{}
+	if err := json.Unmarshal([]byte(data), &dataObj); err != nil {
+		return nil, fmt.Errorf("invalid JSON data: %s", err.Error())
+	}
+
+	results := []RecordReference{}
+	for _, ref := range refs {
+		for _, targetID := range getValuesByPath(dataObj, strings.Split(ref.ItemPath, "/")) {
+			targetRecord, targetErr := GetRecord(db, ref.TargetSchema, targetID)
+			if targetErr != nil {
+				return nil, targetErr
+			}
+
+			if targetRecord == nil {
+				return nil, ErrReferenceNotFound{
+					msg: fmt.Sprintf("%s: %s record %s not found", ref.ItemPath, ref.TargetSchema, targetID)}
+			}
+
+			if ref.TargetRevision > 0 && targetRecord.Revision != ref.TargetRevision {
+				return nil, ErrReferenceNotFound{
+					msg: fmt.Sprintf("%s: %s record %s is not revision %d",
+						ref.ItemPath, ref.TargetSchema, targetID, ref.TargetRevision)}
+			}
+
+			results = append(results, RecordReference{
+				SchemaName:     schemaName,
+				ItemPath:       ref.ItemPath,
+				TargetRecordID: targetID,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+//getValuesByPath collect all non empty string values located at path; array is expanded
+func getValuesByPath(data interface{}, path []string) []string {
+	if arr, ok := data.([]interface{}); ok {
+		results := []string{}
+		for _, item := range arr {
+			results = append(results, getValuesByPath(item, path)...)
+		}
+		return results
+	}
+
+	if len(path) == 0 {
+		if str, ok := data.(string); ok && str != "" {
+			return []string{str}
+		}
+		return []string{}
+	}
+
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return []string{}
+	}
+
+	return getValuesByPath(obj[path[0]], path[1:])
+}
+
+//AddRecordReferences save links from newly created record to its referenced records
+func AddRecordReferences(db rdbmstool.DbHandlerProxy, recordID string, refs []RecordReference) error {
+	for _, ref := range refs {
+		_, dbErr := db.Exec(`INSERT INTO doc_record_reference (record_id, item_path, target_record_id)
+		VALUES (?,?,?)`, recordID, ref.ItemPath, ref.TargetRecordID)
+		if dbErr != nil {
+			return fmt.Errorf("failed to save record %s reference %s into database: %s",
+				recordID, ref.ItemPath, dbErr.Error())
+		}
+	}
+
+	return nil
+}
+
+//GetReferencingRecords get records which reference to specified record (reverse lookup)
+//	sourceSchema filter referencing records by schema name, use empty string for all schemas
+func GetReferencingRecords(db rdbmstool.DbHandlerProxy, targetRecordID string,
+	sourceSchema string) ([]RecordReference, error) {
+	sqlStr := `SELECT a.record_id, c.name, a.item_path, a.target_record_id
+	FROM doc_record_reference a
+	JOIN doc_record b ON a.record_id = b.id
+	JOIN doc_schema c ON b.schema_id = c.id
+	WHERE a.target_record_id = ?`
+	params := []interface{}{targetRecordID}
+	if sourceSchema != "" {
+		sqlStr += " AND c.name = ?"
+		params = append(params, sourceSchema)
+	}
+	sqlStr += " ORDER BY c.name, a.record_id"
+
+	rows, rowsErr := db.Query(sqlStr, params...)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	results := []RecordReference{}
+	for rows.Next() {
+		ref := RecordReference{}
+		if err := rows.Scan(&ref.RecordID, &ref.SchemaName, &ref.ItemPath, &ref.TargetRecordID); err != nil {
+			if err == sql.ErrNoRows {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		results = append(results, ref)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,80 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractReferences(t *testing.T) {
+	xmlStr, refs, err := ExtractReferences(`<dxdoc name="po" revision="0">` +
+		`<dxref name="pr" schema="pr" revision="2"></dxref>` +
+		`<dxsection name="items" isArray="true"><dxref name="quotation" schema="quotation" isOptional="true"></dxref></dxsection>` +
+		`</dxdoc>`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if strings.Contains(xmlStr, "dxref") {
+		t.Errorf("expect dxref is replaced but get: %s", xmlStr)
+	}
+	if !strings.Contains(xmlStr, `<dxstr name="pr" lenLimit="36"></dxstr>`) {
+		t.Errorf("expect dxref is converted into dxstr but get: %s", xmlStr)
+	}
+
+	if len(refs) != 2 {
+		t.Fatalf("expect 2 references but get %d", len(refs))
+		return
+	}
+	if refs[0].ItemPath != "pr" || refs[0].TargetSchema != "pr" || refs[0].TargetRevision != 2 {
+		t.Errorf("unexpected first reference: %+v", refs[0])
+	}
+	if refs[1].ItemPath != "items/quotation" || !refs[1].IsOptional || refs[1].TargetRevision != 0 {
+		t.Errorf("unexpected second reference: %+v", refs[1])
+	}
+
+	backStr, backErr := ApplyReferences(xmlStr, refs)
+	if backErr != nil {
+		t.Fatal(backErr)
+		return
+	}
+	if !strings.Contains(backStr, `<dxref name="pr" schema="pr" revision="2"></dxref>`) {
+		t.Errorf("expect dxstr is converted back into dxref but get: %s", backStr)
+	}
+	if !strings.Contains(backStr, `<dxref name="quotation" isOptional="true" schema="quotation"></dxref>`) {
+		t.Errorf("expect nested dxstr is converted back into dxref but get: %s", backStr)
+	}
+
+	if _, _, err = ExtractReferences(`<dxdoc name="po"><dxref name="pr"></dxref></dxdoc>`); err == nil {
+		t.Errorf("expect dxref without target schema is rejected")
+	}
+
+	xmlStr, _, err = ExtractReferences(`<dxdoc name="po">` +
+		`<dxref name="pr" schema="pr" enableLenLimit="true" lenLimit="10"></dxref></dxdoc>`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if !strings.Contains(xmlStr, `<dxstr name="pr" lenLimit="36"></dxstr>`) {
+		t.Errorf("expect declared length limit of dxref is replaced but get: %s", xmlStr)
+	}
+}
+
+func TestGetValuesByPath(t *testing.T) {
+	data := map[string]interface{}{
+		"pr": "abc",
+		"items": []interface{}{
+			map[string]interface{}{"quotation": "q1"},
+			map[string]interface{}{"quotation": ""},
+			map[string]interface{}{"quotation": "q2"},
+		},
+	}
+
+	if values := getValuesByPath(data, []string{"pr"}); len(values) != 1 || values[0] != "abc" {
+		t.Errorf("expect ['abc'] but get %v", values)
+	}
+
+	if values := getValuesByPath(data, []string{"items", "quotation"}); len(values) != 2 {
+		t.Errorf("expect 2 non empty values but get %v", values)
+	}
+}
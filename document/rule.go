@@ -0,0 +1,186 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guinso/rdbmstool"
+	lua "github.com/yuin/gopher-lua"
+)
+
+//RuleScriptTimeout maximum time a rule script may run for a document
+const RuleScriptTimeout = 2 * time.Second
+
+//RuleScript Lua script of cross-field validation rules carried by schema revision
+//
+//	document data is exposed as global table 'doc' (array is 1-based);
+//	call addError(path, message) to report a failed rule, path is JSON pointer; e.g.
+//
+//	if doc.needAudit and doc.auditor == nil then
+//		addError("/auditor", "auditor is required when needAudit is true")
+//	end
+type RuleScript struct {
+	Script string `json:"script"`
+}
+
+//JSON export to JSON string
+func (rule *RuleScript) JSON() string {
+	jsonRaw, _ := json.Marshal(rule)
+
+	return string(jsonRaw)
+}
+
+//ParseRuleScriptFromJSON convert JSON string into RuleScript and check Lua syntax
+func ParseRuleScriptFromJSON(jsonStr string) (*RuleScript, error) {
+	rule := RuleScript{}
+	if err := json.Unmarshal([]byte(jsonStr), &rule); err != nil {
+		return nil, fmt.Errorf("invalid rule script JSON: %s", err.Error())
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+//Validate check rule script can be compiled
+func (rule *RuleScript) Validate() error {
+	L := lua.NewState()
+	defer L.Close()
+
+	if _, err := L.LoadString(rule.Script); err != nil {
+		return fmt.Errorf("invalid rule script: %s", err.Error())
+	}
+
+	return nil
+}
+
+//GetRuleScript get rule script of schema revision (use -1 for draft)
+//return empty script if revision has no rule script
+func GetRuleScript(db rdbmstool.DbHandlerProxy, schemaName string, revision int) (*RuleScript, error) {
+	row := db.QueryRow(`SELECT a.script FROM doc_schema_rule a
+	JOIN doc_schema b ON a.schema_id = b.id
+	WHERE b.name = ? AND a.revision = ?`, schemaName, revision)
+
+	rule := RuleScript{}
+	if err := row.Scan(&rule.Script); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to fetch rule script of %s rev%d from database: %s",
+			schemaName, revision, err.Error())
+	}
+
+	return &rule, nil
+}
+
+//SaveDraftRuleScript declare or overwrite rule script of schema draft
+//rule script is released together with draft, see SaveDraftToNewRevision
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrDraftNotFound error will return if schema has no draft
+func SaveDraftRuleScript(db rdbmstool.DbHandlerProxy, schemaName string, rule *RuleScript) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	draft, draftErr := GetDraftSchema(db, schemaName)
+	if draftErr != nil {
+		return draftErr
+	}
+	if draft == nil {
+		return ErrDraftNotFound{msg: fmt.Sprintf("no draft found for %s", schemaName)}
+	}
+
+	_, dbErr := db.Exec(`INSERT INTO doc_schema_rule (schema_id, revision, script) VALUES (?,-1,?)
+	ON DUPLICATE KEY UPDATE script = VALUES(script)`, schemaInfo.ID, rule.Script)
+	if dbErr != nil {
+		return fmt.Errorf("failed to save rule script of %s into database: %s", schemaName, dbErr.Error())
+	}
+
+	return nil
+}
+
+//DecodeJSONData convert JSON document data into map, number is kept as json.Number
+func DecodeJSONData(jsonStr string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	decoder.UseNumber() //keep decimal places as written
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %s", err.Error())
+	}
+
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON data must be an object")
+	}
+
+	return obj, nil
+}
+
+//Run execute rule script against document data with Lua runner and collect every failed rule
+//	data is decoded document, see DecodeJSONData, ParseXMLData and TypeXMLData
+//return error if script fail to run
+//NOTE: script globals persist in Lua runner; use a fresh runner for each record
+func (rule *RuleScript) Run(L *lua.LState, data map[string]interface{}) ([]Violation, error) {
+	violations := []Violation{}
+	if strings.TrimSpace(rule.Script) == "" {
+		return violations, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RuleScriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	L.SetGlobal("doc", toLuaValue(L, data))
+	L.SetGlobal("addError", L.NewFunction(func(L *lua.LState) int {
+		violations = append(violations, Violation{Path: L.ToString(1), Rule: RuleCustom, Message: L.ToString(2)})
+		return 0
+	}))
+
+	if err := L.DoString(rule.Script); err != nil {
+		return nil, fmt.Errorf("failed to run rule script: %s", err.Error())
+	}
+
+	return violations, nil
+}
+
+//toLuaValue convert decoded document value into Lua value
+func toLuaValue(L *lua.LState, value interface{}) lua.LValue {
+	switch x := value.(type) {
+	case map[string]interface{}:
+		table := L.NewTable()
+		for key, subValue := range x {
+			table.RawSetString(key, toLuaValue(L, subValue))
+		}
+		return table
+	case []interface{}:
+		table := L.NewTable()
+		for index, subValue := range x {
+			table.RawSetInt(index+1, toLuaValue(L, subValue))
+		}
+		return table
+	case json.Number:
+		tmpFloat, _ := strconv.ParseFloat(x.String(), 64)
+		return lua.LNumber(tmpFloat)
+	case float64:
+		return lua.LNumber(x)
+	case string:
+		return lua.LString(x)
+	case bool:
+		return lua.LBool(x)
+	}
+
+	return lua.LNil
+}
@@ -0,0 +1,137 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/guinso/gxdoc/luascript"
+	"github.com/guinso/gxdoc/testutil"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestRuleScriptRun(t *testing.T) {
+	rule := RuleScript{Script: `
+if doc.needAudit and doc.auditor == nil then
+	addError("/auditor", "auditor is required when needAudit is true")
+end
+
+local sum = 0
+for _, item in ipairs(doc.items) do
+	sum = sum + item.qty
+end
+if sum ~= doc.totalQty then
+	addError("/totalQty", "totalQty must equal to sum of item qty")
+end`}
+
+	data, err := DecodeJSONData(`{"needAudit": true, "totalQty": 5, "items": [{"qty": 1}, {"qty": 2}]}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	violations, runErr := rule.Run(L, data)
+	if runErr != nil {
+		t.Fatal(runErr)
+		return
+	}
+
+	if len(violations) != 2 {
+		t.Fatalf("expect 2 violations but get %d: %v", len(violations), violations)
+	}
+	if violations[0].Path != "/auditor" || violations[1].Path != "/totalQty" ||
+		violations[0].Rule != RuleCustom {
+		t.Errorf("expect /auditor and /totalQty custom violations but get %v", violations)
+	}
+
+	data, _ = DecodeJSONData(`{"needAudit": false, "totalQty": 3, "items": [{"qty": 1}, {"qty": 2}]}`)
+	if violations, _ = rule.Run(L, data); len(violations) != 0 {
+		t.Errorf("expect data pass all rules but get %v", violations)
+	}
+}
+
+func TestRuleScriptValidate(t *testing.T) {
+	if _, err := ParseRuleScriptFromJSON(`{"script": "if then end"}`); err == nil {
+		t.Errorf("expect rule script with syntax error is rejected")
+	}
+
+	if _, err := ParseRuleScriptFromJSON(`{"script": "addError('/a', 'b')"}`); err != nil {
+		t.Errorf("expect valid rule script is accepted: %s", err.Error())
+	}
+}
+
+func TestRuleScriptSandbox(t *testing.T) {
+	rule := RuleScript{Script: `
+if io ~= nil or os ~= nil or dofile ~= nil or require ~= nil or QuerySQL ~= nil then
+	addError("/", "rule script can reach outside of sandbox")
+end
+addError("/code", string.upper(doc.code))`}
+
+	data, _ := DecodeJSONData(`{"code": "abc"}`)
+
+	L := luascript.GetSandboxRunner()
+	defer L.Close()
+
+	violations, runErr := rule.Run(L, data)
+	if runErr != nil {
+		t.Fatal(runErr)
+		return
+	}
+	if len(violations) != 1 || violations[0].Message != "ABC" {
+		t.Errorf("expect only string library is usable in sandbox but get %v", violations)
+	}
+}
+
+func TestReleasedRuleScript(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	draftRule := &RuleScript{Script: `if doc.qty > 10 then addError("/qty", "qty must not exceed 10") end`}
+	if err := SaveDraftRuleScript(trx, "pr", draftRule); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if err := SaveDraftToNewRevision(trx, "pr"); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	rule, ruleErr := GetRuleScript(trx, "pr", 2)
+	if ruleErr != nil {
+		t.Fatal(ruleErr)
+		return
+	}
+	if rule.Script != draftRule.Script {
+		t.Fatalf("expect released revision 2 carry draft rule script but get '%s'", rule.Script)
+	}
+
+	if draft, _ := GetRuleScript(trx, "pr", -1); draft.Script != "" {
+		t.Errorf("expect draft rule script is moved into released revision")
+	}
+
+	L := luascript.GetSandboxRunner()
+	defer L.Close()
+
+	data, _ := DecodeJSONData(`{"qty": 11, "pr number": "PR01"}`)
+	violations, runErr := rule.Run(L, data)
+	if runErr != nil {
+		t.Fatal(runErr)
+		return
+	}
+	if len(violations) != 1 || violations[0].Path != "/qty" {
+		t.Errorf("expect released rule reject qty over 10 but get %v", violations)
+	}
+}
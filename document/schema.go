@@ -13,7 +13,7 @@ func GetDraftSchema(db rdbmstool.DbHandlerProxy, name string) (*gxschema.DxDoc,
 	return GetSchemaByRevision(db, name, -1)
 }
 
-//GetSchema get latest document schema from database
+//GetSchema get latest document schema from database, with included items spliced in
 func GetSchema(db rdbmstool.DbHandlerProxy, name string) (*gxschema.DxDoc, error) {
 	sqlStr := `SELECT b.xml_definition, a.latest_revision, a.schema_id 
 	FROM (
@@ -48,11 +48,21 @@ func GetSchema(db rdbmstool.DbHandlerProxy, name string) (*gxschema.DxDoc, error
 	dxdoc.Revision = tmpRev
 	dxdoc.ID = tmpID
 
-	return dxdoc, nil
+	return flattenSchema(db, dxdoc, []string{})
 }
 
-//GetSchemaByRevision get document schema from database by revision
+//GetSchemaByRevision get document schema from database by revision, with included items spliced in
 func GetSchemaByRevision(db rdbmstool.DbHandlerProxy, name string, revision int) (*gxschema.DxDoc, error) {
+	dxdoc, dxErr := GetDeclaredSchemaByRevision(db, name, revision)
+	if dxErr != nil || dxdoc == nil {
+		return dxdoc, dxErr
+	}
+
+	return flattenSchema(db, dxdoc, []string{})
+}
+
+//GetDeclaredSchemaByRevision get document schema from database by revision, without included items
+func GetDeclaredSchemaByRevision(db rdbmstool.DbHandlerProxy, name string, revision int) (*gxschema.DxDoc, error) {
 	sqlStr := `SELECT a.xml_definition, a.schema_id FROM doc_schema_revision a
 	JOIN doc_schema b ON a.schema_id = b.id
 	WHERE b.name = ? AND a.revision = ?`
@@ -86,7 +96,7 @@ func GetSchemaByRevision(db rdbmstool.DbHandlerProxy, name string, revision int)
 	return dxdoc, nil
 }
 
-//GetSchemaByID get document schema from database by schema_id
+//GetSchemaByID get latest document schema from database by schema_id, with included items spliced in
 func GetSchemaByID(db rdbmstool.DbHandlerProxy, id string) (*gxschema.DxDoc, error) {
 	sqlStr := `SELECT b.xml_definition, a.latest_revision, c.name
 	FROM (
@@ -121,14 +131,20 @@ func GetSchemaByID(db rdbmstool.DbHandlerProxy, id string) (*gxschema.DxDoc, err
 	dxdoc.Revision = tmpRev
 	dxdoc.ID = id
 
-	return dxdoc, nil
+	return flattenSchema(db, dxdoc, []string{})
 }
 
 //AddSchema register document schema into database
 //RETURN:
 //	int: latest revision number
 //NOTE: ErrSchemaInfoNotFound error will return if document not register yet in doc_schema datatable
+//NOTE: ErrSchemaLint error will return if schema has lint error
+//NOTE: hash of new revision cover XML definition only; call SealSchemaRevision after saving its references and includes
 func AddSchema(db rdbmstool.DbHandlerProxy, schemaName string, doc *gxschema.DxDoc, remark string) (int, error) {
+	if err := checkSchemaLint(schemaName, doc); err != nil {
+		return 0, err
+	}
+
 	//check SchemaInfo is registered
 	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
 	if infoErr != nil {
@@ -177,8 +193,20 @@ func AddSchema(db rdbmstool.DbHandlerProxy, schemaName string, doc *gxschema.DxD
 		return 0, fmt.Errorf("failed to get XML definition: %s", xmlErr.Error())
 	}
 
-	_, insertErr := db.Exec(`INSERT INTO doc_schema_revision (schema_id,revision,xml_definition,remark) VALUES (?,?,?,?)`,
-		schemaInfo.ID, revision, xmlStr, remark)
+	//chain new revision to previous revision's hash
+	prevHash, hashErr := getRevisionHash(db, schemaInfo.ID, revision-1)
+	if hashErr != nil {
+		return 0, hashErr
+	}
+	//hash cover XML definition only, see SealSchemaRevision
+	contentHash, contentErr := computeRevisionHash(prevHash, schemaInfo.ID,
+		&BundleRevision{Revision: revision, xmlDef: xmlStr})
+	if contentErr != nil {
+		return 0, contentErr
+	}
+
+	_, insertErr := db.Exec(`INSERT INTO doc_schema_revision (schema_id,revision,xml_definition,remark,content_hash,prev_hash) VALUES (?,?,?,?,?,?)`,
+		schemaInfo.ID, revision, xmlStr, remark, contentHash, prevHash)
 
 	if insertErr != nil {
 		return 0, fmt.Errorf("failed to register new %s definition into database: %s",
@@ -192,7 +220,12 @@ func AddSchema(db rdbmstool.DbHandlerProxy, schemaName string, doc *gxschema.DxD
 //	draft doc schema shall not affect production record
 //NOTE: if draft already exists, XML definition and remark will be overwriten
 //NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrSchemaLint error will return if schema has lint error
 func SaveSchemaAsDraft(db rdbmstool.DbHandlerProxy, schemaName string, doc *gxschema.DxDoc, remark string) error {
+	if err := checkSchemaLint(schemaName, doc); err != nil {
+		return err
+	}
+
 	xmlStr, xmlErr := doc.XML()
 	if xmlErr != nil {
 		return fmt.Errorf("failed convert doc schema into XML schema format: %s", xmlErr.Error())
@@ -241,6 +274,7 @@ func SaveSchemaAsDraft(db rdbmstool.DbHandlerProxy, schemaName string, doc *gxsc
 
 //SaveDraftToNewRevision convert draft into new revision
 //Will return ErrDraftNotFound error if no draft available
+//Will return ErrSchemaLint error if draft has lint error
 func SaveDraftToNewRevision(db rdbmstool.DbHandlerProxy, schemaName string) error {
 	//check SchemaInfo is registered
 	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
@@ -251,9 +285,9 @@ func SaveDraftToNewRevision(db rdbmstool.DbHandlerProxy, schemaName string) erro
 		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
 	}
 
-	row := db.QueryRow(`SELECT COUNT(schema_id) FROM doc_schema_revision WHERE schema_id = ? AND revision = -1`, schemaInfo.ID)
-	var tmpInt int
-	rowErr := row.Scan(&tmpInt)
+	row := db.QueryRow(`SELECT xml_definition FROM doc_schema_revision WHERE schema_id = ? AND revision = -1`, schemaInfo.ID)
+	var tmpXML string
+	rowErr := row.Scan(&tmpXML)
 	if rowErr != nil {
 		if rowErr == sql.ErrNoRows {
 			return ErrDraftNotFound{msg: fmt.Sprintf("no draft found for %s", schemaName)}
@@ -262,17 +296,82 @@ func SaveDraftToNewRevision(db rdbmstool.DbHandlerProxy, schemaName string) erro
 		return fmt.Errorf("failed to fetch record from database: %s", rowErr.Error())
 	}
 
-	if tmpInt == 0 {
-		return ErrDraftNotFound{msg: fmt.Sprintf("no draft found for %s", schemaName)}
+	draft, draftErr := GetSchemaByRevision(db, schemaName, -1)
+	if draftErr != nil {
+		return draftErr
+	}
+	if err := checkSchemaLint(schemaName, draft); err != nil {
+		return err
+	}
+
+	newRevision := schemaInfo.LatestRevision + 1
+
+	//chain released draft to previous revision's hash
+	prevHash, hashErr := getRevisionHash(db, schemaInfo.ID, newRevision-1)
+	if hashErr != nil {
+		return hashErr
 	}
 
 	_, updateErr := db.Exec(
-		`UPDATE doc_schema_revision SET revision = ? WHERE schema_id = ? AND revision = -1`,
-		schemaInfo.LatestRevision+1, schemaInfo.ID)
+		`UPDATE doc_schema_revision SET revision = ?, prev_hash = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, prevHash, schemaInfo.ID)
 	if updateErr != nil {
 		return fmt.Errorf("failed to convert %s draft mode to release revision: %s",
 			schemaInfo.Name, updateErr.Error())
 	}
 
+	_, refErr := db.Exec(
+		`UPDATE doc_schema_reference SET revision = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, schemaInfo.ID)
+	if refErr != nil {
+		return fmt.Errorf("failed to convert %s draft references to release revision: %s",
+			schemaInfo.Name, refErr.Error())
+	}
+
+	_, includeErr := db.Exec(
+		`UPDATE doc_schema_include SET revision = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, schemaInfo.ID)
+	if includeErr != nil {
+		return fmt.Errorf("failed to convert %s draft includes to release revision: %s",
+			schemaInfo.Name, includeErr.Error())
+	}
+
+	_, localeErr := db.Exec(
+		`UPDATE doc_schema_locale SET revision = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, schemaInfo.ID)
+	if localeErr != nil {
+		return fmt.Errorf("failed to convert %s draft locales to release revision: %s",
+			schemaInfo.Name, localeErr.Error())
+	}
+
+	_, ruleErr := db.Exec(
+		`UPDATE doc_schema_rule SET revision = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, schemaInfo.ID)
+	if ruleErr != nil {
+		return fmt.Errorf("failed to convert %s draft rule script to release revision: %s",
+			schemaInfo.Name, ruleErr.Error())
+	}
+
+	_, computedErr := db.Exec(
+		`UPDATE doc_schema_computed SET revision = ? WHERE schema_id = ? AND revision = -1`,
+		newRevision, schemaInfo.ID)
+	if computedErr != nil {
+		return fmt.Errorf("failed to convert %s draft computed fields to release revision: %s",
+			schemaInfo.Name, computedErr.Error())
+	}
+
+	//hash released revision together with everything moved from draft
+	return SealSchemaRevision(db, schemaName, newRevision)
+}
+
+//checkSchemaLint reject schema which has lint error
+func checkSchemaLint(schemaName string, doc *gxschema.DxDoc) error {
+	issues := LintSchema(doc)
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return ErrSchemaLint{msg: fmt.Sprintf("%s has lint error: %s", schemaName, issue.Error()), Issues: issues}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,623 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+)
+
+//importDefaultPrecision precision of decimal item when source schema doesn't specify one
+const importDefaultPrecision = 6
+
+//ImportWarning construct of source schema which is not supported and ignored or approximated on import
+type ImportWarning struct {
+	Path    string `json:"path"` //item path, nested section item is joined by '/'; empty for whole schema
+	Message string `json:"message"`
+}
+
+func (warning ImportWarning) String() string {
+	if warning.Path == "" {
+		return warning.Message
+	}
+
+	return warning.Path + ": " + warning.Message
+}
+
+//schemaImporter collect warnings while converting source schema into schema items
+type schemaImporter struct {
+	warnings []ImportWarning
+}
+
+func (importer *schemaImporter) warn(path string, format string, args ...interface{}) {
+	importer.warnings = append(importer.warnings, ImportWarning{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func joinItemPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "/" + name
+}
+
+//newSchemaItem create schema item of specified kind
+func newSchemaItem(name string, kind string, isOptional bool, isArray bool, lenLimit int, precision int,
+	items []gxschema.DxItem) gxschema.DxItem {
+	switch kind {
+	case ItemInt:
+		return &gxschema.DxInt{Name: name, IsOptional: isOptional, IsArray: isArray}
+	case ItemStr:
+		return &gxschema.DxStr{Name: name, IsOptional: isOptional, IsArray: isArray,
+			EnableLenLimit: lenLimit > 0, LenLimit: lenLimit}
+	case ItemBool:
+		return &gxschema.DxBool{Name: name, IsOptional: isOptional, IsArray: isArray}
+	case ItemDecimal:
+		return &gxschema.DxDecimal{Name: name, IsOptional: isOptional, IsArray: isArray, Precision: precision}
+	case ItemFile:
+		return &gxschema.DxFile{Name: name, IsOptional: isOptional, IsArray: isArray}
+	}
+
+	return &gxschema.DxSection{Name: name, IsOptional: isOptional, IsArray: isArray, Items: items}
+}
+
+//ImportFromJSONSchema convert JSON Schema into document schema draft named as schemaName
+//	root must be an object schema; property order is preserved as item order
+//	integer, string, boolean, number, object and array are mapped into int, str, bool, decimal, section and isArray;
+//	maxLength become lenLimit, multipleOf (e.g. 0.01) become precision, and property not listed in required is optional
+//return warnings of constructs which are not supported and ignored or approximated
+func ImportFromJSONSchema(schemaName string, jsonStr string) (*gxschema.DxDoc, []ImportWarning, error) {
+	root := jsonSchemaNode{}
+	if err := json.Unmarshal([]byte(jsonStr), &root); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON Schema: %s", err.Error())
+	}
+	if types := root.getTypes(); root.Properties == nil && !(len(types) == 1 && types[0] == "object") {
+		return nil, nil, fmt.Errorf("invalid JSON Schema: root must be an object schema")
+	}
+
+	importer := schemaImporter{warnings: []ImportWarning{}}
+	importer.checkJSONKeywords(&root, "", true)
+
+	items := importer.importJSONProperties(&root, "")
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("invalid JSON Schema: root has no supported property")
+	}
+
+	return &gxschema.DxDoc{Name: schemaName, Revision: -1, Items: items}, importer.warnings, nil
+}
+
+//jsonSchemaNode subset of JSON Schema keywords understood by importer
+type jsonSchemaNode struct {
+	Type        json.RawMessage       `json:"type"`
+	Description string                `json:"description"`
+	Format      string                `json:"format"`
+	MaxLength   *int                  `json:"maxLength"`
+	MultipleOf  *float64              `json:"multipleOf"`
+	Properties  *jsonSchemaProperties `json:"properties"`
+	Required    []string              `json:"required"`
+	Items       *jsonSchemaNode       `json:"items"`
+
+	keywords []string //every keyword found in source, for reporting unsupported ones
+}
+
+func (node *jsonSchemaNode) UnmarshalJSON(raw []byte) error {
+	type plainNode jsonSchemaNode
+	if err := json.Unmarshal(raw, (*plainNode)(node)); err != nil {
+		return err
+	}
+
+	keywords := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &keywords); err != nil {
+		return err
+	}
+	for keyword := range keywords {
+		node.keywords = append(node.keywords, keyword)
+	}
+	sort.Strings(node.keywords)
+
+	return nil
+}
+
+//getTypes get declared types, 'type' can be either a string or an array of string
+func (node *jsonSchemaNode) getTypes() []string {
+	if len(node.Type) == 0 {
+		return []string{}
+	}
+
+	var single string
+	if err := json.Unmarshal(node.Type, &single); err == nil {
+		return []string{single}
+	}
+
+	multiple := []string{}
+	json.Unmarshal(node.Type, &multiple)
+
+	return multiple
+}
+
+//jsonSchemaProperties properties of object schema in declared order
+type jsonSchemaProperties struct {
+	names []string
+	nodes map[string]*jsonSchemaNode
+}
+
+func (props *jsonSchemaProperties) UnmarshalJSON(raw []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("properties must be an object")
+	}
+
+	props.nodes = map[string]*jsonSchemaNode{}
+	for decoder.More() {
+		token, tokenErr := decoder.Token()
+		if tokenErr != nil {
+			return tokenErr
+		}
+
+		name, _ := token.(string)
+		node := jsonSchemaNode{}
+		if err := decoder.Decode(&node); err != nil {
+			return fmt.Errorf("property %s: %s", name, err.Error())
+		}
+
+		if _, exists := props.nodes[name]; !exists {
+			props.names = append(props.names, name)
+		}
+		props.nodes[name] = &node
+	}
+
+	return nil
+}
+
+//jsonSchemaKeywords keywords which are mapped or safe to ignore
+var jsonSchemaKeywords = map[string]bool{
+	"type": true, "description": true, "format": true, "maxLength": true, "multipleOf": true,
+	"properties": true, "required": true, "items": true, "title": true, "$comment": true,
+	"additionalProperties": true, "examples": true, "default": true,
+}
+
+//jsonSchemaRootKeywords keywords which only make sense at root
+var jsonSchemaRootKeywords = map[string]bool{"$schema": true, "$id": true, "$defs": true}
+
+func (importer *schemaImporter) checkJSONKeywords(node *jsonSchemaNode, path string, isRoot bool) {
+	for _, keyword := range node.keywords {
+		if jsonSchemaKeywords[keyword] || (isRoot && jsonSchemaRootKeywords[keyword]) {
+			continue
+		}
+
+		importer.warn(path, "keyword '%s' is not supported and ignored", keyword)
+	}
+}
+
+func (importer *schemaImporter) importJSONProperties(node *jsonSchemaNode, path string) []gxschema.DxItem {
+	items := []gxschema.DxItem{}
+	if node.Properties == nil {
+		return items
+	}
+
+	required := map[string]bool{}
+	for _, name := range node.Required {
+		required[name] = true
+	}
+
+	for _, name := range node.Properties.names {
+		item := importer.importJSONProperty(name, node.Properties.nodes[name], joinItemPath(path, name), !required[name])
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func (importer *schemaImporter) importJSONProperty(name string, node *jsonSchemaNode, path string,
+	isOptional bool) gxschema.DxItem {
+	importer.checkJSONKeywords(node, path, false)
+
+	isArray := false
+	kind, isNullable := importer.getJSONKind(node, path)
+	if kind == "array" {
+		if node.Items == nil {
+			importer.warn(path, "array without 'items' is not supported and skipped")
+			return nil
+		}
+
+		isArray = true
+		node = node.Items
+		importer.checkJSONKeywords(node, path, false)
+
+		kind, _ = importer.getJSONKind(node, path)
+		if kind == "array" {
+			importer.warn(path, "nested array is not supported and skipped")
+			return nil
+		}
+	}
+	if kind == "" {
+		return nil
+	}
+	if isNullable {
+		isOptional = true
+	}
+
+	lenLimit := 0
+	if kind == ItemStr {
+		if node.MaxLength != nil && *node.MaxLength > 0 {
+			lenLimit = *node.MaxLength
+		}
+		if node.Format != "" {
+			importer.warn(path, "format '%s' is not supported, imported as str", node.Format)
+		}
+	}
+
+	precision := 0
+	if kind == ItemDecimal {
+		precision = importer.getJSONPrecision(node, path)
+	}
+
+	var items []gxschema.DxItem
+	if kind == ItemSection {
+		items = importer.importJSONProperties(node, path)
+		if len(items) == 0 {
+			importer.warn(path, "object without supported property is skipped")
+			return nil
+		}
+	}
+
+	return newSchemaItem(name, kind, isOptional, isArray, lenLimit, precision, items)
+}
+
+//getJSONKind map JSON Schema type into item kind ("array" for array), return empty if not supported
+func (importer *schemaImporter) getJSONKind(node *jsonSchemaNode, path string) (string, bool) {
+	types := []string{}
+	isNullable := false
+	for _, typeName := range node.getTypes() {
+		if typeName == "null" {
+			isNullable = true
+		} else {
+			types = append(types, typeName)
+		}
+	}
+
+	if len(types) == 0 {
+		if node.Description == "file" {
+			return ItemFile, isNullable //file item exported by ToJSONSchema
+		}
+
+		importer.warn(path, "property without type is not supported and skipped")
+		return "", isNullable
+	}
+	if len(types) > 1 {
+		importer.warn(path, "multiple types %v is not supported and skipped", types)
+		return "", isNullable
+	}
+
+	switch types[0] {
+	case "integer":
+		return ItemInt, isNullable
+	case "string":
+		return ItemStr, isNullable
+	case "boolean":
+		return ItemBool, isNullable
+	case "number":
+		return ItemDecimal, isNullable
+	case "object":
+		return ItemSection, isNullable
+	case "array":
+		return "array", isNullable
+	}
+
+	importer.warn(path, "type '%s' is not supported and skipped", types[0])
+	return "", isNullable
+}
+
+//getJSONPrecision get decimal places from multipleOf, e.g. 0.01 is 2 decimal places
+func (importer *schemaImporter) getJSONPrecision(node *jsonSchemaNode, path string) int {
+	if node.MultipleOf == nil {
+		importer.warn(path, "number without multipleOf is imported with precision %d", importDefaultPrecision)
+		return importDefaultPrecision
+	}
+
+	text := strconv.FormatFloat(*node.MultipleOf, 'f', -1, 64)
+	precision := 0
+	if index := strings.Index(text, "."); index >= 0 {
+		precision = len(text) - index - 1
+	}
+	if strings.TrimLeft(strings.Replace(text, ".", "", 1), "0") != "1" {
+		importer.warn(path, "multipleOf %s is not a power of ten, imported with precision %d", text, precision)
+	}
+
+	return precision
+}
+
+//ImportFromXSD convert XML Schema (XSD) into document schema draft named as schemaName
+//	first global element is document root, its sequence (or all) of child elements become items;
+//	built-in simple types are mapped into int, str, bool or decimal, complex type become section,
+//	minOccurs="0" become optional, maxOccurs greater than 1 become isArray,
+//	maxLength and fractionDigits facets become lenLimit and precision
+//return warnings of constructs which are not supported and ignored or approximated
+func ImportFromXSD(schemaName string, xsdStr string) (*gxschema.DxDoc, []ImportWarning, error) {
+	schema := xsdSchema{}
+	if err := xml.Unmarshal([]byte(xsdStr), &schema); err != nil {
+		return nil, nil, fmt.Errorf("invalid XSD: %s", err.Error())
+	}
+	if schema.XMLName.Local != "schema" {
+		return nil, nil, fmt.Errorf("invalid XSD: root element must be xs:schema")
+	}
+	if len(schema.Elements) == 0 {
+		return nil, nil, fmt.Errorf("invalid XSD: no global element declared")
+	}
+
+	importer := xsdImporter{
+		schemaImporter: schemaImporter{warnings: []ImportWarning{}},
+		complexTypes:   map[string]*xsdComplexType{},
+		simpleTypes:    map[string]*xsdSimpleType{},
+		resolving:      map[string]bool{},
+	}
+	for index := range schema.ComplexTypes {
+		importer.complexTypes[schema.ComplexTypes[index].Name] = &schema.ComplexTypes[index]
+	}
+	for index := range schema.SimpleTypes {
+		importer.simpleTypes[schema.SimpleTypes[index].Name] = &schema.SimpleTypes[index]
+	}
+	for _, other := range unsupportedXSD(schema.Others) {
+		importer.warn("", "xs:%s is not supported and ignored", other.XMLName.Local)
+	}
+	for _, element := range schema.Elements[1:] {
+		importer.warn("", "global element '%s' is ignored, only first global element is imported", element.Name)
+	}
+
+	root := schema.Elements[0]
+	complexType := importer.getComplexType(&root)
+	if complexType == nil {
+		return nil, nil, fmt.Errorf("invalid XSD: root element %s must be a complex type", root.Name)
+	}
+
+	items := importer.importComplexType(complexType, "")
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("invalid XSD: root element %s has no supported child element", root.Name)
+	}
+
+	return &gxschema.DxDoc{Name: schemaName, Revision: -1, Items: items}, importer.warnings, nil
+}
+
+type xsdSchema struct {
+	XMLName      xml.Name
+	Elements     []xsdElement     `xml:"element"`
+	ComplexTypes []xsdComplexType `xml:"complexType"`
+	SimpleTypes  []xsdSimpleType  `xml:"simpleType"`
+	Others       []xsdAny         `xml:",any"`
+}
+
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	Ref         string          `xml:"ref,attr"`
+	MinOccurs   string          `xml:"minOccurs,attr"`
+	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	Nillable    string          `xml:"nillable,attr"`
+	SimpleType  *xsdSimpleType  `xml:"simpleType"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+}
+
+type xsdComplexType struct {
+	Name       string    `xml:"name,attr"`
+	Sequence   *xsdGroup `xml:"sequence"`
+	All        *xsdGroup `xml:"all"`
+	Choice     *xsdGroup `xml:"choice"`
+	Attributes []xsdAny  `xml:"attribute"`
+	Others     []xsdAny  `xml:",any"`
+}
+
+type xsdGroup struct {
+	Elements []xsdElement `xml:"element"`
+	Others   []xsdAny     `xml:",any"`
+}
+
+type xsdSimpleType struct {
+	Name        string          `xml:"name,attr"`
+	Restriction *xsdRestriction `xml:"restriction"`
+	Others      []xsdAny        `xml:",any"`
+}
+
+type xsdRestriction struct {
+	Base           string    `xml:"base,attr"`
+	MaxLength      *xsdFacet `xml:"maxLength"`
+	Length         *xsdFacet `xml:"length"`
+	FractionDigits *xsdFacet `xml:"fractionDigits"`
+	Others         []xsdAny  `xml:",any"`
+}
+
+type xsdFacet struct {
+	Value string `xml:"value,attr"`
+}
+
+type xsdAny struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+}
+
+//unsupportedXSD drop documentation from unrecognized XSD constructs
+func unsupportedXSD(others []xsdAny) []xsdAny {
+	results := []xsdAny{}
+	for _, other := range others {
+		if other.XMLName.Local != "annotation" {
+			results = append(results, other)
+		}
+	}
+
+	return results
+}
+
+//xsdImporter resolve named types declared in XSD while importing
+type xsdImporter struct {
+	schemaImporter
+	complexTypes map[string]*xsdComplexType
+	simpleTypes  map[string]*xsdSimpleType
+	resolving    map[string]bool //named complex types being imported, to stop recursive type
+}
+
+//localName remove namespace prefix, e.g. xs:string become string
+func localName(qname string) string {
+	if index := strings.LastIndex(qname, ":"); index >= 0 {
+		return qname[index+1:]
+	}
+
+	return qname
+}
+
+//getComplexType get inline or named complex type of element, return nil if element is simple type
+func (importer *xsdImporter) getComplexType(element *xsdElement) *xsdComplexType {
+	if element.ComplexType != nil {
+		return element.ComplexType
+	}
+	if element.Type != "" {
+		return importer.complexTypes[localName(element.Type)]
+	}
+
+	return nil
+}
+
+func (importer *xsdImporter) importComplexType(complexType *xsdComplexType, path string) []gxschema.DxItem {
+	for _, attr := range complexType.Attributes {
+		importer.warn(path, "attribute '%s' is not supported and ignored", attr.Name)
+	}
+	for _, other := range unsupportedXSD(complexType.Others) {
+		importer.warn(path, "xs:%s is not supported and ignored", other.XMLName.Local)
+	}
+
+	items := []gxschema.DxItem{}
+	groups := []*xsdGroup{complexType.Sequence, complexType.All, complexType.Choice}
+	for groupIndex, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		isChoice := groupIndex == 2
+		if isChoice {
+			importer.warn(path, "xs:choice is not supported, its elements are imported as optional items")
+		}
+		for _, other := range unsupportedXSD(group.Others) {
+			importer.warn(path, "nested xs:%s is not supported and ignored", other.XMLName.Local)
+		}
+
+		for index := range group.Elements {
+			if item := importer.importElement(&group.Elements[index], path, isChoice); item != nil {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+func (importer *xsdImporter) importElement(element *xsdElement, path string, isOptional bool) gxschema.DxItem {
+	if element.Ref != "" {
+		importer.warn(path, "element reference '%s' is not supported and skipped", element.Ref)
+		return nil
+	}
+
+	itemPath := joinItemPath(path, element.Name)
+	isOptional = isOptional || element.MinOccurs == "0" || element.Nillable == "true"
+	isArray := element.MaxOccurs == "unbounded"
+	if maxOccurs, err := strconv.Atoi(element.MaxOccurs); err == nil && maxOccurs > 1 {
+		isArray = true
+	}
+
+	if complexType := importer.getComplexType(element); complexType != nil {
+		typeName := localName(element.Type)
+		if typeName != "" && element.ComplexType == nil {
+			if importer.resolving[typeName] {
+				importer.warn(itemPath, "recursive type '%s' is not supported and skipped", typeName)
+				return nil
+			}
+
+			importer.resolving[typeName] = true
+			defer delete(importer.resolving, typeName)
+		}
+
+		items := importer.importComplexType(complexType, itemPath)
+		if len(items) == 0 {
+			importer.warn(itemPath, "element without supported child element is skipped")
+			return nil
+		}
+
+		return newSchemaItem(element.Name, ItemSection, isOptional, isArray, 0, 0, items)
+	}
+
+	kind, lenLimit, precision := importer.getSimpleKind(element, itemPath)
+	if kind == "" {
+		return nil
+	}
+
+	return newSchemaItem(element.Name, kind, isOptional, isArray, lenLimit, precision, nil)
+}
+
+//getSimpleKind map simple type of element into item kind, return empty kind if not supported
+func (importer *xsdImporter) getSimpleKind(element *xsdElement, path string) (string, int, int) {
+	simpleType := element.SimpleType
+	typeName := element.Type
+	if simpleType == nil && typeName != "" {
+		simpleType = importer.simpleTypes[localName(typeName)]
+	}
+	if simpleType == nil && typeName == "" {
+		importer.warn(path, "element without type is imported as str")
+		return ItemStr, 0, 0
+	}
+
+	lenLimit := 0
+	precision := -1
+	if simpleType != nil {
+		for _, other := range unsupportedXSD(simpleType.Others) {
+			importer.warn(path, "xs:%s is not supported and skipped", other.XMLName.Local)
+			return "", 0, 0
+		}
+		if simpleType.Restriction == nil {
+			importer.warn(path, "simple type without restriction is not supported and skipped")
+			return "", 0, 0
+		}
+
+		restriction := simpleType.Restriction
+		typeName = restriction.Base
+		for _, other := range unsupportedXSD(restriction.Others) {
+			importer.warn(path, "facet '%s' is not supported and ignored", other.XMLName.Local)
+		}
+		for _, facet := range []*xsdFacet{restriction.MaxLength, restriction.Length} {
+			if facet != nil {
+				lenLimit, _ = strconv.Atoi(facet.Value)
+			}
+		}
+		if restriction.FractionDigits != nil {
+			precision, _ = strconv.Atoi(restriction.FractionDigits.Value)
+		}
+	}
+
+	switch localName(typeName) {
+	case "long", "int", "integer", "short", "byte", "nonNegativeInteger", "positiveInteger",
+		"nonPositiveInteger", "negativeInteger", "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte":
+		return ItemInt, 0, 0
+	case "string", "normalizedString", "token":
+		return ItemStr, lenLimit, 0
+	case "boolean":
+		return ItemBool, 0, 0
+	case "decimal", "float", "double":
+		if precision < 0 {
+			importer.warn(path, "%s without fractionDigits is imported with precision %d",
+				localName(typeName), importDefaultPrecision)
+			precision = importDefaultPrecision
+		}
+		return ItemDecimal, 0, precision
+	case "base64Binary", "hexBinary":
+		return ItemFile, 0, 0
+	case "date", "dateTime", "time", "duration", "anyURI", "language", "Name", "NCName", "ID", "IDREF":
+		importer.warn(path, "type '%s' is not supported, imported as str", localName(typeName))
+		return ItemStr, lenLimit, 0
+	}
+
+	importer.warn(path, "type '%s' is not supported and skipped", typeName)
+	return "", 0, 0
+}
@@ -0,0 +1,166 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportFromJSONSchema(t *testing.T) {
+	jsonStr := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"invNo": {"type": "string", "maxLength": 10},
+			"customer": {"type": ["string", "null"], "format": "uuid"},
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"qty": {"type": "integer", "minimum": 1},
+						"price": {"type": "number", "multipleOf": 0.01}
+					},
+					"required": ["qty", "price"]
+				}
+			},
+			"tags": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"required": ["invNo", "items"]
+	}`
+
+	schema, warnings, err := ImportFromJSONSchema("invoice", jsonStr)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if schema.Name != "invoice" || len(schema.Items) != 3 {
+		t.Fatalf("expect invoice schema with 3 items but get %s with %d items", schema.Name, len(schema.Items))
+	}
+
+	expects := []ItemInfo{
+		{Name: "invNo", Kind: ItemStr, LenLimit: 10},
+		{Name: "customer", Kind: ItemStr, IsOptional: true},
+		{Name: "items", Kind: ItemSection, IsArray: true},
+	}
+	for index, expect := range expects {
+		info, _ := GetItemInfo(schema.Items[index])
+		if info.Name != expect.Name || info.Kind != expect.Kind || info.IsOptional != expect.IsOptional ||
+			info.IsArray != expect.IsArray || info.LenLimit != expect.LenLimit {
+			t.Errorf("expect item %d is %+v but get %+v", index, expect, *info)
+		}
+	}
+
+	section, _ := GetItemInfo(schema.Items[2])
+	price, _ := GetItemInfo(section.Items[1])
+	if price.Kind != ItemDecimal || price.Precision != 2 || price.IsOptional {
+		t.Errorf("expect items/price is mandatory decimal with precision 2 but get %+v", *price)
+	}
+
+	warningPaths := map[string]bool{}
+	for _, warning := range warnings {
+		warningPaths[warning.Path] = true
+	}
+	for _, path := range []string{"customer", "items/qty", "tags"} {
+		if !warningPaths[path] {
+			t.Errorf("expect warning of %s but get %v", path, warnings)
+		}
+	}
+
+	if _, _, err := ImportFromJSONSchema("invoice", `{"type": "array"}`); err == nil {
+		t.Errorf("expect non object root is rejected")
+	}
+}
+
+func TestImportFromXSD(t *testing.T) {
+	xsdStr := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="invoice">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="invNo">
+          <xs:simpleType>
+            <xs:restriction base="xs:string">
+              <xs:maxLength value="10"/>
+            </xs:restriction>
+          </xs:simpleType>
+        </xs:element>
+        <xs:element name="issueDate" type="xs:date" minOccurs="0"/>
+        <xs:element name="items" type="lineType" maxOccurs="unbounded"/>
+      </xs:sequence>
+      <xs:attribute name="currency" type="xs:string"/>
+    </xs:complexType>
+  </xs:element>
+  <xs:complexType name="lineType">
+    <xs:sequence>
+      <xs:element name="qty" type="xs:long"/>
+      <xs:element name="price" type="money"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:simpleType name="money">
+    <xs:restriction base="xs:decimal">
+      <xs:fractionDigits value="2"/>
+    </xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+	schema, warnings, err := ImportFromXSD("invoice", xsdStr)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if len(schema.Items) != 3 {
+		t.Fatalf("expect 3 items but get %d", len(schema.Items))
+	}
+
+	invNo, _ := GetItemInfo(schema.Items[0])
+	if invNo.Kind != ItemStr || invNo.LenLimit != 10 {
+		t.Errorf("expect invNo is str with lenLimit 10 but get %+v", *invNo)
+	}
+	issueDate, _ := GetItemInfo(schema.Items[1])
+	if issueDate.Kind != ItemStr || !issueDate.IsOptional {
+		t.Errorf("expect issueDate is optional str but get %+v", *issueDate)
+	}
+	items, _ := GetItemInfo(schema.Items[2])
+	if items.Kind != ItemSection || !items.IsArray || len(items.Items) != 2 {
+		t.Fatalf("expect items is array section with 2 items but get %+v", *items)
+	}
+	price, _ := GetItemInfo(items.Items[1])
+	if price.Kind != ItemDecimal || price.Precision != 2 {
+		t.Errorf("expect items/price is decimal with precision 2 but get %+v", *price)
+	}
+
+	messages := []string{}
+	for _, warning := range warnings {
+		messages = append(messages, warning.String())
+	}
+	joined := strings.Join(messages, "\n")
+	if !strings.Contains(joined, "issueDate: type 'date'") || !strings.Contains(joined, "attribute 'currency'") {
+		t.Errorf("expect warnings of issueDate and currency attribute but get:\n%s", joined)
+	}
+}
+
+func TestImportFromXSDRoundTrip(t *testing.T) {
+	schema, _, err := ImportFromJSONSchema("invoice",
+		`{"type": "object", "properties": {"qty": {"type": "integer"}, "note": {"type": "string", "maxLength": 20}}}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	xsdRaw, xsdErr := ToXSD(schema, nil, nil)
+	if xsdErr != nil {
+		t.Fatal(xsdErr)
+		return
+	}
+
+	imported, warnings, importErr := ImportFromXSD("invoice", string(xsdRaw))
+	if importErr != nil {
+		t.Fatal(importErr)
+		return
+	}
+	if len(warnings) != 0 || len(imported.Items) != 2 {
+		t.Errorf("expect exported XSD is imported without warning but get %v", warnings)
+	}
+}
@@ -0,0 +1,222 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/guinso/gxschema"
+)
+
+//itemTypeRef item type of JSON schema definition for str item referencing another schema, see Reference
+const itemTypeRef = "ref"
+
+//schemaDefJSON JSON representation of schema definition, equivalent to <dxdoc> XML
+type schemaDefJSON struct {
+	Name        string           `json:"name"`
+	Revision    int              `json:"revision"`
+	Locale      string           `json:"locale,omitempty"`      //informative, ignored on parse
+	Label       string           `json:"label,omitempty"`       //informative, ignored on parse
+	Description string           `json:"description,omitempty"` //informative, ignored on parse
+	Items       []schemaItemJSON `json:"items"`
+}
+
+//schemaItemJSON JSON representation of schema item, equivalent to <dxint>, <dxstr>, <dxref>, etc.
+type schemaItemJSON struct {
+	Type        string           `json:"type"` //int, str, bool, decimal, file, section or ref
+	Name        string           `json:"name"`
+	Label       string           `json:"label,omitempty"`       //informative, ignored on parse
+	Description string           `json:"description,omitempty"` //informative, ignored on parse
+	IsOptional  bool             `json:"isOptional,omitempty"`
+	IsArray     bool             `json:"isArray,omitempty"`
+	LenLimit    int              `json:"lenLimit,omitempty"`  //str only, 0 means no length limit
+	Precision   *int             `json:"precision,omitempty"` //decimal only
+	Schema      string           `json:"schema,omitempty"`    //ref only, target schema name
+	Revision    int              `json:"revision,omitempty"`  //ref only, 0 means latest revision
+	Items       []schemaItemJSON `json:"items,omitempty"`     //section only
+}
+
+//SchemaToJSON convert schema definition into JSON, refs (optional) are references of the schema revision
+//	output is equivalent to XML definition with references applied; e.g.
+//
+//	{"name": "invoice", "revision": 2, "items": [
+//		{"type": "str", "name": "invNo", "lenLimit": 10},
+//		{"type": "ref", "name": "customer", "schema": "customer"},
+//		{"type": "section", "name": "items", "isArray": true, "items": [
+//			{"type": "int", "name": "qty"},
+//			{"type": "decimal", "name": "price", "precision": 2}]}]}
+func SchemaToJSON(schema *gxschema.DxDoc, refs []Reference) (string, error) {
+	return SchemaToLocalizedJSON(schema, refs, nil)
+}
+
+//SchemaToLocalizedJSON convert schema definition into JSON same as SchemaToJSON,
+//with label and description of locale (optional)
+func SchemaToLocalizedJSON(schema *gxschema.DxDoc, refs []Reference, locale *SchemaLocale) (string, error) {
+	refMap := map[string]Reference{}
+	for _, ref := range refs {
+		refMap[ref.ItemPath] = ref
+	}
+
+	items, itemsErr := toSchemaItemsJSON(schema.Items, "", refMap, locale)
+	if itemsErr != nil {
+		return "", itemsErr
+	}
+
+	def := schemaDefJSON{Name: schema.Name, Revision: schema.Revision, Items: items}
+	if locale != nil {
+		def.Locale = locale.Locale
+		if item := locale.GetItem(""); item != nil {
+			def.Label = item.Label
+			def.Description = item.Description
+		}
+	}
+
+	jsonRaw, jsonErr := json.Marshal(def)
+	if jsonErr != nil {
+		return "", fmt.Errorf("failed to convert schema %s into JSON: %s", schema.Name, jsonErr.Error())
+	}
+
+	return string(jsonRaw), nil
+}
+
+func toSchemaItemsJSON(items []gxschema.DxItem, path string, refMap map[string]Reference,
+	locale *SchemaLocale) ([]schemaItemJSON, error) {
+	results := []schemaItemJSON{}
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+
+		itemPath := joinItemPath(path, info.Name)
+		result := schemaItemJSON{Type: info.Kind, Name: info.Name, IsOptional: info.IsOptional, IsArray: info.IsArray}
+		if locale != nil {
+			if item := locale.GetItem(itemPath); item != nil {
+				result.Label = item.Label
+				result.Description = item.Description
+			}
+		}
+		switch info.Kind {
+		case ItemStr:
+			if ref, ok := refMap[itemPath]; ok {
+				result.Type = itemTypeRef
+				result.Schema = ref.TargetSchema
+				result.Revision = ref.TargetRevision
+			} else {
+				result.LenLimit = info.LenLimit
+			}
+		case ItemDecimal:
+			precision := info.Precision
+			result.Precision = &precision
+		case ItemSection:
+			subItems, subErr := toSchemaItemsJSON(info.Items, itemPath, refMap, locale)
+			if subErr != nil {
+				return nil, subErr
+			}
+			result.Items = subItems
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+//ParseSchemaFromJSON convert JSON schema definition (see SchemaToJSON) into schema definition and its references
+//	'ref' item become str item with 36 characters length limit, same as <dxref> in XML definition
+func ParseSchemaFromJSON(jsonStr string) (*gxschema.DxDoc, []Reference, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+	decoder.DisallowUnknownFields()
+
+	def := schemaDefJSON{}
+	if err := decoder.Decode(&def); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %s", err.Error())
+	}
+	if def.Name == "" {
+		return nil, nil, fmt.Errorf("schema name is required")
+	}
+
+	refs := []Reference{}
+	items, itemsErr := fromSchemaItemsJSON(def.Items, "", &refs)
+	if itemsErr != nil {
+		return nil, nil, itemsErr
+	}
+
+	return &gxschema.DxDoc{Name: def.Name, Revision: def.Revision, Items: items}, refs, nil
+}
+
+func fromSchemaItemsJSON(items []schemaItemJSON, path string, refs *[]Reference) ([]gxschema.DxItem, error) {
+	if len(items) == 0 {
+		if path == "" {
+			return nil, fmt.Errorf("schema must have at least one item")
+		}
+		return nil, fmt.Errorf("section %s must have at least one item", path)
+	}
+
+	names := map[string]bool{}
+	results := []gxschema.DxItem{}
+	for _, item := range items {
+		if item.Name == "" {
+			return nil, fmt.Errorf("item name is required")
+		}
+
+		itemPath := joinItemPath(path, item.Name)
+		if names[item.Name] {
+			return nil, fmt.Errorf("item %s is declared more than once", itemPath)
+		}
+		names[item.Name] = true
+
+		if item.LenLimit < 0 || (item.LenLimit > 0 && item.Type != ItemStr) {
+			return nil, fmt.Errorf("item %s has invalid lenLimit", itemPath)
+		}
+		if item.Precision != nil && (*item.Precision < 0 || item.Type != ItemDecimal) {
+			return nil, fmt.Errorf("item %s has invalid precision", itemPath)
+		}
+		if (item.Schema != "" || item.Revision != 0) && item.Type != itemTypeRef {
+			return nil, fmt.Errorf("item %s must be ref type to specify schema or revision", itemPath)
+		}
+		if len(item.Items) > 0 && item.Type != ItemSection {
+			return nil, fmt.Errorf("item %s must be section type to have items", itemPath)
+		}
+
+		precision := 0
+		if item.Precision != nil {
+			precision = *item.Precision
+		}
+
+		var subItems []gxschema.DxItem
+		switch item.Type {
+		case ItemInt, ItemStr, ItemBool, ItemFile:
+		case ItemDecimal:
+			if item.Precision == nil {
+				return nil, fmt.Errorf("decimal item %s must specify precision", itemPath)
+			}
+		case ItemSection:
+			var subErr error
+			subItems, subErr = fromSchemaItemsJSON(item.Items, itemPath, refs)
+			if subErr != nil {
+				return nil, subErr
+			}
+		case itemTypeRef:
+			if item.Schema == "" {
+				return nil, fmt.Errorf("ref item %s must specify target schema", itemPath)
+			}
+			if item.Revision < 0 {
+				return nil, fmt.Errorf("ref item %s has invalid revision: %d", itemPath, item.Revision)
+			}
+
+			*refs = append(*refs, Reference{ItemPath: itemPath, TargetSchema: item.Schema,
+				TargetRevision: item.Revision, IsOptional: item.IsOptional, IsArray: item.IsArray})
+			results = append(results, newSchemaItem(item.Name, ItemStr, item.IsOptional, item.IsArray,
+				referenceIDLength, 0, nil))
+			continue
+		default:
+			return nil, fmt.Errorf("item %s has unknown type '%s'", itemPath, item.Type)
+		}
+
+		results = append(results, newSchemaItem(item.Name, item.Type, item.IsOptional, item.IsArray,
+			item.LenLimit, precision, subItems))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"testing"
+)
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	jsonStr := `{"name":"invoice","revision":2,"items":[` +
+		`{"type":"str","name":"invNo","lenLimit":10},` +
+		`{"type":"ref","name":"customer","isOptional":true,"schema":"customer","revision":1},` +
+		`{"type":"section","name":"items","isArray":true,"items":[` +
+		`{"type":"int","name":"qty"},{"type":"decimal","name":"price","precision":2}]}]}`
+
+	schema, refs, err := ParseSchemaFromJSON(jsonStr)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if len(refs) != 1 || refs[0].ItemPath != "customer" || refs[0].TargetSchema != "customer" ||
+		refs[0].TargetRevision != 1 || !refs[0].IsOptional {
+		t.Errorf("expect customer reference but get %+v", refs)
+	}
+
+	customer, _ := GetItemInfo(schema.Items[1])
+	if customer.Kind != ItemStr || customer.LenLimit != referenceIDLength {
+		t.Errorf("expect ref item become str with lenLimit %d but get %+v", referenceIDLength, *customer)
+	}
+
+	result, resultErr := SchemaToJSON(schema, refs)
+	if resultErr != nil {
+		t.Fatal(resultErr)
+		return
+	}
+	if result != jsonStr {
+		t.Errorf("expect round trip JSON\n%s\nbut get\n%s", jsonStr, result)
+	}
+}
+
+func TestParseSchemaFromJSONInvalid(t *testing.T) {
+	invalids := []string{
+		`{"name":"invoice","items":[]}`,
+		`{"name":"invoice","items":[{"type":"text","name":"invNo"}]}`,
+		`{"name":"invoice","items":[{"type":"decimal","name":"price"}]}`,
+		`{"name":"invoice","items":[{"type":"int","name":"qty"},{"type":"int","name":"qty"}]}`,
+		`{"name":"invoice","items":[{"type":"ref","name":"customer"}]}`,
+		`{"name":"invoice","items":[{"type":"int","name":"qty","lenLimit":3}]}`,
+		`{"name":"invoice","items":[{"type":"int","name":"qty","optional":true}]}`,
+	}
+
+	for _, jsonStr := range invalids {
+		if _, _, err := ParseSchemaFromJSON(jsonStr); err == nil {
+			t.Errorf("expect error for %s", jsonStr)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package document
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/guinso/gxschema"
+	"github.com/guinso/rdbmstool"
+)
+
+var syncRevisionFilePattern = regexp.MustCompile(`^[1-9][0-9]*\.xml$`)
+
+//action of SyncChange
+const (
+	SyncCreateSchema = "createSchema" //register schema info
+	SyncSaveDraft    = "saveDraft"    //save definition as draft
+	SyncRelease      = "release"      //release definition as new revision
+	SyncConflict     = "conflict"     //released revision differs from directory, can't be synchronised
+	SyncUntracked    = "untracked"    //schema has no definition file in directory, left untouched
+)
+
+//SchemaFile schema definition kept in sync directory:
+//	{dir}/{name}.xml is latest definition, {dir}/{name}/revisions/{revision}.xml are released revisions (optional)
+type SchemaFile struct {
+	Name      string
+	Path      string
+	Revisions []SchemaRevisionFile //in ascending order
+}
+
+//SchemaRevisionFile released schema revision kept in sync directory
+type SchemaRevisionFile struct {
+	Revision int
+	Path     string
+}
+
+//SyncChange difference between sync directory and database, see PlanSchemaSync
+type SyncChange struct {
+	Schema   string
+	Action   string
+	Revision int //revision to release, or released revision which differs
+	Message  string
+
+	definition *syncDefinition
+}
+
+//syncDefinition schema definition with its references and includes, as declared
+type syncDefinition struct {
+	schema    *gxschema.DxDoc
+	refs      []Reference
+	includes  []Include
+	canonical string //XML definition used for comparison
+}
+
+//ReadSchemaDirectory list schema definition files of sync directory, ordered by schema name
+//	files and folders started with '.' are ignored; e.g. .git
+func ReadSchemaDirectory(dir string) ([]SchemaFile, error) {
+	entries, dirErr := ioutil.ReadDir(dir)
+	if dirErr != nil {
+		return nil, dirErr
+	}
+
+	files := map[string]*SchemaFile{}
+	folders := []string{}
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry.Name(), "."):
+		case entry.IsDir():
+			folders = append(folders, entry.Name())
+		case strings.HasSuffix(entry.Name(), ".xml"):
+			name := strings.TrimSuffix(entry.Name(), ".xml")
+			files[name] = &SchemaFile{Name: name, Path: filepath.Join(dir, entry.Name()),
+				Revisions: []SchemaRevisionFile{}}
+		}
+	}
+
+	for _, folder := range folders {
+		file, ok := files[folder]
+		if !ok {
+			return nil, fmt.Errorf("folder %s has no schema definition %s.xml", folder, folder)
+		}
+
+		revisionDir := filepath.Join(dir, folder, "revisions")
+		revisionEntries, revisionErr := ioutil.ReadDir(revisionDir)
+		if os.IsNotExist(revisionErr) {
+			continue
+		}
+		if revisionErr != nil {
+			return nil, revisionErr
+		}
+
+		for _, entry := range revisionEntries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if !syncRevisionFilePattern.MatchString(entry.Name()) {
+				return nil, fmt.Errorf("%s is not named by revision number; e.g. 1.xml",
+					filepath.Join(revisionDir, entry.Name()))
+			}
+
+			revision, _ := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".xml"))
+			file.Revisions = append(file.Revisions,
+				SchemaRevisionFile{Revision: revision, Path: filepath.Join(revisionDir, entry.Name())})
+		}
+		sort.Slice(file.Revisions, func(i, j int) bool { return file.Revisions[i].Revision < file.Revisions[j].Revision })
+	}
+
+	results := []SchemaFile{}
+	for _, file := range files {
+		results = append(results, *file)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results, nil
+}
+
+//PlanSchemaSync compare sync directory with database and list changes needed to bring database in line:
+//	revisions of revisions/ folder missing from database are released in order, then latest definition
+//	is released (if release is true) or saved as draft when it differs from latest revision;
+//	released revision which differs from directory is reported as conflict since revision is immutable
+//	return empty list if database and directory agree
+func PlanSchemaSync(db rdbmstool.DbHandlerProxy, files []SchemaFile, release bool) ([]SyncChange, error) {
+	changes := []SyncChange{}
+	tracked := map[string]bool{}
+	for _, file := range files {
+		tracked[file.Name] = true
+
+		fileChanges, planErr := planSchemaFileSync(db, file, release)
+		if planErr != nil {
+			return nil, planErr
+		}
+		changes = append(changes, fileChanges...)
+	}
+
+	rows, rowsErr := db.Query(`SELECT name FROM doc_schema ORDER BY name`)
+	if rowsErr != nil {
+		return nil, fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		if !tracked[name] {
+			changes = append(changes, SyncChange{Schema: name, Action: SyncUntracked,
+				Message: "no definition file in directory"})
+		}
+	}
+
+	return changes, nil
+}
+
+func planSchemaFileSync(db rdbmstool.DbHandlerProxy, file SchemaFile, release bool) ([]SyncChange, error) {
+	changes := []SyncChange{}
+
+	info, infoErr := GetSchemaInfo(db, file.Name)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	latest := 0
+	if info == nil {
+		changes = append(changes, SyncChange{Schema: file.Name, Action: SyncCreateSchema, Message: "register schema"})
+	} else if info.LatestRevision > 0 {
+		latest = info.LatestRevision
+	}
+
+	//released revisions
+	planned := latest
+	var current *syncDefinition
+	for _, revisionFile := range file.Revisions {
+		definition, defErr := readSyncDefinition(file.Name, revisionFile.Path)
+		if defErr != nil {
+			return nil, defErr
+		}
+
+		switch {
+		case revisionFile.Revision <= latest:
+			released, releasedErr := getSyncDefinition(db, file.Name, revisionFile.Revision)
+			if releasedErr != nil {
+				return nil, releasedErr
+			}
+			if released == nil || released.canonical != definition.canonical {
+				changes = append(changes, SyncChange{Schema: file.Name, Action: SyncConflict,
+					Revision: revisionFile.Revision,
+					Message:  fmt.Sprintf("revision %d differs from database", revisionFile.Revision)})
+			}
+		case revisionFile.Revision == planned+1:
+			planned++
+			changes = append(changes, SyncChange{Schema: file.Name, Action: SyncRelease, Revision: planned,
+				Message: fmt.Sprintf("release revision %d", planned), definition: definition})
+		default:
+			changes = append(changes, SyncChange{Schema: file.Name, Action: SyncConflict,
+				Revision: revisionFile.Revision,
+				Message: fmt.Sprintf("revision %d can't be released after revision %d",
+					revisionFile.Revision, planned)})
+		}
+
+		if revisionFile.Revision == planned {
+			current = definition
+		}
+	}
+
+	//latest definition
+	definition, defErr := readSyncDefinition(file.Name, file.Path)
+	if defErr != nil {
+		return nil, defErr
+	}
+
+	if current == nil && planned > 0 {
+		released, releasedErr := getSyncDefinition(db, file.Name, planned)
+		if releasedErr != nil {
+			return nil, releasedErr
+		}
+		current = released
+	}
+	if current != nil && current.canonical == definition.canonical {
+		return changes, nil
+	}
+
+	if release {
+		changes = append(changes, SyncChange{Schema: file.Name, Action: SyncRelease, Revision: planned + 1,
+			Message: fmt.Sprintf("release revision %d", planned+1), definition: definition})
+		return changes, nil
+	}
+
+	if info != nil && info.HasDraft {
+		draft, draftErr := getSyncDefinition(db, file.Name, -1)
+		if draftErr != nil {
+			return nil, draftErr
+		}
+		if draft != nil && draft.canonical == definition.canonical {
+			return changes, nil
+		}
+	}
+
+	return append(changes, SyncChange{Schema: file.Name, Action: SyncSaveDraft, Revision: -1,
+		Message: "save draft", definition: definition}), nil
+}
+
+//ApplySyncChange write change planned by PlanSchemaSync into database
+//	conflict and untracked changes are left untouched
+//NOTE: ErrSchemaLint error will return if definition has lint error
+func ApplySyncChange(db rdbmstool.DbHandlerProxy, change SyncChange, remark string) error {
+	switch change.Action {
+	case SyncCreateSchema:
+		return AddSchemaInfo(db, change.Schema, "")
+	case SyncRelease:
+		revision, addErr := AddSchema(db, change.Schema, change.definition.schema, remark)
+		if addErr != nil {
+			return addErr
+		}
+		if revision != change.Revision {
+			return fmt.Errorf("%s is released as revision %d instead of %d, database changed since planned",
+				change.Schema, revision, change.Revision)
+		}
+
+		if err := saveSyncDefinition(db, change.Schema, revision, change.definition); err != nil {
+			return err
+		}
+
+		return SealSchemaRevision(db, change.Schema, revision)
+	case SyncSaveDraft:
+		if err := SaveSchemaAsDraft(db, change.Schema, change.definition.schema, remark); err != nil {
+			return err
+		}
+
+		return saveSyncDefinition(db, change.Schema, -1, change.definition)
+	}
+
+	return nil
+}
+
+//GetDefinition get XML definition of change as declared in directory, empty if change carries no definition
+func (change *SyncChange) GetDefinition() string {
+	if change.definition == nil {
+		return ""
+	}
+
+	return change.definition.canonical
+}
+
+//saveSyncDefinition save references and includes of saved definition, then check includes can be resolved
+func saveSyncDefinition(db rdbmstool.DbHandlerProxy, name string, revision int, definition *syncDefinition) error {
+	if err := SaveReferences(db, name, revision, definition.refs); err != nil {
+		return err
+	}
+	if err := SaveIncludes(db, name, revision, definition.includes); err != nil {
+		return err
+	}
+
+	_, flattenErr := GetSchemaByRevision(db, name, revision)
+	return flattenErr
+}
+
+//readSyncDefinition parse schema definition file, which may declare <dxref> and <dxinclude>
+func readSyncDefinition(name string, path string) (*syncDefinition, error) {
+	content, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	includeStr, includes, includeErr := ExtractIncludes(string(content))
+	if includeErr != nil {
+		return nil, fmt.Errorf("%s: %s", path, includeErr.Error())
+	}
+
+	xmlStr, refs, refErr := ExtractReferences(includeStr)
+	if refErr != nil {
+		return nil, fmt.Errorf("%s: %s", path, refErr.Error())
+	}
+
+	schema, schemaErr := gxschema.ParseSchemaFromXML(xmlStr)
+	if schemaErr != nil {
+		return nil, fmt.Errorf("%s: %s", path, schemaErr.Error())
+	}
+	if schema.Name != "" && schema.Name != name {
+		return nil, fmt.Errorf("%s: dxdoc name %s not match with file name", path, schema.Name)
+	}
+
+	return newSyncDefinition(name, schema, refs, includes)
+}
+
+//getSyncDefinition get declared definition of schema revision (use -1 for draft), nil if not found
+func getSyncDefinition(db rdbmstool.DbHandlerProxy, name string, revision int) (*syncDefinition, error) {
+	schema, schemaErr := GetDeclaredSchemaByRevision(db, name, revision)
+	if schemaErr != nil || schema == nil {
+		return nil, schemaErr
+	}
+
+	refs, refErr := getDeclaredReferences(db, name, revision)
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	includes, includeErr := GetIncludes(db, name, revision)
+	if includeErr != nil {
+		return nil, includeErr
+	}
+
+	return newSyncDefinition(name, schema, refs, includes)
+}
+
+//newSyncDefinition write definition back into XML with revision and ID left out, so file and database compare equal
+func newSyncDefinition(name string, schema *gxschema.DxDoc, refs []Reference,
+	includes []Include) (*syncDefinition, error) {
+	oriRev := schema.Revision
+	oriID := schema.ID
+	oriName := schema.Name
+
+	schema.Revision = 0
+	schema.ID = ""
+	schema.Name = name
+
+	xmlStr, xmlErr := schema.XML()
+
+	schema.Revision = oriRev
+	schema.ID = oriID
+	schema.Name = oriName
+	if xmlErr != nil {
+		return nil, fmt.Errorf("failed to get XML definition of %s: %s", name, xmlErr.Error())
+	}
+
+	xmlStr, refErr := ApplyReferences(xmlStr, refs)
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	xmlStr, includeErr := ApplyIncludes(xmlStr, includes)
+	if includeErr != nil {
+		return nil, includeErr
+	}
+
+	return &syncDefinition{schema: schema, refs: refs, includes: includes, canonical: xmlStr}, nil
+}
@@ -0,0 +1,254 @@
+package document
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+func writeSyncTestFiles(t *testing.T, files map[string]string) string {
+	dir, dirErr := ioutil.TempDir("", "gxdoc-sync")
+	if dirErr != nil {
+		t.Fatal(dirErr)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestReadSchemaDirectory(t *testing.T) {
+	dir := writeSyncTestFiles(t, map[string]string{
+		"pr.xml":                    `<dxdoc name="pr"></dxdoc>`,
+		"invoice.xml":               `<dxdoc name="invoice"></dxdoc>`,
+		"invoice/revisions/2.xml":   `<dxdoc name="invoice"></dxdoc>`,
+		"invoice/revisions/10.xml":  `<dxdoc name="invoice"></dxdoc>`,
+		"invoice/revisions/1.xml":   `<dxdoc name="invoice"></dxdoc>`,
+		".git/config":               "",
+		"README.md":                 "schemas",
+		"invoice/revisions/.keep":   "",
+		"invoice/notes/readme.txt":  "",
+		".hidden/revisions/abc.xml": "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := ReadSchemaDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0].Name != "invoice" || files[1].Name != "pr" {
+		t.Fatalf("expect invoice and pr but get %v", files)
+	}
+	if files[0].Path != filepath.Join(dir, "invoice.xml") {
+		t.Errorf("unexpected path of invoice: %s", files[0].Path)
+	}
+
+	revisions := files[0].Revisions
+	if len(revisions) != 3 || revisions[0].Revision != 1 || revisions[1].Revision != 2 || revisions[2].Revision != 10 {
+		t.Errorf("expect revisions 1, 2 and 10 in order but get %v", revisions)
+	}
+	if revisions[1].Path != filepath.Join(dir, "invoice", "revisions", "2.xml") {
+		t.Errorf("unexpected path of revision 2: %s", revisions[1].Path)
+	}
+	if len(files[1].Revisions) != 0 {
+		t.Errorf("expect pr has no revision file but get %v", files[1].Revisions)
+	}
+}
+
+func TestReadSchemaDirectoryInvalid(t *testing.T) {
+	cases := map[string]map[string]string{
+		"folder without definition": {
+			"invoice/revisions/1.xml": `<dxdoc name="invoice"></dxdoc>`,
+		},
+		"revision not numbered": {
+			"invoice.xml":                 `<dxdoc name="invoice"></dxdoc>`,
+			"invoice/revisions/first.xml": `<dxdoc name="invoice"></dxdoc>`,
+		},
+		"revision zero": {
+			"invoice.xml":             `<dxdoc name="invoice"></dxdoc>`,
+			"invoice/revisions/0.xml": `<dxdoc name="invoice"></dxdoc>`,
+		},
+	}
+
+	for name, files := range cases {
+		dir := writeSyncTestFiles(t, files)
+		if _, err := ReadSchemaDirectory(dir); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+		os.RemoveAll(dir)
+	}
+
+	if _, err := ReadSchemaDirectory(filepath.Join(os.TempDir(), "gxdoc-sync-not-exists")); err == nil {
+		t.Error("expect error on missing directory")
+	}
+}
+
+//getSyncTestDirectory sync directory against test database:
+//	invoice revision 1 differs from database, pr has a new item and sync-po is not registered yet
+func getSyncTestDirectory(t *testing.T) string {
+	return writeSyncTestFiles(t, map[string]string{
+		"invoice.xml": `<dxdoc name="invoice"><dxstr name="invNo"></dxstr>` +
+			`<dxint name="totalQty" isOptional="true"></dxint><dxdecimal name="price" precision="2"></dxdecimal></dxdoc>`,
+		"invoice/revisions/1.xml": `<dxdoc name="invoice"><dxstr name="invNo"></dxstr></dxdoc>`,
+		"invoice/revisions/2.xml": `<dxdoc name="invoice"><dxstr name="invNo"></dxstr>` +
+			`<dxint name="totalQty" isOptional="true"></dxint><dxdecimal name="price" precision="2"></dxdecimal></dxdoc>`,
+		"pr.xml": `<dxdoc name="pr"><dxint name="qty"></dxint><dxstr name="pr number" lenLimit="6"></dxstr>` +
+			`<dxstr name="remark"></dxstr></dxdoc>`,
+		"sync-po.xml": `<dxdoc name="sync-po"><dxstr name="poNo"></dxstr>` +
+			`<dxref name="pr" schema="pr" revision="1"></dxref></dxdoc>`,
+	})
+}
+
+func checkSyncChanges(t *testing.T, changes []SyncChange, expects []SyncChange) {
+	if len(changes) != len(expects) {
+		t.Fatalf("expect %d changes but get %+v", len(expects), changes)
+		return
+	}
+
+	for index, expect := range expects {
+		change := changes[index]
+		if change.Schema != expect.Schema || change.Action != expect.Action || change.Revision != expect.Revision {
+			t.Errorf("expect change #%d %s %s revision %d but get %s %s revision %d", index+1,
+				expect.Schema, expect.Action, expect.Revision, change.Schema, change.Action, change.Revision)
+		}
+	}
+}
+
+func TestPlanSchemaSync(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	dir := getSyncTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	files, filesErr := ReadSchemaDirectory(dir)
+	if filesErr != nil {
+		t.Fatal(filesErr)
+		return
+	}
+
+	changes, planErr := PlanSchemaSync(db, files, false)
+	if planErr != nil {
+		t.Fatal(planErr)
+		return
+	}
+	checkSyncChanges(t, changes, []SyncChange{
+		{Schema: "invoice", Action: SyncConflict, Revision: 1},
+		{Schema: "pr", Action: SyncSaveDraft, Revision: -1},
+		{Schema: "sync-po", Action: SyncCreateSchema},
+		{Schema: "sync-po", Action: SyncSaveDraft, Revision: -1},
+	})
+
+	changes, planErr = PlanSchemaSync(db, files, true)
+	if planErr != nil {
+		t.Fatal(planErr)
+		return
+	}
+	checkSyncChanges(t, changes, []SyncChange{
+		{Schema: "invoice", Action: SyncConflict, Revision: 1},
+		{Schema: "pr", Action: SyncRelease, Revision: 2},
+		{Schema: "sync-po", Action: SyncCreateSchema},
+		{Schema: "sync-po", Action: SyncRelease, Revision: 1},
+	})
+
+	//schema in database without definition file is untracked
+	changes, planErr = PlanSchemaSync(db, files[1:2], false)
+	if planErr != nil {
+		t.Fatal(planErr)
+		return
+	}
+	checkSyncChanges(t, changes, []SyncChange{
+		{Schema: "pr", Action: SyncSaveDraft, Revision: -1},
+		{Schema: "invoice", Action: SyncUntracked},
+	})
+}
+
+func TestApplySyncChange(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	dir := getSyncTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	files, filesErr := ReadSchemaDirectory(dir)
+	if filesErr != nil {
+		t.Fatal(filesErr)
+		return
+	}
+
+	changes, planErr := PlanSchemaSync(trx, files, true)
+	if planErr != nil {
+		t.Fatal(planErr)
+		return
+	}
+	planned := changes
+	for _, change := range planned {
+		if err := ApplySyncChange(trx, change, "sync"); err != nil {
+			t.Fatalf("failed to apply %s %s: %s", change.Schema, change.Action, err.Error())
+			return
+		}
+	}
+
+	pr, prErr := GetSchemaByRevision(trx, "pr", 2)
+	if prErr != nil {
+		t.Fatal(prErr)
+		return
+	}
+	if pr == nil || len(pr.Items) != 3 {
+		t.Errorf("expect pr revision 2 with 3 items but get %+v", pr)
+	}
+
+	refs, refsErr := GetReferences(trx, "sync-po", 1)
+	if refsErr != nil {
+		t.Fatal(refsErr)
+		return
+	}
+	if len(refs) != 1 || refs[0].TargetSchema != "pr" || refs[0].TargetRevision != 1 {
+		t.Errorf("expect sync-po revision 1 references pr revision 1 but get %+v", refs)
+	}
+
+	//database agrees with directory except conflict which is left untouched
+	changes, planErr = PlanSchemaSync(trx, files, true)
+	if planErr != nil {
+		t.Fatal(planErr)
+		return
+	}
+	checkSyncChanges(t, changes, []SyncChange{
+		{Schema: "invoice", Action: SyncConflict, Revision: 1},
+	})
+
+	//release planned before database changed is rejected
+	for _, change := range planned {
+		if change.Schema == "pr" && change.Action == SyncRelease {
+			if err := ApplySyncChange(trx, change, "sync"); err == nil {
+				t.Error("expect stale release of pr revision 2 is rejected")
+			}
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/guinso/gxschema"
+)
+
+//validation rules reported by Violation
+const (
+	RuleFormat    = "format"    //input data is not well formed JSON or XML
+	RuleRequired  = "required"  //mandatory item is missing
+	RuleType      = "type"      //value type not match with item type
+	RuleLenLimit  = "lenLimit"  //string longer than item's length limit
+	RulePrecision = "precision" //decimal has more decimal places than item's precision
+	RuleArray     = "array"     //array given to non array item or vice versa
+	RuleSchema    = "schema"    //other rule enforced by gxschema
+	RuleReference = "reference" //referenced document record not found
+	RuleCustom    = "custom"    //cross-field rule declared in schema's rule script
+	RuleComputed  = "computed"  //value of computed field is supplied by client
+)
+
+//Violation single validation failure of document data
+type Violation struct {
+	Path    string      `json:"path"` //JSON pointer of offending value; e.g. /items/0/qty
+	Rule    string      `json:"rule"`
+	Value   interface{} `json:"value,omitempty"`
+	Message string      `json:"message"`
+	Label   string      `json:"label,omitempty"` //localized label of offending item, see LocalizeViolations
+}
+
+func (violation Violation) Error() string {
+	if violation.Path == "" {
+		return violation.Message
+	}
+
+	return violation.Path + ": " + violation.Message
+}
+
+//ValidateDataFromJSON validate JSON data against schema and collect all violations found
+//return empty list if data is valid
+func ValidateDataFromJSON(jsonStr string, schema *gxschema.DxDoc) []Violation {
+	obj, jsonErr := DecodeJSONData(jsonStr)
+	if jsonErr != nil {
+		return []Violation{{Rule: RuleFormat, Message: jsonErr.Error()}}
+	}
+
+	violations := validateItems(schema.Items, obj, "", false)
+	if len(violations) == 0 {
+		//fallback to rules which only enforced by gxschema
+		if invalid := gxschema.ValidateDataFromJSON(jsonStr, schema); invalid != nil {
+			violations = append(violations, Violation{Rule: RuleSchema, Message: invalid.Error()})
+		}
+	}
+
+	return violations
+}
+
+//ValidateDataFromXML validate XML data against schema and collect all violations found
+//return empty list if data is valid
+func ValidateDataFromXML(xmlStr string, schema *gxschema.DxDoc) []Violation {
+	obj, xmlErr := ParseXMLData(xmlStr)
+	if xmlErr != nil {
+		return []Violation{{Rule: RuleFormat, Message: "invalid XML: " + xmlErr.Error()}}
+	}
+
+	violations := validateItems(schema.Items, obj, "", true)
+	if len(violations) == 0 {
+		//fallback to rules which only enforced by gxschema
+		if invalid := gxschema.ValidateDataFromXML(xmlStr, schema); invalid != nil {
+			violations = append(violations, Violation{Rule: RuleSchema, Message: invalid.Error()})
+		}
+	}
+
+	return violations
+}
+
+//ParseXMLData convert XML data into same structure as decoded JSON object
+//	root element name is ignored, element with child elements become map,
+//	otherwise become string; repeated elements become array
+func ParseXMLData(xmlStr string) (map[string]interface{}, error) {
+	type xmlNode struct {
+		children map[string]interface{}
+		text     bytes.Buffer
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	stack := []*xmlNode{}
+	var root map[string]interface{}
+
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, fmt.Errorf("XML data must have only one root element")
+			}
+			stack = append(stack, &xmlNode{})
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(element)
+			}
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var value interface{}
+			if node.children != nil {
+				value = node.children
+			} else {
+				value = node.text.String()
+			}
+
+			if len(stack) == 0 {
+				if node.children == nil {
+					node.children = map[string]interface{}{}
+				}
+				root = node.children
+				continue
+			}
+
+			parent := stack[len(stack)-1]
+			if parent.children == nil {
+				parent.children = map[string]interface{}{}
+			}
+
+			name := element.Name.Local
+			if existing, ok := parent.children[name]; ok {
+				if arr, isArr := existing.([]interface{}); isArr {
+					parent.children[name] = append(arr, value)
+				} else {
+					parent.children[name] = []interface{}{existing, value}
+				}
+			} else {
+				parent.children[name] = value
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("XML data has no root element")
+	}
+
+	return root, nil
+}
+
+//TypeXMLData convert parsed XML data into same value types as decoded JSON data
+//	int and decimal become json.Number, bool become bool, array items are always
+//	wrapped in array even if only one element is given; value which not match
+//	schema item type is left as it is
+//NOTE: data is modified in place
+func TypeXMLData(data map[string]interface{}, items []gxschema.DxItem) map[string]interface{} {
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			continue
+		}
+
+		value, exists := data[info.Name]
+		if !exists {
+			continue
+		}
+
+		if !info.IsArray {
+			data[info.Name] = typeXMLValue(info, value)
+			continue
+		}
+
+		arr, isArr := value.([]interface{})
+		if !isArr {
+			arr = []interface{}{value}
+		}
+		for index, element := range arr {
+			arr[index] = typeXMLValue(info, element)
+		}
+		data[info.Name] = arr
+	}
+
+	return data
+}
+
+func typeXMLValue(info *ItemInfo, value interface{}) interface{} {
+	if info.Kind == ItemSection {
+		switch x := value.(type) {
+		case map[string]interface{}:
+			return TypeXMLData(x, info.Items)
+		case string:
+			if strings.TrimSpace(x) == "" {
+				return map[string]interface{}{}
+			}
+		}
+		return value
+	}
+
+	str, isStr := value.(string)
+	if !isStr {
+		return value
+	}
+	str = strings.TrimSpace(str)
+
+	switch info.Kind {
+	case ItemInt:
+		if _, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return json.Number(str)
+		}
+	case ItemDecimal:
+		if _, err := strconv.ParseFloat(str, 64); err == nil {
+			return json.Number(str)
+		}
+	case ItemBool:
+		if flag, err := strconv.ParseBool(str); err == nil {
+			return flag
+		}
+	}
+
+	return value
+}
+
+func validateItems(items []gxschema.DxItem, obj map[string]interface{}, path string, isXML bool) []Violation {
+	violations := []Violation{}
+
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			violations = append(violations, Violation{Path: path, Rule: RuleSchema, Message: infoErr.Error()})
+			continue
+		}
+
+		itemPath := path + "/" + escapeJSONPointer(info.Name)
+		value, exists := obj[info.Name]
+		if !exists || value == nil {
+			if !info.IsOptional {
+				violations = append(violations, Violation{
+					Path: itemPath, Rule: RuleRequired, Message: "value is required"})
+			}
+			continue
+		}
+
+		arr, isArr := value.([]interface{})
+		if info.IsArray {
+			if !isArr {
+				if !isXML {
+					violations = append(violations, Violation{
+						Path: itemPath, Rule: RuleArray, Value: value, Message: "value must be an array"})
+					continue
+				}
+
+				arr = []interface{}{value} //single XML element is array of one
+			}
+
+			for index, subValue := range arr {
+				violations = append(violations,
+					validateValue(info, subValue, itemPath+"/"+strconv.Itoa(index), isXML)...)
+			}
+		} else if isArr {
+			violations = append(violations, Violation{
+				Path: itemPath, Rule: RuleArray, Value: value, Message: "value must not be an array"})
+		} else {
+			violations = append(violations, validateValue(info, value, itemPath, isXML)...)
+		}
+	}
+
+	return violations
+}
+
+func validateValue(info *ItemInfo, value interface{}, path string, isXML bool) []Violation {
+	typeViolation := func(expected string) []Violation {
+		return []Violation{{Path: path, Rule: RuleType, Value: value, Message: "value must be " + expected}}
+	}
+
+	switch info.Kind {
+	case ItemInt:
+		raw, ok := getNumberText(value, isXML)
+		if !ok {
+			return typeViolation("an integer")
+		}
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return typeViolation("an integer")
+		}
+	case ItemStr:
+		str, ok := value.(string)
+		if !ok {
+			return typeViolation("a string")
+		}
+		if info.LenLimit > 0 && utf8.RuneCountInString(str) > info.LenLimit {
+			return []Violation{{Path: path, Rule: RuleLenLimit, Value: value,
+				Message: fmt.Sprintf("value must not longer than %d characters", info.LenLimit)}}
+		}
+	case ItemBool:
+		if isXML {
+			str, _ := value.(string)
+			if _, err := strconv.ParseBool(strings.TrimSpace(str)); err != nil {
+				return typeViolation("a boolean")
+			}
+		} else if _, ok := value.(bool); !ok {
+			return typeViolation("a boolean")
+		}
+	case ItemDecimal:
+		raw, ok := getNumberText(value, isXML)
+		if !ok {
+			return typeViolation("a number")
+		}
+		tmpFloat, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return typeViolation("a number")
+		}
+		if strings.ContainsAny(raw, "eE") {
+			raw = strconv.FormatFloat(tmpFloat, 'f', -1, 64)
+		}
+		if dotIndex := strings.Index(raw, "."); dotIndex >= 0 &&
+			len(strings.TrimRight(raw[dotIndex+1:], "0")) > info.Precision {
+			return []Violation{{Path: path, Rule: RulePrecision, Value: value,
+				Message: fmt.Sprintf("value must not have more than %d decimal places", info.Precision)}}
+		}
+	case ItemSection:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			if str, isStr := value.(string); isXML && isStr && strings.TrimSpace(str) == "" {
+				obj = map[string]interface{}{} //empty XML element
+			} else {
+				return typeViolation("an object")
+			}
+		}
+
+		return validateItems(info.Items, obj, path, isXML)
+	}
+
+	return []Violation{}
+}
+
+//getNumberText get text form of numeric value
+func getNumberText(value interface{}, isXML bool) (string, bool) {
+	if isXML {
+		str, ok := value.(string)
+		return strings.TrimSpace(str), ok
+	}
+
+	switch x := value.(type) {
+	case json.Number:
+		return x.String(), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+//escapeJSONPointer escape item name as JSON pointer reference token (RFC 6901)
+func escapeJSONPointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
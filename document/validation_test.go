@@ -0,0 +1,144 @@
+package document
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func getValidationTestSchema() *gxschema.DxDoc {
+	return &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 1,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "code", EnableLenLimit: true, LenLimit: 5},
+			gxschema.DxBool{Name: "isPaid"},
+			gxschema.DxStr{Name: "remark", IsOptional: true},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+			}},
+		},
+	}
+}
+
+func TestValidateDataFromJSON(t *testing.T) {
+	schema := getValidationTestSchema()
+
+	violations := ValidateDataFromJSON(`{
+		"code": "INV-00001",
+		"isPaid": "yes",
+		"items": [
+			{"qty": 1, "price": 2.5},
+			{"qty": 1.5, "price": 2.555},
+			{"price": 1}
+		]
+	}`, schema)
+
+	expected := []Violation{
+		{Path: "/code", Rule: RuleLenLimit},
+		{Path: "/isPaid", Rule: RuleType},
+		{Path: "/items/1/qty", Rule: RuleType},
+		{Path: "/items/1/price", Rule: RulePrecision},
+		{Path: "/items/2/qty", Rule: RuleRequired},
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("expect %d violations but get %d: %v", len(expected), len(violations), violations)
+	}
+	for index, item := range expected {
+		if strings.Compare(violations[index].Path, item.Path) != 0 ||
+			strings.Compare(violations[index].Rule, item.Rule) != 0 {
+			t.Errorf("expect violation %s (%s) but get %s (%s)",
+				item.Path, item.Rule, violations[index].Path, violations[index].Rule)
+		}
+	}
+
+	if violations[0].Value != "INV-00001" {
+		t.Errorf("expect offending value INV-00001 but get %v", violations[0].Value)
+	}
+
+	violations = ValidateDataFromJSON(`{"code": "A", "isPaid": true, "items": {"qty": 1, "price": 1}}`, schema)
+	if len(violations) != 1 || violations[0].Rule != RuleArray {
+		t.Errorf("expect single array violation but get %v", violations)
+	}
+
+	violations = ValidateDataFromJSON(`{"code": "A",`, schema)
+	if len(violations) != 1 || violations[0].Rule != RuleFormat {
+		t.Errorf("expect malformed JSON is reported but get %v", violations)
+	}
+}
+
+func TestValidateDataFromXMLStructure(t *testing.T) {
+	schema := getValidationTestSchema()
+
+	data, err := ParseXMLData(`<invoice>
+		<code>A1</code>
+		<isPaid>true</isPaid>
+		<items><qty>1</qty><price>2.50</price></items>
+	</invoice>`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//single XML element is accepted as array of one
+	if violations := validateItems(schema.Items, data, "", true); len(violations) > 0 {
+		t.Errorf("expect XML data is valid but get %v", violations)
+	}
+
+	violations := ValidateDataFromXML(`<invoice>
+		<code>A1</code><code>A2</code>
+		<isPaid>maybe</isPaid>
+		<items><qty>1</qty><price>2.50</price></items>
+		<items><qty>x</qty><price>1</price></items>
+	</invoice>`, schema)
+
+	expected := []string{"/code", "/isPaid", "/items/1/qty"}
+	if len(violations) != len(expected) {
+		t.Fatalf("expect %d violations but get %d: %v", len(expected), len(violations), violations)
+	}
+	for index, path := range expected {
+		if strings.Compare(violations[index].Path, path) != 0 {
+			t.Errorf("expect violation at %s but get %s", path, violations[index].Path)
+		}
+	}
+}
+
+func TestTypeXMLData(t *testing.T) {
+	schema := getValidationTestSchema()
+
+	data, err := ParseXMLData(`<invoice>
+		<code>A1</code>
+		<isPaid>true</isPaid>
+		<items><qty>3</qty><price>2.50</price></items>
+	</invoice>`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	data = TypeXMLData(data, schema.Items)
+	if isPaid, ok := data["isPaid"].(bool); !ok || !isPaid {
+		t.Errorf("expect isPaid is boolean true but get %#v", data["isPaid"])
+	}
+
+	items, ok := data["items"].([]interface{})
+	if !ok || len(items) != 1 {
+		t.Fatalf("expect single items element is wrapped in array but get %#v", data["items"])
+	}
+	item, _ := items[0].(map[string]interface{})
+	if qty, _ := item["qty"].(json.Number); qty != "3" {
+		t.Errorf("expect qty is number 3 but get %#v", item["qty"])
+	}
+	if price, _ := item["price"].(json.Number); price != "2.50" {
+		t.Errorf("expect price is number 2.50 but get %#v", item["price"])
+	}
+}
+
+func TestEscapeJSONPointer(t *testing.T) {
+	if result := escapeJSONPointer("a/b~c"); strings.Compare(result, "a~1b~0c") != 0 {
+		t.Errorf("expect a~1b~0c but get %s", result)
+	}
+}
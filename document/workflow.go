@@ -0,0 +1,240 @@
+package document
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/guinso/rdbmstool"
+)
+
+//Workflow state machine declared by a document schema
+type Workflow struct {
+	InitialState string       `json:"initialState"`
+	States       []string     `json:"states"`
+	Transitions  []Transition `json:"transitions"`
+}
+
+//Transition allowed movement of document from one state to another
+type Transition struct {
+	Name  string   `json:"name"`
+	From  []string `json:"from"`
+	To    string   `json:"to"`
+	Roles []string `json:"roles"` //empty means any requestor is allowed
+}
+
+//ParseWorkflowFromJSON convert JSON string into Workflow and check it is well defined
+func ParseWorkflowFromJSON(jsonStr string) (*Workflow, error) {
+	workflow := Workflow{}
+	if err := json.Unmarshal([]byte(jsonStr), &workflow); err != nil {
+		return nil, fmt.Errorf("invalid workflow JSON: %s", err.Error())
+	}
+
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &workflow, nil
+}
+
+//Validate check workflow states and transitions are consistent
+func (workflow *Workflow) Validate() error {
+	if len(workflow.States) == 0 {
+		return fmt.Errorf("workflow must declare at least one state")
+	}
+
+	states := map[string]bool{}
+	for _, state := range workflow.States {
+		if state == "" {
+			return fmt.Errorf("workflow state name cannot be empty")
+		}
+		if states[state] {
+			return fmt.Errorf("workflow state '%s' is declared more than once", state)
+		}
+		states[state] = true
+	}
+
+	if !states[workflow.InitialState] {
+		return fmt.Errorf("workflow initial state '%s' is not declared", workflow.InitialState)
+	}
+
+	names := map[string]bool{}
+	for _, transition := range workflow.Transitions {
+		if transition.Name == "" {
+			return fmt.Errorf("workflow transition name cannot be empty")
+		}
+		if names[transition.Name] {
+			return fmt.Errorf("workflow transition '%s' is declared more than once", transition.Name)
+		}
+		names[transition.Name] = true
+
+		if len(transition.From) == 0 {
+			return fmt.Errorf("workflow transition '%s' must have at least one source state", transition.Name)
+		}
+		for _, from := range transition.From {
+			if !states[from] {
+				return fmt.Errorf("workflow transition '%s' source state '%s' is not declared",
+					transition.Name, from)
+			}
+		}
+		if !states[transition.To] {
+			return fmt.Errorf("workflow transition '%s' target state '%s' is not declared",
+				transition.Name, transition.To)
+		}
+	}
+
+	return nil
+}
+
+//GetTransition get transition by name, return nil if not found
+func (workflow *Workflow) GetTransition(name string) *Transition {
+	for index := range workflow.Transitions {
+		if workflow.Transitions[index].Name == name {
+			return &workflow.Transitions[index]
+		}
+	}
+
+	return nil
+}
+
+//GetCurrentState get workflow state of record state;
+//record created before workflow was declared has no state and is at initial state
+func (workflow *Workflow) GetCurrentState(recordState string) string {
+	if recordState == "" {
+		return workflow.InitialState
+	}
+
+	return recordState
+}
+
+//Check verify transition is allowed from current state by requestor having specified roles
+//Will return ErrTransitionNotAllowed or ErrTransitionNotAuthorize if rules are violated
+func (transition *Transition) Check(currentState string, roles []string) error {
+	allowed := false
+	for _, from := range transition.From {
+		if from == currentState {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrTransitionNotAllowed{
+			msg: fmt.Sprintf("transition '%s' is not allowed from state '%s'", transition.Name, currentState)}
+	}
+
+	if len(transition.Roles) == 0 {
+		return nil
+	}
+
+	for _, required := range transition.Roles {
+		for _, role := range roles {
+			if role == required {
+				return nil
+			}
+		}
+	}
+
+	return ErrTransitionNotAuthorize{
+		msg: fmt.Sprintf("transition '%s' requires one of roles: %v", transition.Name, transition.Roles)}
+}
+
+//JSON export to JSON string
+func (workflow *Workflow) JSON() string {
+	jsonRaw, _ := json.Marshal(workflow)
+
+	return string(jsonRaw)
+}
+
+//GetWorkflow get workflow declared by document schema
+//return nil if schema has no workflow
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+func GetWorkflow(db rdbmstool.DbHandlerProxy, schemaName string) (*Workflow, error) {
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	if schemaInfo == nil {
+		return nil, ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	row := db.QueryRow(`SELECT definition FROM doc_schema_workflow WHERE schema_id = ?`, schemaInfo.ID)
+	var tmpDef string
+	if err := row.Scan(&tmpDef); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch workflow of %s from database: %s", schemaName, err.Error())
+	}
+
+	return ParseWorkflowFromJSON(tmpDef)
+}
+
+//SaveWorkflow declare or overwrite workflow of document schema
+//NOTE: ErrSchemaInfoNotFound error will return if document not register in doc_schema datatable
+//NOTE: ErrWorkflowStateInUse error will return if any document record is in a state new workflow doesn't declare
+func SaveWorkflow(db rdbmstool.DbHandlerProxy, schemaName string, workflow *Workflow) error {
+	if err := workflow.Validate(); err != nil {
+		return err
+	}
+
+	schemaInfo, infoErr := GetSchemaInfo(db, schemaName)
+	if infoErr != nil {
+		return infoErr
+	}
+	if schemaInfo == nil {
+		return ErrSchemaInfoNotFound{msg: schemaName + " not found in database"}
+	}
+
+	if err := checkWorkflowStatesInUse(db, schemaInfo.ID, schemaName, workflow); err != nil {
+		return err
+	}
+
+	_, dbErr := db.Exec(`INSERT INTO doc_schema_workflow (schema_id, definition) VALUES (?,?)
+	ON DUPLICATE KEY UPDATE definition = VALUES(definition)`, schemaInfo.ID, workflow.JSON())
+	if dbErr != nil {
+		return fmt.Errorf("failed to save workflow of %s into database: %s", schemaName, dbErr.Error())
+	}
+
+	return nil
+}
+
+//checkWorkflowStatesInUse check every state document records are in is declared by workflow,
+//record without state is left out since it is at initial state, see GetCurrentState
+func checkWorkflowStatesInUse(db rdbmstool.DbHandlerProxy, schemaID string, schemaName string,
+	workflow *Workflow) error {
+	rows, rowsErr := db.Query(`SELECT DISTINCT state FROM doc_record WHERE schema_id = ? AND state <> ''
+	ORDER BY state`, schemaID)
+	if rowsErr != nil {
+		return fmt.Errorf("error encounter access database: %s", rowsErr.Error())
+	}
+	defer rows.Close()
+
+	declared := map[string]bool{}
+	for _, state := range workflow.States {
+		declared[state] = true
+	}
+
+	missing := []string{}
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return fmt.Errorf("failed to fetch record from database: %s", err.Error())
+		}
+
+		if !declared[state] {
+			missing = append(missing, state)
+		}
+	}
+
+	if len(missing) > 0 {
+		return ErrWorkflowStateInUse{
+			msg: fmt.Sprintf("%s records are still in states not declared by workflow: %s",
+				schemaName, strings.Join(missing, ", ")),
+			States: missing,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/guinso/gxdoc/testutil"
+)
+
+func TestParseWorkflowFromJSON(t *testing.T) {
+	_, err := ParseWorkflowFromJSON(`{
+		"initialState": "draft",
+		"states": ["draft", "submitted"],
+		"transitions": [{"name": "submit", "from": ["draft"], "to": "approved"}]
+	}`)
+	if err == nil {
+		t.Errorf("expect undeclared target state is rejected")
+	}
+
+	_, err = ParseWorkflowFromJSON(`{"initialState": "new", "states": ["draft"]}`)
+	if err == nil {
+		t.Errorf("expect undeclared initial state is rejected")
+	}
+}
+
+func TestTransitionCheck(t *testing.T) {
+	workflow, err := ParseWorkflowFromJSON(`{
+		"initialState": "draft",
+		"states": ["draft", "submitted", "approved"],
+		"transitions": [
+			{"name": "submit", "from": ["draft"], "to": "submitted"},
+			{"name": "approve", "from": ["submitted"], "to": "approved", "roles": ["manager"]}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if err = workflow.GetTransition("submit").Check("draft", nil); err != nil {
+		t.Errorf("expect submit is allowed from draft: %s", err.Error())
+	}
+
+	if _, ok := workflow.GetTransition("submit").Check("approved", nil).(ErrTransitionNotAllowed); !ok {
+		t.Errorf("expect submit is not allowed from approved")
+	}
+
+	if _, ok := workflow.GetTransition("approve").Check("submitted", []string{"clerk"}).(ErrTransitionNotAuthorize); !ok {
+		t.Errorf("expect approve is rejected without manager role")
+	}
+
+	if err = workflow.GetTransition("approve").Check("submitted", []string{"clerk", "manager"}); err != nil {
+		t.Errorf("expect approve is allowed with manager role: %s", err.Error())
+	}
+
+	if workflow.GetTransition("reject") != nil {
+		t.Errorf("expect undeclared transition is not found")
+	}
+}
+
+func TestWorkflowGetCurrentState(t *testing.T) {
+	workflow := Workflow{InitialState: "draft", States: []string{"draft", "submitted"}}
+
+	if state := workflow.GetCurrentState(""); state != "draft" {
+		t.Errorf("expect record without state is at initial state but get '%s'", state)
+	}
+	if state := workflow.GetCurrentState("submitted"); state != "submitted" {
+		t.Errorf("expect record state submitted but get '%s'", state)
+	}
+}
+
+func TestSaveWorkflowStateInUse(t *testing.T) {
+	db, dbErr := testutil.GetTestDB()
+	if dbErr != nil {
+		t.Fatal(dbErr)
+		return
+	}
+
+	trx, trxErr := db.Begin()
+	if trxErr != nil {
+		t.Fatal(trxErr)
+		return
+	}
+
+	defer trx.Rollback()
+
+	//record created before workflow is declared has no state
+	record, addErr := AddRecord(trx, "pr", 1, `{"qty": 1, "pr number": "PR01"}`, "tester")
+	if addErr != nil {
+		t.Fatal(addErr)
+		return
+	}
+
+	workflow := &Workflow{
+		InitialState: "draft",
+		States:       []string{"draft", "submitted"},
+		Transitions:  []Transition{{Name: "submit", From: []string{"draft"}, To: "submitted"}},
+	}
+	if err := SaveWorkflow(trx, "pr", workflow); err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	record, transitErr := TransitRecord(trx, "pr", record.ID, "submit", "tester", nil)
+	if transitErr != nil {
+		t.Fatalf("expect record without state transit from initial state: %s", transitErr.Error())
+		return
+	}
+	if record.State != "submitted" {
+		t.Errorf("expect record state submitted but get '%s'", record.State)
+	}
+
+	//submitted is dropped while record is still in it
+	err := SaveWorkflow(trx, "pr", &Workflow{InitialState: "draft", States: []string{"draft"}})
+	inUseErr, ok := err.(ErrWorkflowStateInUse)
+	if !ok {
+		t.Fatalf("expect ErrWorkflowStateInUse but get %v", err)
+		return
+	}
+	if len(inUseErr.States) != 1 || inUseErr.States[0] != "submitted" {
+		t.Errorf("expect state submitted is in use but get %v", inUseErr.States)
+	}
+}
@@ -0,0 +1,200 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/guinso/gxschema"
+)
+
+//DataSchemaLocationAttr attribute of <dxdoc> in schema XML response which point to XSD of document data
+//it is informative only and removed when schema XML is submitted
+const DataSchemaLocationAttr = "dataSchemaLocation"
+
+//GetXSDURL get path of XSD export of schema revision
+func GetXSDURL(name string, revision int) string {
+	return fmt.Sprintf("/api/document/schemas/%s/revisions/%d/export.xsd", url.PathEscape(name), revision)
+}
+
+//ToXSD convert document schema into XML Schema (XSD) of document data
+//	root element is named after schema, child elements follow item order of schema
+//	refs (optional) are references of the schema revision, referencing item is exported as 36 characters ID
+//	computed (optional) are computed fields of the schema revision, exported as optional element
+//return error if schema or item name is not a valid XML element name
+func ToXSD(schema *gxschema.DxDoc, refs []Reference, computed []ComputedField) ([]byte, error) {
+	if !IsXMLName(schema.Name) {
+		return nil, fmt.Errorf("schema name '%s' is not a valid XML element name", schema.Name)
+	}
+
+	refPaths := map[string]bool{}
+	for _, ref := range refs {
+		refPaths[ref.ItemPath] = true
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString(xml.Header)
+	buffer.WriteString(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">` + "\n")
+	fmt.Fprintf(&buffer, "  <!-- generated from gxdoc schema %s revision %d -->\n", escapeXMLComment(schema.Name), schema.Revision)
+	fmt.Fprintf(&buffer, "  <xs:element name=\"%s\">\n", schema.Name)
+
+	if err := writeXSDComplexType(&buffer, schema.Items, "", refPaths, GetComputedPaths(computed), "    "); err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString("  </xs:element>\n")
+	buffer.WriteString("</xs:schema>\n")
+
+	return buffer.Bytes(), nil
+}
+
+func writeXSDComplexType(buffer *bytes.Buffer, items []gxschema.DxItem, path string,
+	refPaths map[string]bool, computedPaths map[string]bool, indent string) error {
+	buffer.WriteString(indent + "<xs:complexType>\n")
+	buffer.WriteString(indent + "  <xs:sequence>\n")
+
+	for _, item := range items {
+		info, infoErr := GetItemInfo(item)
+		if infoErr != nil {
+			return infoErr
+		}
+		if !IsXMLName(info.Name) {
+			return fmt.Errorf("item name '%s' is not a valid XML element name", info.Name)
+		}
+
+		itemPath := info.Name
+		if path != "" {
+			itemPath = path + "/" + info.Name
+		}
+
+		occurs := ""
+		if info.IsOptional || computedPaths[itemPath] {
+			occurs += ` minOccurs="0"`
+		}
+		if info.IsArray {
+			occurs += ` maxOccurs="unbounded"`
+		}
+
+		elementIndent := indent + "    "
+		if computedPaths[itemPath] {
+			buffer.WriteString(elementIndent + "<!-- computed by server, must not be supplied -->\n")
+		}
+		switch info.Kind {
+		case ItemInt:
+			fmt.Fprintf(buffer, "%s<xs:element name=\"%s\" type=\"xs:long\"%s/>\n", elementIndent, info.Name, occurs)
+		case ItemBool:
+			fmt.Fprintf(buffer, "%s<xs:element name=\"%s\" type=\"xs:boolean\"%s/>\n", elementIndent, info.Name, occurs)
+		case ItemFile:
+			fmt.Fprintf(buffer, "%s<xs:element name=\"%s\" type=\"xs:string\"%s/>\n", elementIndent, info.Name, occurs)
+		case ItemStr:
+			lenLimit := info.LenLimit
+			if refPaths[itemPath] {
+				lenLimit = referenceIDLength
+			}
+			if lenLimit == 0 {
+				fmt.Fprintf(buffer, "%s<xs:element name=\"%s\" type=\"xs:string\"%s/>\n", elementIndent, info.Name, occurs)
+				continue
+			}
+
+			writeXSDSimpleType(buffer, elementIndent, info.Name, occurs, "xs:string",
+				fmt.Sprintf("<xs:maxLength value=\"%d\"/>", lenLimit))
+		case ItemDecimal:
+			writeXSDSimpleType(buffer, elementIndent, info.Name, occurs, "xs:decimal",
+				fmt.Sprintf("<xs:fractionDigits value=\"%d\"/>", info.Precision))
+		case ItemSection:
+			fmt.Fprintf(buffer, "%s<xs:element name=\"%s\"%s>\n", elementIndent, info.Name, occurs)
+			if err := writeXSDComplexType(buffer, info.Items, itemPath, refPaths, computedPaths, elementIndent+"  "); err != nil {
+				return err
+			}
+			buffer.WriteString(elementIndent + "</xs:element>\n")
+		}
+	}
+
+	buffer.WriteString(indent + "  </xs:sequence>\n")
+	buffer.WriteString(indent + "</xs:complexType>\n")
+
+	return nil
+}
+
+func writeXSDSimpleType(buffer *bytes.Buffer, indent string, name string, occurs string, base string, facet string) {
+	fmt.Fprintf(buffer, "%s<xs:element name=\"%s\"%s>\n", indent, name, occurs)
+	buffer.WriteString(indent + "  <xs:simpleType>\n")
+	fmt.Fprintf(buffer, "%s    <xs:restriction base=\"%s\">\n", indent, base)
+	buffer.WriteString(indent + "      " + facet + "\n")
+	buffer.WriteString(indent + "    </xs:restriction>\n")
+	buffer.WriteString(indent + "  </xs:simpleType>\n")
+	buffer.WriteString(indent + "</xs:element>\n")
+}
+
+//IsXMLName check name can be used as XML element name without namespace prefix
+func IsXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+
+	for index, char := range name {
+		isLetter := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char > 0x7f
+		if index == 0 && !isLetter {
+			return false
+		}
+		if !isLetter && !(char >= '0' && char <= '9') && char != '-' && char != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func escapeXMLComment(text string) string {
+	return strings.Replace(text, "--", "- -", -1)
+}
+
+//SetDataSchemaLocation set dataSchemaLocation attribute of <dxdoc> root element
+func SetDataSchemaLocation(xmlStr string, location string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	buffer := bytes.Buffer{}
+	encoder := xml.NewEncoder(&buffer)
+
+	isRoot := true
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		}
+		if tokenErr != nil {
+			return "", fmt.Errorf("invalid XML: %s", tokenErr.Error())
+		}
+
+		if element, ok := token.(xml.StartElement); ok && isRoot {
+			isRoot = false
+			element.Attr = append(removeXMLAttr(element.Attr, DataSchemaLocationAttr),
+				xml.Attr{Name: xml.Name{Local: DataSchemaLocationAttr}, Value: location})
+			token = element
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return "", fmt.Errorf("failed to rewrite XML: %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
+
+func removeXMLAttr(attrs []xml.Attr, name string) []xml.Attr {
+	results := []xml.Attr{}
+	for _, attr := range attrs {
+		if attr.Name.Local != name {
+			results = append(results, attr)
+		}
+	}
+
+	return results
+}
+
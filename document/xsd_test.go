@@ -0,0 +1,82 @@
+package document
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/guinso/gxschema"
+)
+
+func TestToXSD(t *testing.T) {
+	schema := &gxschema.DxDoc{
+		Name:     "invoice",
+		Revision: 2,
+		Items: []gxschema.DxItem{
+			gxschema.DxStr{Name: "invNo", EnableLenLimit: true, LenLimit: 10},
+			gxschema.DxStr{Name: "customer", IsOptional: true},
+			gxschema.DxSection{Name: "items", IsArray: true, Items: []gxschema.DxItem{
+				gxschema.DxInt{Name: "qty"},
+				gxschema.DxDecimal{Name: "price", Precision: 2},
+			}},
+			gxschema.DxDecimal{Name: "total", Precision: 2},
+		},
+	}
+
+	xsdRaw, err := ToXSD(schema, []Reference{{ItemPath: "customer", TargetSchema: "customer"}},
+		[]ComputedField{{Path: "total", Expression: "1"}})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	xsdStr := string(xsdRaw)
+	if err := xml.Unmarshal(xsdRaw, new(struct{})); err != nil {
+		t.Errorf("expect well formed XSD but get error: %s", err.Error())
+	}
+
+	expects := []string{
+		`<xs:element name="invoice">`,
+		`<xs:maxLength value="10"/>`,
+		`<xs:element name="customer" minOccurs="0">`,
+		`<xs:maxLength value="36"/>`,
+		`<xs:element name="items" maxOccurs="unbounded">`,
+		`<xs:element name="qty" type="xs:long"/>`,
+		`<xs:fractionDigits value="2"/>`,
+		`<!-- computed by server, must not be supplied -->`,
+		`<xs:element name="total" minOccurs="0">`,
+	}
+	for _, expect := range expects {
+		if !strings.Contains(xsdStr, expect) {
+			t.Errorf("expect XSD contains %s but get:\n%s", expect, xsdStr)
+		}
+	}
+
+	schema.Items = append(schema.Items, gxschema.DxInt{Name: "pr number"})
+	if _, err := ToXSD(schema, nil, nil); err == nil {
+		t.Errorf("expect item name with space is rejected")
+	}
+}
+
+func TestSetDataSchemaLocation(t *testing.T) {
+	xmlStr, err := SetDataSchemaLocation(`<dxdoc name="invoice" revision="2"><dxint name="qty"></dxint></dxdoc>`,
+		GetXSDURL("invoice", 2))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	expect := `dataSchemaLocation="/api/document/schemas/invoice/revisions/2/export.xsd"`
+	if !strings.Contains(xmlStr, expect) {
+		t.Errorf("expect %s in %s", expect, xmlStr)
+	}
+
+	cleanStr, _, extractErr := ExtractReferences(xmlStr)
+	if extractErr != nil {
+		t.Fatal(extractErr)
+		return
+	}
+	if strings.Contains(cleanStr, DataSchemaLocationAttr) {
+		t.Errorf("expect %s is removed from submitted schema but get %s", DataSchemaLocationAttr, cleanStr)
+	}
+}
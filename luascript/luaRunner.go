@@ -50,6 +50,35 @@ func GetLuaRunner(db *sql.DB, r *http.Request) *lua.LState {
 	// log.Println(fmt.Sprintf("Lua execute in %s", elapsed))
 }
 
+//GetSandboxRunner get lua runner for schema rule scripts and computed fields
+//	only base, table, string and math libraries are available; no database, file, OS nor module access
+func GetSandboxRunner() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	//base library can still reach file system and module loader
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	L.SetGlobal("parseDateStr", L.NewFunction(parseDateTimeString))
+
+	return L
+}
+
 //LuaParseDateTimeString parse date time string
 //accepted string format is 2018-07-31 14:32:07
 func parseDateTimeString(L *lua.LState) int {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/guinso/gxdoc/bootSequence"
 	"github.com/guinso/gxdoc/configuration"
@@ -12,6 +13,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	fmt.Print("loading configuration file...")
 	configErr := configuration.LoadINIConfigFile()
 	if configErr != nil {
@@ -27,6 +32,7 @@ func main() {
 		panic(dbErr)
 	}
 	util.SetDB(db)
+	util.SetTrustRoleHeader(configuration.GetConfig().TrustRoleHeader)
 	fmt.Println("\t\t[OK]")
 
 	fmt.Print("creating directories...")
@@ -25,14 +25,173 @@ CREATE TABLE `doc_schema_revision` (
   `revision` int(11) NOT NULL,
   `xml_definition` text NOT NULL,
   `remark` text NOT NULL,
+  `content_hash` char(64) NOT NULL DEFAULT '',
+  `prev_hash` char(64) NOT NULL DEFAULT '',
   PRIMARY KEY (`schema_id`,`revision`),
   CONSTRAINT `doc_schema_revision_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
-INSERT INTO `doc_schema_revision` (`schema_id`, `revision`, `xml_definition`, `remark`) VALUES
-('1984aa4b-6093-490b-b549-d202095c5e33',	-1,	'<dxdoc name=\"pr\" revision=\"1\" id=\"2\">\r\n<dxint name=\"qty\"></dxint>\r\n<dxstr name=\"pr number\" lenLimit=\"6\"></dxstr>\r\n</dxdoc>',	''),
-('1984aa4b-6093-490b-b549-d202095c5e33',	1,	'<dxdoc name=\"pr\" revision=\"1\" id=\"2\">\r\n<dxint name=\"qty\"></dxint>\r\n<dxstr name=\"pr number\" lenLimit=\"6\"></dxstr>\r\n</dxdoc>',	''),
-('733bee1b-f79a-4cb7-b675-842317b994b5',	1,	'<dxdoc name=\"invoice\" revision=\"1\" id=\"1\"><dxstr name=\"invNo\"></dxstr><dxint name=\"totalQty\" isOptional=\"true\"></dxint><dxdecimal name=\"price\" precision=\"2\"></dxdecimal></dxdoc>',	''),
-('733bee1b-f79a-4cb7-b675-842317b994b5',	2,	'<dxdoc name=\"invoice\" revision=\"2\" id=\"1\"><dxstr name=\"invNo\"></dxstr><dxint name=\"totalQty\" isOptional=\"true\"></dxint><dxdecimal name=\"price\" precision=\"2\"></dxdecimal></dxdoc>',	'');
+INSERT INTO `doc_schema_revision` (`schema_id`, `revision`, `xml_definition`, `remark`, `content_hash`, `prev_hash`) VALUES
+('1984aa4b-6093-490b-b549-d202095c5e33',	-1,	'<dxdoc name=\"pr\" revision=\"1\" id=\"2\">\r\n<dxint name=\"qty\"></dxint>\r\n<dxstr name=\"pr number\" lenLimit=\"6\"></dxstr>\r\n</dxdoc>',	'',	'',	''),
+('1984aa4b-6093-490b-b549-d202095c5e33',	1,	'<dxdoc name=\"pr\" revision=\"1\" id=\"2\">\r\n<dxint name=\"qty\"></dxint>\r\n<dxstr name=\"pr number\" lenLimit=\"6\"></dxstr>\r\n</dxdoc>',	'',	'35bf9bd099ecf536c51afa4389298ab261e718829a665d15c82d216b9b71fd70',	''),
+('733bee1b-f79a-4cb7-b675-842317b994b5',	1,	'<dxdoc name=\"invoice\" revision=\"1\" id=\"1\"><dxstr name=\"invNo\"></dxstr><dxint name=\"totalQty\" isOptional=\"true\"></dxint><dxdecimal name=\"price\" precision=\"2\"></dxdecimal></dxdoc>',	'',	'03b63cd5c1b306d66a114cb31324dcfb3741eed81e9470540ec6d981943d2ca3',	''),
+('733bee1b-f79a-4cb7-b675-842317b994b5',	2,	'<dxdoc name=\"invoice\" revision=\"2\" id=\"1\"><dxstr name=\"invNo\"></dxstr><dxint name=\"totalQty\" isOptional=\"true\"></dxint><dxdecimal name=\"price\" precision=\"2\"></dxdecimal></dxdoc>',	'',	'7ec057e79ca36a3629619d2c006352dcddbb4d8e22f9f7b298373cf6644f6bd2',	'03b63cd5c1b306d66a114cb31324dcfb3741eed81e9470540ec6d981943d2ca3');
+
+DROP TABLE IF EXISTS `audit_log`;
+CREATE TABLE `audit_log` (
+  `id` char(36) NOT NULL,
+  `actor` char(100) NOT NULL,
+  `created` datetime NOT NULL,
+  `action` char(50) NOT NULL,
+  `target_schema` char(100) NOT NULL,
+  `target_document` char(36) NOT NULL DEFAULT '',
+  `before_data` mediumtext NOT NULL,
+  `after_data` mediumtext NOT NULL,
+  `request_id` char(64) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `created` (`created`),
+  KEY `target_schema` (`target_schema`),
+  KEY `request_id` (`request_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_workflow`;
+CREATE TABLE `doc_schema_workflow` (
+  `schema_id` char(36) NOT NULL,
+  `definition` text NOT NULL,
+  PRIMARY KEY (`schema_id`),
+  CONSTRAINT `doc_schema_workflow_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_record`;
+CREATE TABLE `doc_record` (
+  `id` char(36) NOT NULL,
+  `schema_id` char(36) NOT NULL,
+  `number` char(100) DEFAULT NULL,
+  `revision` int(11) NOT NULL,
+  `state` char(50) NOT NULL DEFAULT '',
+  `version` int(11) NOT NULL,
+  `data` mediumtext NOT NULL,
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `schema_number` (`schema_id`,`number`),
+  CONSTRAINT `doc_record_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_record_version`;
+CREATE TABLE `doc_record_version` (
+  `record_id` char(36) NOT NULL,
+  `version` int(11) NOT NULL,
+  `action` char(100) NOT NULL,
+  `state` char(50) NOT NULL DEFAULT '',
+  `data` mediumtext NOT NULL,
+  `actor` char(100) NOT NULL,
+  `created` datetime NOT NULL,
+  `content_hash` char(64) NOT NULL,
+  `prev_hash` char(64) NOT NULL DEFAULT '',
+  PRIMARY KEY (`record_id`,`version`),
+  CONSTRAINT `doc_record_version_ibfk_1` FOREIGN KEY (`record_id`) REFERENCES `doc_record` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_reference`;
+CREATE TABLE `doc_schema_reference` (
+  `schema_id` char(36) NOT NULL,
+  `revision` int(11) NOT NULL,
+  `item_path` char(200) NOT NULL,
+  `target_schema` char(100) NOT NULL,
+  `target_revision` int(11) NOT NULL DEFAULT '0',
+  `is_optional` tinyint(1) NOT NULL DEFAULT '0',
+  `is_array` tinyint(1) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`schema_id`,`revision`,`item_path`),
+  CONSTRAINT `doc_schema_reference_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_rule`;
+CREATE TABLE `doc_schema_rule` (
+  `schema_id` char(36) NOT NULL,
+  `revision` int(11) NOT NULL,
+  `script` text NOT NULL,
+  PRIMARY KEY (`schema_id`,`revision`),
+  CONSTRAINT `doc_schema_rule_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_computed`;
+CREATE TABLE `doc_schema_computed` (
+  `schema_id` char(36) NOT NULL,
+  `revision` int(11) NOT NULL,
+  `item_path` char(200) NOT NULL,
+  `seq` int(11) NOT NULL,
+  `expression` text NOT NULL,
+  PRIMARY KEY (`schema_id`,`revision`,`item_path`),
+  CONSTRAINT `doc_schema_computed_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_include`;
+CREATE TABLE `doc_schema_include` (
+  `schema_id` char(36) NOT NULL,
+  `revision` int(11) NOT NULL,
+  `seq` int(11) NOT NULL,
+  `item_path` char(200) NOT NULL,
+  `position` int(11) NOT NULL,
+  `target_schema` char(100) NOT NULL,
+  `target_revision` int(11) NOT NULL,
+  `section` char(100) NOT NULL DEFAULT '',
+  PRIMARY KEY (`schema_id`,`revision`,`seq`),
+  KEY `target_schema` (`target_schema`),
+  CONSTRAINT `doc_schema_include_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_locale`;
+CREATE TABLE `doc_schema_locale` (
+  `schema_id` char(36) NOT NULL,
+  `revision` int(11) NOT NULL,
+  `locale` char(35) NOT NULL,
+  `metadata` text NOT NULL,
+  PRIMARY KEY (`schema_id`,`revision`,`locale`),
+  CONSTRAINT `doc_schema_locale_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_record_reference`;
+CREATE TABLE `doc_record_reference` (
+  `record_id` char(36) NOT NULL,
+  `item_path` char(200) NOT NULL,
+  `target_record_id` char(36) NOT NULL,
+  PRIMARY KEY (`record_id`,`item_path`,`target_record_id`),
+  KEY `target_record_id` (`target_record_id`),
+  CONSTRAINT `doc_record_reference_ibfk_1` FOREIGN KEY (`record_id`) REFERENCES `doc_record` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `doc_record_reference_ibfk_2` FOREIGN KEY (`target_record_id`) REFERENCES `doc_record` (`id`) ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_schema_numbering`;
+CREATE TABLE `doc_schema_numbering` (
+  `schema_id` char(36) NOT NULL,
+  `pattern` char(100) NOT NULL,
+  `reset_period` char(10) NOT NULL,
+  PRIMARY KEY (`schema_id`),
+  CONSTRAINT `doc_schema_numbering_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_numbering_counter`;
+CREATE TABLE `doc_numbering_counter` (
+  `schema_id` char(36) NOT NULL,
+  `period_key` char(10) NOT NULL,
+  `last_value` int(11) NOT NULL,
+  PRIMARY KEY (`schema_id`,`period_key`),
+  CONSTRAINT `doc_numbering_counter_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `doc_numbering_allocation`;
+CREATE TABLE `doc_numbering_allocation` (
+  `schema_id` char(36) NOT NULL,
+  `period_key` char(10) NOT NULL,
+  `seq` int(11) NOT NULL,
+  `number` char(100) NOT NULL,
+  `record_id` char(36) NOT NULL,
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`schema_id`,`period_key`,`seq`),
+  UNIQUE KEY `schema_number` (`schema_id`,`number`),
+  KEY `record_id` (`record_id`),
+  CONSTRAINT `doc_numbering_allocation_ibfk_1` FOREIGN KEY (`schema_id`) REFERENCES `doc_schema` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
 -- 2018-06-12 04:10:42
\ No newline at end of file
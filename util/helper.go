@@ -19,12 +19,19 @@ import (
 )
 
 var productionDB *sql.DB
+var trustRoleHeader = false
 
 //SetDB set and hold production database handler
 func SetDB(db *sql.DB) {
 	productionDB = db
 }
 
+//SetTrustRoleHeader enable or disable reading requestor's name and roles from 'X-Actor' and 'X-Roles' header
+//NOTE: only enable when server is behind trusted proxy which authenticate user and set both headers
+func SetTrustRoleHeader(trust bool) {
+	trustRoleHeader = trust
+}
+
 //GetDB get production database handler
 func GetDB() *sql.DB {
 	return productionDB
@@ -60,7 +67,8 @@ func SendHTTPResponseJSON(w http.ResponseWriter, json string) {
 func SendHTTPClientErrorJSON(w http.ResponseWriter, httpCode int, errorCode int, errorMessage string) {
 	w.Header().Set("Content-Type", "application/json; charset=utf8")
 	w.WriteHeader(httpCode)
-	w.Write([]byte(fmt.Sprintf(`{"errorCode":%d, "errorMessage":"%s"}`, errorCode, errorMessage)))
+	msgRaw, _ := json.Marshal(errorMessage) //escape quotes and control characters
+	w.Write([]byte(fmt.Sprintf(`{"errorCode":%d, "errorMessage":%s}`, errorCode, msgRaw)))
 }
 
 //SendHTTPServerErrorJSON send HTTP error response to client
@@ -81,6 +89,39 @@ func GetHTTPRequestBody(r *http.Request) (string, error) {
 	return string(bodyRaw), nil
 }
 
+//GetRequestActor get requestor's name from 'X-Actor' header, fallback to 'anonymous'
+//NOTE: header is client supplied, it is ignored ('anonymous') unless trusted by SetTrustRoleHeader
+func GetRequestActor(r *http.Request) string {
+	if !trustRoleHeader {
+		return "anonymous"
+	}
+
+	actor := strings.TrimSpace(r.Header.Get("X-Actor"))
+	if actor == "" {
+		return "anonymous"
+	}
+
+	return actor
+}
+
+//GetRequestRoles get requestor's roles from comma separated 'X-Roles' header
+//NOTE: header is client supplied, it is ignored (no roles) unless trusted by SetTrustRoleHeader
+func GetRequestRoles(r *http.Request) []string {
+	roles := []string{}
+	if !trustRoleHeader {
+		return roles
+	}
+
+	for _, role := range strings.Split(r.Header.Get("X-Roles"), ",") {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
 //IsPOST check request HTTP is POST method
 func IsPOST(r *http.Request) bool {
 	return strings.Compare(r.Method, "POST") == 0
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetRequestActorAndRoles(t *testing.T) {
+	defer SetTrustRoleHeader(false)
+
+	r := httptest.NewRequest("POST", "/api/document/invoice/records", nil)
+	r.Header.Set("X-Actor", "alice")
+	r.Header.Set("X-Roles", "clerk, manager")
+
+	SetTrustRoleHeader(false)
+	if actor := GetRequestActor(r); actor != "anonymous" {
+		t.Errorf("expect untrusted X-Actor is ignored but get %s", actor)
+	}
+	if roles := GetRequestRoles(r); len(roles) != 0 {
+		t.Errorf("expect untrusted X-Roles is ignored but get %v", roles)
+	}
+
+	SetTrustRoleHeader(true)
+	if actor := GetRequestActor(r); actor != "alice" {
+		t.Errorf("expect trusted X-Actor alice but get %s", actor)
+	}
+	if roles := GetRequestRoles(r); strings.Join(roles, ",") != "clerk,manager" {
+		t.Errorf("expect trusted X-Roles clerk and manager but get %v", roles)
+	}
+
+	r.Header.Del("X-Actor")
+	if actor := GetRequestActor(r); actor != "anonymous" {
+		t.Errorf("expect anonymous when trusted X-Actor is absent but get %s", actor)
+	}
+}